@@ -1,9 +1,18 @@
 package cmd
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
-	"regexp"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/joshrosso/nexp/config"
 	ne "github.com/joshrosso/nexp/export"
@@ -12,14 +21,123 @@ import (
 
 func init() {
 	exportCmd.Flags().StringP("to-file", "o", "", "Write export content to file specified instead of standard out.")
-	exportCmd.Flags().StringP("format", "f", "markdown", "Export format for page.")
+	exportCmd.Flags().StringP("format", "f", "markdown", "Export format for page. Several formats may be"+
+		" given, separated by commas (e.g. markdown,json), with --to-file or --stdin. Each format is"+
+		" written to its own file, named after --to-file with the format's extension in place of its own"+
+		" (e.g. -o page.md writes page.md and page.json).")
+	exportCmd.Flags().String("profile", "", "Use the token of the named profile saved with"+
+		" 'nexp login --profile'.")
 	exportCmd.Flags().StringP("token", "t", "", "Define an API token to use for"+
 		" operations. By default the env var NOTION_TOKEN is used or the token value"+
 		" in ${HOME}/.config/nexp.yaml")
-	exportCmd.Flags().StringP("image-directory", "d", "images", "Location to store Notion-hosted images.")
+	exportCmd.Flags().StringP("image-directory", "d", "images", "Location to store Notion-hosted images. When"+
+		" --to-file is set, the default is relative to the file's directory.")
 	exportCmd.Flags().Bool("disable-images", false, "Skips all images found in pages.")
+	exportCmd.Flags().Bool("keep-remote-image-urls", false, "Link to images hosted in Notion at their"+
+		" original URL rather than downloading them. These URLs expire, typically after an hour.")
 	exportCmd.Flags().Bool("skip-empty-paragraphs", false, "Omit any empty paragraph blocks from the output.")
 	exportCmd.Flags().Bool("overwrite-existing-images", false, "Redownloads images even existing copies are found on the filesytem.")
+	exportCmd.Flags().Bool("dedupe-images", false, "Reuse an existing image or file with the same content rather"+
+		" than keeping a duplicate.")
+	exportCmd.Flags().String("file-mode", "0644", "Permissions, in octal, of the pages, images, and files"+
+		" written by the export.")
+	exportCmd.Flags().String("dir-mode", "0755", "Permissions, in octal, of the directories created by the export.")
+	exportCmd.Flags().Int("download-concurrency", 1, "Number of images and files to download at once.")
+	exportCmd.Flags().Bool("image-names-from-captions", false, "Name downloaded images using their caption rather than"+
+		" their Notion UUID.")
+	exportCmd.Flags().Bool("frontmatter", false, "Add YAML frontmatter generated from the page's properties"+
+		" and omit the title heading.")
+	exportCmd.Flags().String("frontmatter-format", "yaml", "Format of the frontmatter added by --frontmatter:"+
+		" yaml, toml (delimited by +++), or json.")
+	exportCmd.Flags().Bool("reading-time", false, "Add the minutes it takes to read the page to the"+
+		" frontmatter added by --frontmatter, as reading_time.")
+	exportCmd.Flags().Int("words-per-minute", 200, "Reading speed --reading-time is estimated at.")
+	exportCmd.Flags().Bool("exclude-code-from-word-count", false, "Leave the contents of code blocks out"+
+		" of the words --reading-time counts.")
+	exportCmd.Flags().String("date-format", "", "Go time layout to format dates in the frontmatter and"+
+		" database tables with, e.g. \"Jan 2, 2006\". By default dates are formatted as 2006-01-02.")
+	exportCmd.Flags().Bool("no-title", false, "Omit the page's title heading from the export.")
+	exportCmd.Flags().Bool("include-icon", false, "Prepend the page's emoji icon to its title.")
+	exportCmd.Flags().Bool("include-cover", false, "Add the page's cover image to the top of the page.")
+	exportCmd.Flags().Bool("no-callout-icons", false, "Omit the icon of callouts from the export.")
+	exportCmd.Flags().Bool("quote-children", false, "Render the blocks nested in a quote within the"+
+		" quote, in markdown, rather than indented beneath it.")
+	exportCmd.Flags().Bool("callouts-as-alerts", false, "Render callouts as GitHub alerts (e.g. > [!TIP])"+
+		" when their icon or color suggests one.")
+	exportCmd.Flags().Bool("footer-source-link", false, "Add a link to the page in Notion to the end of the export.")
+	exportCmd.Flags().Bool("footer-timestamp", false, "Add the time of the export to the end of the export.")
+	exportCmd.Flags().Bool("embed-videos", false, "Embed videos using HTML rather than linking to them.")
+	exportCmd.Flags().Bool("bold-column-headers", false, "Bold the first column of tables with a column header.")
+	exportCmd.Flags().String("table-alignment", "", "Alignment of every column of markdown tables: left,"+
+		" center, or right.")
+	exportCmd.Flags().StringSlice("column-alignments", nil, "Alignment of markdown table columns by"+
+		" position, overriding --table-alignment, e.g. left,,right. Empty entries use --table-alignment.")
+	exportCmd.Flags().Int("indent-width", 0, "Number of indent characters per level of nesting (default 4,"+
+		" or 1 with --indent-tabs).")
+	exportCmd.Flags().Bool("indent-tabs", false, "Indent nested blocks with tabs rather than spaces.")
+	exportCmd.Flags().Bool("hanging-indent", false, "Align the lines of multi-line list items with the"+
+		" item's text rather than its marker.")
+	exportCmd.Flags().StringToString("language-override", nil, "Map a Notion code block language to another"+
+		" name for syntax highlighting, e.g. shell=bash. May be repeated.")
+	exportCmd.Flags().String("markdown-flavor", "gfm", "Markdown dialect to render to-dos and tables"+
+		" for: gfm or commonmark.")
+	exportCmd.Flags().String("todo-style", "checkbox", "How markdown to-dos show whether they're checked:"+
+		" checkbox ([x]) or emoji (✅/⬜), for viewers that don't render task lists.")
+	exportCmd.Flags().String("ordered-list-style", "decimal", "Marker of numbered list items: decimal (1.),"+
+		" lower-alpha (a.), lower-roman (i.), or paren (1)).")
+	exportCmd.Flags().Bool("autolink-urls", false, "Link URLs typed as plain text, e.g. as"+
+		" <https://example.com> in markdown.")
+	exportCmd.Flags().Bool("resolve-link-titles", false, "Replace the text of links to Notion pages, when"+
+		" it's the link's URL, with the title of the page.")
+	exportCmd.Flags().Bool("resolve-user-names", false, "With --frontmatter, add the names of the users who"+
+		" created and last edited the page as created_by and last_edited_by. The integration must be able"+
+		" to read user information.")
+	exportCmd.Flags().Bool("caption-images", false, "Add the caption of each image beneath it.")
+	exportCmd.Flags().Int("max-depth", 0, "Levels of nested blocks to export, counting top-level blocks as"+
+		" the first, e.g. 1 exports only top-level blocks. By default every level is exported.")
+	exportCmd.Flags().Bool("mark-truncated", false, "Add \"...\" in place of the blocks left out by"+
+		" --max-depth.")
+	exportCmd.Flags().Bool("mark-unsupported", false, "Add a placeholder comment where blocks that can't be"+
+		" exported were left out.")
+	exportCmd.Flags().Bool("annotate-synced-blocks", false, "Add a comment naming the original block before"+
+		" the content of each synced block.")
+	exportCmd.Flags().Bool("heading-anchors", false, "Give every heading an anchor derived from its text.")
+	exportCmd.Flags().Bool("embed-block-ids", false, "Add a comment recording the Notion ID of each block"+
+		" before it.")
+	exportCmd.Flags().Bool("html-columns", false, "Wrap columns in HTML so they render side by side.")
+	exportCmd.Flags().String("from-block", "", "Export only the block, given as its UUID or a link"+
+		" copied from Notion, and the blocks nested in it. The page identifier is then optional.")
+	exportCmd.Flags().Bool("database", false, "Treat the identifier as a database and export its rows as a table.")
+	exportCmd.Flags().Bool("inline-databases", false, "Render the rows of databases embedded in a page as"+
+		" a table.")
+	exportCmd.Flags().Bool("database-as-pages", false, "With --database, also export each row as a page,"+
+		" linked from the table. Pages are written alongside the file specified by --to-file, or to the"+
+		" current directory.")
+	exportCmd.Flags().Bool("epub", false, "Export the page and its subpages, with their images, as an EPUB"+
+		" written to the file specified by --to-file. --format is ignored.")
+	exportCmd.Flags().Bool("stdin", false, "Read newline-delimited page identifiers from standard in and export"+
+		" each to its own file in --output-dir.")
+	exportCmd.Flags().String("output-dir", ".", "Directory pages exported with --stdin are written to.")
+	exportCmd.Flags().Bool("dry-run", false, "Render the export without downloading images and files or"+
+		" writing output, and summarize what would be downloaded and written.")
+	exportCmd.Flags().String("since", "", "Only export pages edited after this time, in RFC 3339 format"+
+		" (e.g. 2024-01-02T15:04:05Z). The files of other pages are left as is.")
+	exportCmd.Flags().Bool("skip-unchanged", false, "With --recursive or --database-as-pages, only rewrite the"+
+		" pages edited since the export recorded in manifest.json. The files of other pages are left as is.")
+	exportCmd.Flags().Bool("index", false, "With --recursive, --database-as-pages, or --stdin, also write an"+
+		" index linking to every exported page, nested by hierarchy, alongside the exported pages.")
+	exportCmd.Flags().BoolP("verbose", "v", false, "Log each page fetched, batch of blocks retrieved, and file"+
+		" downloaded to standard error.")
+	exportCmd.Flags().BoolP("recursive", "r", false, "Export subpages to their own files, linked from the page"+
+		" containing them. Files are written alongside the file specified by --to-file, or to the current"+
+		" directory.")
+
+	listCmd.Flags().String("profile", "", "Use the token of the named profile saved with"+
+		" 'nexp login --profile'.")
+
+	loginCmd.Flags().String("profile", "", "Save the token to the named profile, rather than as the"+
+		" token used by default.")
+	loginCmd.Flags().Bool("default", false, "With --profile, also use the profile by default.")
 }
 
 var rootCmd = &cobra.Command{
@@ -54,9 +172,19 @@ var loginCmd = &cobra.Command{
 	},
 }
 
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the Notion pages shared with your integration and their IDs.",
+	Run:   RunList,
+	CompletionOptions: cobra.CompletionOptions{
+		DisableDefaultCmd: true,
+	},
+}
+
 func SetupCommands() *cobra.Command {
 	rootCmd.AddCommand(exportCmd)
 	rootCmd.AddCommand(loginCmd)
+	rootCmd.AddCommand(listCmd)
 	return rootCmd
 }
 
@@ -64,74 +192,737 @@ func RunExport(cmd *cobra.Command, args []string) {
 	// ignore the error here as no format flag should result in an empty
 	// string.
 	f, _ := cmd.Flags().GetString("format")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	profile, _ := cmd.Flags().GetString("profile")
 
+	// a renderer is set up for each format, the first of which the exporter
+	// starts with.
+	renderers, err := resolveRenderers(f)
+	if err != nil {
+		fmt.Printf("Failed attaching renderer to exporter. Error: %s", err)
+		os.Exit(1)
+	}
 	eopts := ne.ExporterOptions{
 		NotionToken: "",
-		Format:      f,
-		Renderer:    nil,
+		Profile:     profile,
+		Renderer:    renderers[0],
+	}
+	if verbose {
+		eopts.Logger = os.Stderr
 	}
 	e, err := ne.NewExporter(eopts)
 	if err != nil {
 		fmt.Printf("Failed creating exporter. Error: %s", err)
 		os.Exit(1)
 	}
-	e.Renderer, err = ne.NewRenderer(f)
-	if err != nil {
-		fmt.Printf("Failed attaching renderer to exporter. Error: %s", err)
-		os.Exit(1)
+
+	fromStdin, _ := cmd.Flags().GetBool("stdin")
+	fromBlock, _ := cmd.Flags().GetString("from-block")
+	var pageID, blockID string
+	if fromBlock != "" {
+		// the block identifies what's exported, so a page isn't needed.
+		blockID, err = ne.ParseBlockID(fromBlock)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	} else if !fromStdin {
+		if len(args) < 1 {
+			fmt.Println("A proper page identifier was not provided.")
+			os.Exit(1)
+		}
+		// the page may be referenced by its UUID, with or without dashes, or
+		// by a URL copied from Notion.
+		pageID, err = ne.ParsePageID(args[0])
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	}
 
-	if len(args) < 1 {
-		fmt.Println("A proper page identifier was not provided.")
+	savePath, _ := cmd.Flags().GetString("image-directory")
+	ignoreImages, _ := cmd.Flags().GetBool("disable-images")
+	keepRemoteImageURLs, _ := cmd.Flags().GetBool("keep-remote-image-urls")
+	overwriteExistingImages, _ := cmd.Flags().GetBool("overwrite-existing-images")
+	skipEmptyParagraphs, _ := cmd.Flags().GetBool("skip-empty-paragraphs")
+	imageNamesFromCaptions, _ := cmd.Flags().GetBool("image-names-from-captions")
+	downloadConcurrency, _ := cmd.Flags().GetInt("download-concurrency")
+	dedupeImages, _ := cmd.Flags().GetBool("dedupe-images")
+	fileModeFlag, _ := cmd.Flags().GetString("file-mode")
+	fileMode, err := parseFileMode(fileModeFlag, 0600)
+	if err != nil {
+		fmt.Printf("Invalid --file-mode %s, %s.\n", fileModeFlag, err)
 		os.Exit(1)
 	}
-	// TODO(joshrosso): Needs more robust UUID detection
-	// notion expects all page references to be UUID 4 with dashes removed.
-	// this can be validated by looking at the argument passed for a 32
-	// character alphanumeric string.
-	reg, err := regexp.Compile("[a-z0-9]{32}$")
+	dirModeFlag, _ := cmd.Flags().GetString("dir-mode")
+	dirMode, err := parseFileMode(dirModeFlag, 0700)
 	if err != nil {
-		fmt.Println("Unexpected error compiling regex for page ID detection")
+		fmt.Printf("Invalid --dir-mode %s, %s.\n", dirModeFlag, err)
 		os.Exit(1)
 	}
-	pageID := reg.FindString(args[0])
-	if pageID == "" {
-		fmt.Printf("Could not detect valid page UUID for %s\n", args[0])
+	recursive, _ := cmd.Flags().GetBool("recursive")
+	database, _ := cmd.Flags().GetBool("database")
+	databaseAsPages, _ := cmd.Flags().GetBool("database-as-pages")
+	inlineDatabases, _ := cmd.Flags().GetBool("inline-databases")
+	frontmatter, _ := cmd.Flags().GetBool("frontmatter")
+	noTitle, _ := cmd.Flags().GetBool("no-title")
+	includeIcon, _ := cmd.Flags().GetBool("include-icon")
+	includeCover, _ := cmd.Flags().GetBool("include-cover")
+	htmlColumns, _ := cmd.Flags().GetBool("html-columns")
+	embedVideos, _ := cmd.Flags().GetBool("embed-videos")
+	noCalloutIcons, _ := cmd.Flags().GetBool("no-callout-icons")
+	calloutsAsAlerts, _ := cmd.Flags().GetBool("callouts-as-alerts")
+	quoteChildren, _ := cmd.Flags().GetBool("quote-children")
+	footerSourceLink, _ := cmd.Flags().GetBool("footer-source-link")
+	footerTimestamp, _ := cmd.Flags().GetBool("footer-timestamp")
+	boldColumnHeaders, _ := cmd.Flags().GetBool("bold-column-headers")
+	indentWidth, _ := cmd.Flags().GetInt("indent-width")
+	indentTabs, _ := cmd.Flags().GetBool("indent-tabs")
+	hangingIndent, _ := cmd.Flags().GetBool("hanging-indent")
+	languageOverrides, _ := cmd.Flags().GetStringToString("language-override")
+	captionImages, _ := cmd.Flags().GetBool("caption-images")
+	autolinkURLs, _ := cmd.Flags().GetBool("autolink-urls")
+	resolveLinkTitles, _ := cmd.Flags().GetBool("resolve-link-titles")
+	resolveUserNames, _ := cmd.Flags().GetBool("resolve-user-names")
+	dateFormat, _ := cmd.Flags().GetString("date-format")
+	readingTime, _ := cmd.Flags().GetBool("reading-time")
+	wordsPerMinute, _ := cmd.Flags().GetInt("words-per-minute")
+	excludeCodeFromWordCount, _ := cmd.Flags().GetBool("exclude-code-from-word-count")
+	frontmatterFormat, _ := cmd.Flags().GetString("frontmatter-format")
+	switch ne.FrontmatterFormat(frontmatterFormat) {
+	case ne.FrontmatterFormatYAML, ne.FrontmatterFormatTOML, ne.FrontmatterFormatJSON:
+	default:
+		fmt.Printf("Unknown frontmatter format %s, expected yaml, toml, or json.\n", frontmatterFormat)
+		os.Exit(1)
+	}
+	markdownFlavor, _ := cmd.Flags().GetString("markdown-flavor")
+	switch ne.MarkdownFlavor(markdownFlavor) {
+	case ne.MarkdownFlavorGFM, ne.MarkdownFlavorCommonMark:
+	default:
+		fmt.Printf("Unknown markdown flavor %s, expected gfm or commonmark.\n", markdownFlavor)
+		os.Exit(1)
+	}
+	orderedListStyle, _ := cmd.Flags().GetString("ordered-list-style")
+	switch ne.OrderedListStyle(orderedListStyle) {
+	case ne.OrderedListStyleDecimal, ne.OrderedListStyleLowerAlpha, ne.OrderedListStyleLowerRoman,
+		ne.OrderedListStyleParen:
+	default:
+		fmt.Printf("Unknown ordered list style %s, expected decimal, lower-alpha, lower-roman, or"+
+			" paren.\n", orderedListStyle)
+		os.Exit(1)
+	}
+	todoStyle, _ := cmd.Flags().GetString("todo-style")
+	switch ne.TodoStyle(todoStyle) {
+	case ne.TodoStyleCheckbox, ne.TodoStyleEmoji:
+	default:
+		fmt.Printf("Unknown to-do style %s, expected checkbox or emoji.\n", todoStyle)
+		os.Exit(1)
+	}
+	tableAlignment, _ := cmd.Flags().GetString("table-alignment")
+	columnAlignmentNames, _ := cmd.Flags().GetStringSlice("column-alignments")
+	var columnAlignments []ne.TableAlignment
+	for _, a := range append([]string{tableAlignment}, columnAlignmentNames...) {
+		switch ne.TableAlignment(a) {
+		case ne.TableAlignmentDefault, ne.TableAlignmentLeft, ne.TableAlignmentCenter,
+			ne.TableAlignmentRight:
+		default:
+			fmt.Printf("Unknown table alignment %s, expected left, center, or right.\n", a)
+			os.Exit(1)
+		}
+	}
+	for _, a := range columnAlignmentNames {
+		columnAlignments = append(columnAlignments, ne.TableAlignment(a))
+	}
+	markUnsupported, _ := cmd.Flags().GetBool("mark-unsupported")
+	maxDepth, _ := cmd.Flags().GetInt("max-depth")
+	markTruncated, _ := cmd.Flags().GetBool("mark-truncated")
+	embedBlockIDs, _ := cmd.Flags().GetBool("embed-block-ids")
+	headingAnchors, _ := cmd.Flags().GetBool("heading-anchors")
+	annotateSyncedBlocks, _ := cmd.Flags().GetBool("annotate-synced-blocks")
+	toFile, _ := cmd.Flags().GetString("to-file")
+	outputDir, _ := cmd.Flags().GetString("output-dir")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	epub, _ := cmd.Flags().GetBool("epub")
+	sinceFlag, _ := cmd.Flags().GetString("since")
+	var since time.Time
+	if sinceFlag != "" {
+		since, err = time.Parse(time.RFC3339, sinceFlag)
+		if err != nil {
+			fmt.Printf("Invalid --since time %s, expected RFC 3339 (e.g. 2024-01-02T15:04:05Z).\n",
+				sinceFlag)
+			os.Exit(1)
+		}
+	}
+	skipUnchanged, _ := cmd.Flags().GetBool("skip-unchanged")
+	if skipUnchanged && !recursive && !databaseAsPages {
+		fmt.Println("--skip-unchanged requires --recursive or --database-as-pages.")
+		os.Exit(1)
+	}
+	skippedSince := describeSkippedSince(since, skipUnchanged)
+	index, _ := cmd.Flags().GetBool("index")
+	if index && !recursive && !databaseAsPages && !fromStdin {
+		fmt.Println("--index requires --recursive, --database-as-pages, or --stdin.")
+		os.Exit(1)
+	}
+	if epub && toFile == "" {
+		fmt.Println("--epub requires --to-file.")
 		os.Exit(1)
 	}
-
-	savePath, _ := cmd.Flags().GetString("image-directory")
-	ignoreImages, _ := cmd.Flags().GetBool("disable-images")
-	overwriteExistingImages, _ := cmd.Flags().GetBool("overwrite-existing-images")
-	skipEmptyParagraphs, _ := cmd.Flags().GetBool("skip-empty-paragraphs")
 	ropts := ne.RenderOptions{
 		ImageOpts: ne.ImageSaveOptions{
-			SavePath:          savePath,
-			IgnoreImages:      ignoreImages,
-			OverwriteExisting: overwriteExistingImages,
+			SavePath:            savePath,
+			IgnoreImages:        ignoreImages,
+			KeepRemoteImageURLs: keepRemoteImageURLs,
+			OverwriteExisting:   overwriteExistingImages,
+			FilenameFromCaption: imageNamesFromCaptions,
+			DownloadConcurrency: downloadConcurrency,
+			DedupeByContent:     dedupeImages,
+			FileMode:            fileMode,
+			DirMode:             dirMode,
 		},
-		SkipEmptyParagraphs: skipEmptyParagraphs,
+		SkipEmptyParagraphs:       skipEmptyParagraphs,
+		Frontmatter:               frontmatter,
+		FrontmatterFormat:         ne.FrontmatterFormat(frontmatterFormat),
+		DateFormat:                dateFormat,
+		ReadingTime:               readingTime,
+		WordsPerMinute:            wordsPerMinute,
+		ExcludeCodeFromWordCount:  excludeCodeFromWordCount,
+		OmitPageHeader:            noTitle,
+		IncludeIcon:               includeIcon,
+		IncludeCover:              includeCover,
+		EmbedVideos:               embedVideos,
+		OmitCalloutIcons:          noCalloutIcons,
+		CalloutsAsAlerts:          calloutsAsAlerts,
+		QuoteChildren:             quoteChildren,
+		HTMLColumns:               htmlColumns,
+		MarkUnsupportedBlocks:     markUnsupported,
+		MaxDepth:                  maxDepth,
+		MarkTruncatedBlocks:       markTruncated,
+		EmbedBlockIDs:             embedBlockIDs,
+		AnnotateSyncedBlocks:      annotateSyncedBlocks,
+		HeadingAnchors:            headingAnchors,
+		FooterSourceLink:          footerSourceLink,
+		FooterTimestamp:           footerTimestamp,
+		BoldColumnHeaders:         boldColumnHeaders,
+		TableAlignment:            ne.TableAlignment(tableAlignment),
+		ColumnAlignments:          columnAlignments,
+		IndentWidth:               indentWidth,
+		HangingIndent:             hangingIndent,
+		LanguageOverrides:         languageOverrides,
+		CaptionImages:             captionImages,
+		AutolinkBareURLs:          autolinkURLs,
+		ResolveInternalLinkTitles: resolveLinkTitles,
+		ResolveUserNames:          resolveUserNames,
+		MarkdownFlavor:            ne.MarkdownFlavor(markdownFlavor),
+		TodoStyle:                 ne.TodoStyle(todoStyle),
+		OrderedListStyle:          ne.OrderedListStyle(orderedListStyle),
+		RecursePages:              recursive,
+		DatabaseAsPages:           databaseAsPages,
+		InlineChildDatabases:      inlineDatabases,
+		DryRun:                    dryRun,
+		EditedSince:               since,
+		SkipUnchangedPages:        skipUnchanged,
+		WriteIndex:                index,
+	}
+	if indentTabs {
+		ropts.IndentChar = "\t"
+	}
+	// pages are written to the directory of --to-file or, with --stdin, to
+	// the output directory.
+	var outDir string
+	switch {
+	case fromStdin:
+		outDir = outputDir
+	case toFile != "":
+		outDir = filepath.Dir(toFile)
+	}
+	if outDir != "" {
+		// images are saved next to the exported file, unless a directory was
+		// chosen explicitly, and are linked to relative to it.
+		if !cmd.Flags().Changed("image-directory") {
+			ropts.ImageOpts.SavePath = filepath.Join(outDir, savePath)
+		}
+		ropts.ImageOpts.LinkRelativeTo = outDir
+		// subpages are written next to the exported page so links between
+		// them resolve.
+		if recursive || databaseAsPages {
+			ropts.PagesDir = outDir
+		}
 	}
 
-	out, err := e.Render(pageID, ropts)
-	if err != nil {
-		fmt.Printf("Page exporting failed. Error: %s\n", err)
+	if blockID != "" && (fromStdin || epub || database) {
+		fmt.Println("--from-block can't be combined with --stdin, --epub, or --database.")
+		os.Exit(1)
+	}
+	if len(renderers) > 1 && toFile == "" && !fromStdin && !epub {
+		fmt.Println("Exporting to multiple formats requires --to-file or --stdin.")
 		os.Exit(1)
 	}
 
-	// check whether an output file was specified. If it was, write to the file
-	// as opposed to printing output to standard out.
-	toFile, _ := cmd.Flags().GetString("to-file")
+	if fromStdin {
+		// the page identifiers are read up front, as they're exported once
+		// per format.
+		ids, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Printf("Failed reading page identifiers from standard in, error: %s\n", err)
+			os.Exit(1)
+		}
+		exported := true
+		for _, r := range renderers {
+			e.Renderer = r
+			if !exportPages(e, bytes.NewReader(ids), outputDir, ne.ResolvePageFileExtension(r), ropts) {
+				exported = false
+			}
+		}
+		if !exported {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if epub {
+		f, err := os.OpenFile(toFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, fileMode)
+		if err != nil {
+			fmt.Printf("Failed to write file to %s, error: %s", toFile, err)
+			os.Exit(1)
+		}
+		err = e.ExportEPUB(f, pageID, ropts)
+		f.Close()
+		if err != nil {
+			os.Remove(toFile)
+			fmt.Printf("EPUB exporting failed. Error: %s\n", err)
+			os.Exit(1)
+		}
+		reportUnsupportedBlocks(e.UnsupportedBlocks())
+		return
+	}
+
+	for _, r := range renderers {
+		e.Renderer = r
+		target := toFile
+		if len(renderers) > 1 {
+			target = resolveFormatFileName(toFile, r)
+		}
+
+		if blockID != "" {
+			out, err := e.RenderBlockSubtree(blockID, ropts)
+			if err != nil {
+				fmt.Printf("Block exporting failed. Error: %s\n", err)
+				os.Exit(1)
+			}
+			if dryRun {
+				reportDryRun(e.DryRunReport(), target, len(out))
+			} else if target == "" {
+				fmt.Printf("%s\n", out)
+			} else if err := os.WriteFile(target, out, fileMode); err != nil {
+				fmt.Printf("Failed to write file to %s, error: %s", target, err)
+				os.Exit(1)
+			}
+			reportUnsupportedBlocks(e.UnsupportedBlocks())
+			continue
+		}
+
+		if database {
+			out, err := e.ExportDatabase(pageID, ropts)
+			if err != nil {
+				fmt.Printf("Database exporting failed. Error: %s\n", err)
+				os.Exit(1)
+			}
+			if dryRun {
+				reportDryRun(e.DryRunReport(), target, len(out))
+			} else if target == "" {
+				fmt.Printf("%s\n", out)
+			} else if err := os.WriteFile(target, out, fileMode); err != nil {
+				fmt.Printf("Failed to write file to %s, error: %s", target, err)
+				os.Exit(1)
+			}
+			reportSkippedPages(e.SkippedPages(), skippedSince)
+			reportUnsupportedBlocks(e.UnsupportedBlocks())
+			continue
+		}
+
+		// check whether an output file was specified. If it was, stream the
+		// export to the file as opposed to printing output to standard out.
+		if target != "" && !dryRun {
+			// the file is only created, or truncated, once the page is
+			// written, so a page skipped with --since leaves it as is.
+			f := &lazyFile{path: target, mode: fileMode}
+			err = e.RenderTo(f, pageID, ropts)
+			f.Close()
+			if err != nil {
+				fmt.Printf("Page exporting failed. Error: %s\n", err)
+				os.Exit(1)
+			}
+			reportSkippedPages(e.SkippedPages(), skippedSince)
+			reportUnsupportedBlocks(e.UnsupportedBlocks())
+			continue
+		}
+
+		out, err := e.Render(pageID, ropts)
+		if err != nil {
+			fmt.Printf("Page exporting failed. Error: %s\n", err)
+			os.Exit(1)
+		}
+		if dryRun {
+			reportDryRun(e.DryRunReport(), target, len(out))
+		} else if len(out) > 0 {
+			fmt.Printf("%s\n", out)
+		}
+		reportSkippedPages(e.SkippedPages(), skippedSince)
+		reportUnsupportedBlocks(e.UnsupportedBlocks())
+	}
+}
+
+// resolveRenderers returns a renderer for each of the comma-separated formats,
+// in the order given. Formats sharing a file extension, such as markdown and
+// md, can't be combined as they'd be written to the same file.
+func resolveRenderers(formats string) ([]ne.Renderer, error) {
+	var renderers []ne.Renderer
+	exts := map[string]string{}
+	for _, format := range strings.Split(formats, ",") {
+		format = strings.TrimSpace(format)
+		r, err := ne.NewRenderer(format)
+		if err != nil {
+			return nil, err
+		}
+		ext := ne.ResolvePageFileExtension(r)
+		if other, ok := exts[ext]; ok {
+			return nil, fmt.Errorf("formats %s and %s are both written to %s files", other, format,
+				ext)
+		}
+		exts[ext] = format
+		renderers = append(renderers, r)
+	}
+	return renderers, nil
+}
+
+// resolveFormatFileName returns the file a page exported with r is written to
+// when exporting to multiple formats: toFile with its extension replaced by
+// the one of r's format. For example, page.md becomes page.json for the JSON
+// renderer, and a toFile without an extension, such as page, becomes
+// page.json.
+func resolveFormatFileName(toFile string, r ne.Renderer) string {
 	if toFile == "" {
-		fmt.Printf("%s\n", out)
-		// TODO(joshrosso): Refactor this function. Not great that there's a
-		// random, non-error "return" here.
-		os.Exit(0)
+		return ""
+	}
+	return strings.TrimSuffix(toFile, filepath.Ext(toFile)) + ne.ResolvePageFileExtension(r)
+}
+
+// pageExporter is the functionality of the exporter used to export a batch of
+// pages.
+type pageExporter interface {
+	RenderTo(w io.Writer, pageID string, opts ...ne.RenderOptions) error
+	UnsupportedBlocks() map[string]int
+	DryRunReport() ne.DryRunReport
+	SkippedPages() []string
+	ExportedTitle() string
+	RenderIndex(w io.Writer, title string, m ne.Manifest, opts ...ne.RenderOptions) error
+}
+
+// exportPages exports every page identified in r, one per line, to its own
+// file in dir, named after the page's ID. Blank lines are skipped. A page that
+// fails to export is reported to standard error without stopping the rest. It
+// returns whether every page was exported. With ropts.WriteIndex, a single
+// index linking to every page in the batch, and the subpages exported with
+// it, is written to dir in place of an index for each page.
+func exportPages(e pageExporter, r io.Reader, dir string, ext string,
+	ropts ne.RenderOptions) bool {
+
+	writeIndex := ropts.WriteIndex
+	ropts.WriteIndex = false
+	index := ne.Manifest{Pages: map[string]ne.ManifestPage{}}
+
+	// nothing is written in a dry run, so the directory isn't needed.
+	if !ropts.DryRun {
+		if err := os.MkdirAll(dir, ropts.ImageOpts.DirMode); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed creating output directory %s, error: %s\n", dir, err)
+			return false
+		}
 	}
-	err = os.WriteFile(toFile, out, 0666)
+
+	ok := true
+	var exported, skipped, failed int
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		written, err := exportPageToDir(e, line, dir, ext, ropts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed exporting %s, error: %s\n", line, err)
+			failed++
+			continue
+		}
+		if writeIndex {
+			// the ID was parsed when the page was exported.
+			pageID, _ := ne.ParsePageID(line)
+			if err := addToIndex(index, e, pageID, dir, ext, ropts); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed indexing %s, error: %s\n", line, err)
+				ok = false
+			}
+		}
+		if !written {
+			skipped++
+			continue
+		}
+		exported++
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed reading page identifiers, error: %s\n", err)
+		ok = false
+	}
+	if writeIndex {
+		if err := writeBatchIndex(e, index, dir, ext, ropts); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed writing index, error: %s\n", err)
+			ok = false
+		}
+	}
+
+	if ropts.EditedSince.IsZero() {
+		fmt.Fprintf(os.Stderr, "Exported %d pages, %d failed.\n", exported, failed)
+	} else {
+		fmt.Fprintf(os.Stderr, "Exported %d pages, %d skipped as they weren't edited since %s,"+
+			" %d failed.\n", exported, skipped, ropts.EditedSince.Format(time.RFC3339), failed)
+	}
+	return ok && failed == 0
+}
+
+// exportPageToDir exports the page identified by ref, a UUID or Notion URL, to
+// a file in dir named after the page's ID with the extension ext. It returns
+// whether the page was written, which it isn't when skipped with --since.
+func exportPageToDir(e pageExporter, ref string, dir string, ext string,
+	ropts ne.RenderOptions) (bool, error) {
+
+	pageID, err := ne.ParsePageID(ref)
+	if err != nil {
+		return false, err
+	}
+
+	fileName := filepath.Join(dir, pageID+ext)
+	if ropts.DryRun {
+		var buf bytes.Buffer
+		if err := e.RenderTo(&buf, pageID, ropts); err != nil {
+			return false, err
+		}
+		reportDryRun(e.DryRunReport(), fileName, buf.Len())
+		reportUnsupportedBlocks(e.UnsupportedBlocks())
+		return !isSkipped(e, pageID), nil
+	}
+	// the file is only created once the page is written, so a page skipped
+	// with --since leaves it as is.
+	f := &lazyFile{path: fileName, mode: ropts.ImageOpts.FileMode}
+	err = e.RenderTo(f, pageID, ropts)
+	f.Close()
+	if err != nil {
+		// a partial page isn't left behind to be mistaken for an export.
+		if f.f != nil {
+			os.Remove(fileName)
+		}
+		return false, err
+	}
+	reportUnsupportedBlocks(e.UnsupportedBlocks())
+
+	return !isSkipped(e, pageID), nil
+}
+
+// addToIndex adds the page pageID, most recently exported by e to dir, to
+// index. With ropts.RecursePages, the subpages recorded in the manifest the
+// export wrote to dir are added too, nested beneath the page.
+func addToIndex(index ne.Manifest, e pageExporter, pageID string, dir string, ext string,
+	ropts ne.RenderOptions) error {
+
+	index.Pages[pageID] = ne.ManifestPage{Path: pageID + ext, Title: e.ExportedTitle()}
+	// nothing, including the manifest, is written in a dry run.
+	if !ropts.RecursePages || ropts.DryRun {
+		return nil
+	}
+	m, err := ne.ReadManifest(dir)
 	if err != nil {
-		fmt.Printf("Failed to write file to %s, error: %s", toFile, err)
+		return err
+	}
+	for id, p := range m.Pages {
+		index.Pages[id] = p
+	}
+	return nil
+}
+
+// writeBatchIndex writes the index of the pages exported by exportPages to dir,
+// named index with the extension ext. In a dry run, its size is reported
+// instead.
+func writeBatchIndex(e pageExporter, index ne.Manifest, dir string, ext string,
+	ropts ne.RenderOptions) error {
+
+	var buf bytes.Buffer
+	if err := e.RenderIndex(&buf, "Index", index, ropts); err != nil {
+		return err
 	}
+	fileName := filepath.Join(dir, "index"+ext)
+	if ropts.DryRun {
+		fmt.Fprintf(os.Stderr, "Would write %s (%d bytes).\n", fileName, buf.Len())
+		return nil
+	}
+	return os.WriteFile(fileName, buf.Bytes(), ropts.ImageOpts.FileMode)
+}
+
+// isSkipped reports whether the page pageID was left out of the most recent
+// export as it wasn't edited since --since.
+func isSkipped(e pageExporter, pageID string) bool {
+	for _, id := range e.SkippedPages() {
+		if id == pageID {
+			return true
+		}
+	}
+	return false
+}
+
+// reportDryRun prints what an export made with --dry-run would have downloaded
+// and written to standard error. The exported page, of size bytes, would have
+// been written to target or, when it's empty, standard out.
+func reportDryRun(report ne.DryRunReport, target string, size int) {
+	if target == "" {
+		target = "standard out"
+	}
+	fmt.Fprintf(os.Stderr, "Rendered %d blocks.\n", report.Blocks)
+	fmt.Fprintf(os.Stderr, "Would download %d files:\n", len(report.Downloads))
+	for _, p := range report.Downloads {
+		fmt.Fprintf(os.Stderr, "  %s\n", p)
+	}
+
+	var pages []string
+	for p := range report.Pages {
+		pages = append(pages, p)
+	}
+	sort.Strings(pages)
+	total := size
+	fmt.Fprintf(os.Stderr, "Would write %d files:\n", len(pages)+1)
+	fmt.Fprintf(os.Stderr, "  %s (%d bytes)\n", target, size)
+	for _, p := range pages {
+		fmt.Fprintf(os.Stderr, "  %s (%d bytes)\n", p, report.Pages[p])
+		total += report.Pages[p]
+	}
+	fmt.Fprintf(os.Stderr, "Would write %d bytes in total.\n", total)
+}
+
+// parseFileMode parses s, permissions in octal such as 0644 or 0o644, into a
+// file mode. An error is returned when s isn't octal, has bits beyond the
+// permission bits (0777), or lacks any of the bits in required, which the
+// export needs to write its own output.
+func parseFileMode(s string, required os.FileMode) (os.FileMode, error) {
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	m, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("expected permissions in octal (e.g. 0644)")
+	}
+	mode := os.FileMode(m)
+	if mode&^os.ModePerm != 0 {
+		return 0, fmt.Errorf("permissions can't exceed 0777")
+	}
+	if mode&required != required {
+		return 0, fmt.Errorf("permissions must grant the owner at least %#o", required)
+	}
+	return mode, nil
+}
+
+// describeSkippedSince returns what pages left out of an export weren't
+// edited since, for reportSkippedPages: since, the last export when
+// skipUnchanged is set, or both.
+func describeSkippedSince(since time.Time, skipUnchanged bool) string {
+	switch {
+	case since.IsZero():
+		return "the last export"
+	case skipUnchanged:
+		return since.Format(time.RFC3339) + " or the last export"
+	}
+	return since.Format(time.RFC3339)
+}
+
+// reportSkippedPages prints the number of pages left out of an export as they
+// weren't edited since to standard error.
+func reportSkippedPages(ids []string, since string) {
+	if len(ids) < 1 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Skipped %d pages as they weren't edited since %s.\n", len(ids), since)
+}
+
+// lazyFile is an io.Writer that creates, or truncates, the file at path on
+// the first write, so nothing is written when no page is rendered. The file
+// is created with the permissions mode.
+type lazyFile struct {
+	path string
+	mode os.FileMode
+	f    *os.File
+}
+
+func (l *lazyFile) Write(p []byte) (int, error) {
+	if l.f == nil {
+		f, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, l.mode)
+		if err != nil {
+			return 0, err
+		}
+		l.f = f
+	}
+	return l.f.Write(p)
+}
+
+// Close closes the file, if it was created.
+func (l *lazyFile) Close() error {
+	if l.f == nil {
+		return nil
+	}
+	return l.f.Close()
+}
+
+// reportUnsupportedBlocks prints a summary of the blocks left out of an export
+// to standard error, so it isn't mixed into an export written to standard out.
+func reportUnsupportedBlocks(counts map[string]int) {
+	if len(counts) < 1 {
+		return
+	}
+	var types []string
+	for t := range counts {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	var summary []string
+	for _, t := range types {
+		summary = append(summary, fmt.Sprintf("%s (%d)", t, counts[t]))
+	}
+	fmt.Fprintf(os.Stderr, "Unsupported blocks were left out of the export: %s\n",
+		strings.Join(summary, ", "))
+}
+
+// RunList prints the ID, last edited time, and title of every page shared
+// with the integration, most recently edited first.
+func RunList(cmd *cobra.Command, args []string) {
+	profile, _ := cmd.Flags().GetString("profile")
+	e, err := ne.NewExporter(ne.ExporterOptions{Profile: profile})
+	if err != nil {
+		fmt.Printf("Failed creating exporter. Error: %s\n", err)
+		os.Exit(1)
+	}
+	pages, err := e.ListPages(context.Background())
+	if err != nil {
+		fmt.Printf("Listing pages failed. Error: %s\n", err)
+		os.Exit(1)
+	}
+	if len(pages) == 0 {
+		fmt.Println("No pages are shared with your integration. Share a page by opening it in" +
+			" Notion, choosing Connections from the ••• menu, and adding your integration.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tLAST EDITED\tTITLE")
+	for _, p := range pages {
+		title := p.Title
+		if title == "" {
+			title = "Untitled"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", p.ID, p.LastEditedTime.Local().Format("2006-01-02 15:04"), title)
+	}
+	w.Flush()
 }
 
 func RunLogin(cmd *cobra.Command, args []string) {
@@ -144,7 +935,34 @@ func RunLogin(cmd *cobra.Command, args []string) {
 		fmt.Println("Must provide login token.")
 		os.Exit(1)
 	}
-	c.Token = args[0]
+	// the token is checked before it's saved, so a mistyped token isn't only
+	// discovered by the next export.
+	e, err := ne.NewExporter(ne.ExporterOptions{NotionToken: args[0]})
+	if err != nil {
+		fmt.Printf("Failed creating exporter. Error: %s\n", err)
+		os.Exit(1)
+	}
+	if err := e.VerifyToken(context.Background()); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	profile, _ := cmd.Flags().GetString("profile")
+	makeDefault, _ := cmd.Flags().GetBool("default")
+	switch {
+	case profile != "":
+		if c.Profiles == nil {
+			c.Profiles = map[string]string{}
+		}
+		c.Profiles[profile] = args[0]
+		if makeDefault {
+			c.DefaultProfile = profile
+		}
+	case makeDefault:
+		fmt.Println("--default requires --profile.")
+		os.Exit(1)
+	default:
+		c.Token = args[0]
+	}
 
 	err = config.SaveNexpConfig(*c)
 	if err != nil {
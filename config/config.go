@@ -9,8 +9,41 @@ import (
 )
 
 type NexpConfig struct {
-	Token  string
-	Images ImageConfig
+	// Token is used when no profile is selected and DefaultProfile isn't
+	// set.
+	Token string
+	// Profiles maps the name of a profile (e.g. work) to its token, so
+	// tokens for several Notion workspaces can be stored.
+	Profiles map[string]string
+	// DefaultProfile is the profile whose token is used when no profile is
+	// selected.
+	DefaultProfile string
+	Images         ImageConfig
+}
+
+// ResolveToken returns the token of the profile named profile. When profile is
+// empty, the token of DefaultProfile is returned or, when that isn't set,
+// Token. An error is returned when the profile doesn't exist or the token
+// found is empty.
+func (c *NexpConfig) ResolveToken(profile string) (string, error) {
+	if profile == "" {
+		profile = c.DefaultProfile
+	}
+	if profile == "" {
+		if c.Token == "" {
+			return "", fmt.Errorf("Token retrieved from configuration was empty")
+		}
+		return c.Token, nil
+	}
+
+	t, ok := c.Profiles[profile]
+	if !ok {
+		return "", fmt.Errorf("Profile %s was not found in configuration", profile)
+	}
+	if t == "" {
+		return "", fmt.Errorf("Token retrieved from profile %s was empty", profile)
+	}
+	return t, nil
 }
 
 type ImageConfig struct {
@@ -34,8 +67,10 @@ func LoadNexpConfig() (*NexpConfig, error) {
 	config := NexpConfig{}
 	err = yaml.Unmarshal(c, &config)
 	if err != nil {
-		return nil, fmt.Errorf("failed loading configuraiton file, "+
-			"error: %s\n", err)
+		// the YAML error is not included as it may quote values from the
+		// file, which would leak the token into logs.
+		return nil, fmt.Errorf("failed parsing configuration file %s, "+
+			"ensure it is valid YAML\n", dir)
 	}
 
 	return &config, nil
@@ -53,7 +88,7 @@ func SaveNexpConfig(c NexpConfig) error {
 		return fmt.Errorf("Failed marshalling config into bytes "+
 			"error: %s\n", err)
 	}
-	err = os.WriteFile(dir, yConf, 0666)
+	err = os.WriteFile(dir, yConf, 0600)
 	if err != nil {
 		return fmt.Errorf("Failed to write config file "+
 			"error: %s\n", err)
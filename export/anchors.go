@@ -0,0 +1,82 @@
+package export
+
+// This file contains the logic used to give headings anchors, so they can be
+// linked to from within the page.
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	// untitledHeadingSlug is the anchor of headings whose text has no
+	// letters or numbers.
+	untitledHeadingSlug = "section"
+)
+
+// headingSlugs records the anchors claimed by the headings of a page, so
+// headings with the same text receive distinct anchors.
+type headingSlugs map[string]bool
+
+// claim returns slug when no heading has claimed it. Otherwise, slug with the
+// first free numeric suffix, starting at 1 (e.g. setup-1), is returned.
+func (s headingSlugs) claim(slug string) string {
+	candidate := slug
+	for i := 1; s[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+	s[candidate] = true
+	return candidate
+}
+
+// SlugifyHeading returns the anchor for a heading whose plain text is text,
+// following GitHub's algorithm: the text is lowercased, spaces become "-",
+// and everything other than letters (including combining marks), numbers,
+// "-", and connector punctuation such as "_" is removed. For example,
+// "Setup & Install" returns "setup--install" and "🚀 Café" returns "-café".
+// When nothing remains, "section" is returned.
+//
+// It's the algorithm RenderOptions.HeadingAnchors gives headings anchors
+// with, so links built from a heading's text, such as those of a table of
+// contents rendered by an override, resolve to the heading. As headings with
+// the same text receive a numeric suffix (e.g. setup-1) in the order they
+// appear, ResolveHeadingAnchor returns the anchor a heading was given.
+func SlugifyHeading(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsMark(r), unicode.IsDigit(r), r == '-',
+			unicode.Is(unicode.Pc, r):
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+
+	if b.Len() < 1 {
+		return untitledHeadingSlug
+	}
+	return b.String()
+}
+
+// resolveHeadingOpts returns the options a heading whose text is rt is
+// rendered with. When RenderOptions.HeadingAnchors is set, they carry the
+// anchor claimed for the heading, resolved with ResolveHeadingAnchor.
+func resolveHeadingOpts(config RenderOptions, rt []na.RichText) []RenderOptions {
+	if config.HeadingAnchors && config.headingSlugs != nil {
+		config.headingAnchor = config.headingSlugs.claim(SlugifyHeading(richTextToPlain(rt)))
+	}
+	return []RenderOptions{config}
+}
+
+// ResolveHeadingAnchor returns the anchor (e.g. "getting-started") of the
+// heading in a heading Block, which is unique within the page. An empty string
+// is returned when RenderOptions.HeadingAnchors isn't set or the Block was not
+// passed this state (e.g. it was rendered outside of an exporter).
+func ResolveHeadingAnchor(b *Block) string {
+	config := resolveRenderConfig(b.Opts...)
+	return config.headingAnchor
+}
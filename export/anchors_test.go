@@ -0,0 +1,53 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+func TestSlugifyHeading(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "words", text: "Getting Started", want: "getting-started"},
+		{name: "punctuation", text: "Setup & Install!", want: "setup--install"},
+		{name: "surrounding space", text: "  Padded  ", want: "padded"},
+		{name: "emoji", text: "🚀 Launch", want: "-launch"},
+		{name: "non-ASCII letters", text: "Café Ärger", want: "café-ärger"},
+		{name: "non-Latin script", text: "日本語 見出し", want: "日本語-見出し"},
+		{name: "combining mark", text: "Cafe\u0301", want: "cafe\u0301"},
+		{name: "numbers, hyphens, and underscores", text: "v1.2 snake_case-x", want: "v12-snake_case-x"},
+		{name: "only punctuation", text: "?!", want: "section"},
+		{name: "empty", text: "", want: "section"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SlugifyHeading(tt.text); got != tt.want {
+				t.Errorf("SlugifyHeading(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchorsAreUnique(t *testing.T) {
+	heading := func(id, s string) na.Block {
+		return &na.Heading2Block{BasicBlock: basicBlock(id, na.BlockTypeHeading2, false),
+			Heading2: na.Heading{RichText: []na.RichText{text(s)}}}
+	}
+	notion := fakeNotion{}.page("anchors", "Anchors",
+		heading("h1", "Setup"), heading("h2", "Setup"), heading("h3", "Setup 1"), heading("h4", "?"))
+
+	out, err := newTestExporter(t, "html", notion).Render("anchors", RenderOptions{HeadingAnchors: true})
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+	for _, want := range []string{`id="setup"`, `id="setup-1"`, `id="setup-1-1"`, `id="section"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("Render() = %q, want it to contain %q", out, want)
+		}
+	}
+}
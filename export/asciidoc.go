@@ -0,0 +1,708 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	adocTitlePattern          = "= %s"
+	adocHeadingOnePattern     = "== %s"
+	adocHeadingTwoPattern     = "=== %s"
+	adocHeadingThreePattern   = "==== %s"
+	adocLinkPattern           = "link:%s[%s]"
+	adocBoldPattern           = "*%s*"
+	adocItalicPattern         = "_%s_"
+	adocStrikeThroughPattern  = "[.line-through]#%s#"
+	adocInlineCodePattern     = "`%s`"
+	adocTodoUncheckedPattern  = "%s [ ] %s"
+	adocTodoCheckedPattern    = "%s [x] %s"
+	adocBlockTitlePattern     = ".%s"
+	adocSourcePattern         = "[source,%s]"
+	adocCodeBlockDelimiter    = "----"
+	adocQuoteDelimiter        = "____"
+	adocCalloutPattern        = "[NOTE]\n====\n%s\n===="
+	adocInlineImagePattern    = "image:%s[%s]"
+	adocImagePattern          = "image::%s[%s]"
+	adocDividerPattern        = "'''"
+	adocEquationPattern       = "[stem]\n++++\n%s\n++++"
+	adocInlineEquationPattern = "stem:[%s]"
+	adocPassthroughPattern    = "++++\n%s\n++++"
+	adocUnsupportedPattern    = "// unsupported: %s"
+	adocCommentPattern        = "// %s"
+	adocAnchorPattern         = "[[%s]]"
+	adocTableDelimiter        = "|==="
+	adocTableHeaderAttribute  = "[%header]"
+	adocTableCellPattern      = "| %s "
+	adocBulletMarker          = "*"
+	adocNumberMarker          = "."
+)
+
+var (
+	// adocTableCellReplacer escapes cell text that would otherwise break the
+	// structure of a table. Pipes delimit cells and the cells of a header row
+	// must be on a single line, so line breaks are replaced with spaces.
+	adocTableCellReplacer = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+	// adocMacroTargetReplacer escapes characters in the target of a link or
+	// image macro that would otherwise end the target early.
+	adocMacroTargetReplacer = strings.NewReplacer(" ", "%20", "[", "%5B", "]", "%5D")
+	// adocMacroTextReplacer escapes the closing bracket in the text of a
+	// macro, which would otherwise end the text early.
+	adocMacroTextReplacer = strings.NewReplacer("]", "\\]")
+)
+
+// AsciiDocRenderer renders a Notion page as AsciiDoc. The page's title is the
+// document title ("= title"), so Notion's headings start one level below it,
+// at "==". Nested list items deepen their marker (e.g. "**" or "..") rather
+// than being indented, as indentation is significant in AsciiDoc.
+type AsciiDocRenderer struct {
+	// tableOpen is true while the rows of a table are being rendered, so the
+	// table can be closed by the first block that isn't one of its rows.
+	tableOpen bool
+}
+
+// RenderPageHeader for AsciiDocRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, it
+// defaults to returning the title of the page as the document title.
+func (a *AsciiDocRenderer) RenderPageHeader(page *na.Page,
+	o ...headerFooterOverride) string {
+
+	// a new page is starting; drop any state from a previous render.
+	a.tableOpen = false
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return fmt.Sprintf(adocTitlePattern, ResolveTitleInPage(page))
+}
+
+// RenderPageFooter for AsciiDocRenderer closes a table still open from the
+// final blocks of the page. It then returns the results of a client's custom
+// pageOverrider definition, or nothing when one is not provided.
+func (a *AsciiDocRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	footer := a.closeTable()
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return footer + o[0](page)
+	}
+
+	return footer
+}
+
+// RenderPageHeader1 for AsciiDocRenderer returns the Block's text as a level
+// 1 section title, "== ". If an override is provided, that function is run and
+// returned value is used instead.
+func (a *AsciiDocRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	return a.renderHeading(adocHeadingOnePattern, b, o...)
+}
+
+// RenderPageHeader2 for AsciiDocRenderer returns the Block's text as a level
+// 2 section title, "=== ". If an override is provided, that function is run
+// and returned value is used instead.
+func (a *AsciiDocRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	return a.renderHeading(adocHeadingTwoPattern, b, o...)
+}
+
+// RenderPageHeader3 for AsciiDocRenderer returns the Block's text as a level
+// 3 section title, "==== ". If an override is provided, that function is run
+// and returned value is used instead.
+func (a *AsciiDocRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	return a.renderHeading(adocHeadingThreePattern, b, o...)
+}
+
+// RenderParagraph for AsciiDocRenderer returns the Block's text. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (a *AsciiDocRenderer) RenderParagraph(b *Block, o ...blockOverride) string {
+	return a.renderPattern("%s", b, o...)
+}
+
+// RenderDivider for AsciiDocRenderer returns three single quotes, a thematic
+// break. If an override is provided, that function is run and returned value
+// is used instead.
+func (a *AsciiDocRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return adocDividerPattern
+}
+
+// RenderNumberedList for AsciiDocRenderer returns the Block's text prepended
+// with ". ", with a "." added for each level of depth. AsciiDoc numbers the
+// items itself. If an override is provided, that function is run and returned
+// value is used instead.
+func (a *AsciiDocRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return resolveAdocListMarker(adocNumberMarker, b) + " " + b.Text
+}
+
+// RenderBulletedList for AsciiDocRenderer returns the Block's text prepended
+// with "* ", with a "*" added for each level of depth. If an override is
+// provided, that function is run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return resolveAdocListMarker(adocBulletMarker, b) + " " + b.Text
+}
+
+// RenderTodoList for AsciiDocRenderer returns the Block's text as a checklist
+// item, "* [ ] " or "* [x] " when checked. If an override is provided, that
+// function is run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	marker := resolveAdocListMarker(adocBulletMarker, b)
+	// when the block isn't a ToDoBlock (e.g. passed from a custom override
+	// pipeline), there is no checked state to read, so render it unchecked.
+	tb, ok := b.BlockRef.(*na.ToDoBlock)
+	if ok && tb.ToDo.Checked {
+		return fmt.Sprintf(adocTodoCheckedPattern, marker, b.Text)
+	}
+	return fmt.Sprintf(adocTodoUncheckedPattern, marker, b.Text)
+}
+
+// RenderCallout for AsciiDocRenderer returns the Block's text as a NOTE
+// admonition, prefixed with the callout's icon: emoji as is and images as an
+// inline image. If an override is provided, that function is run and returned
+// value is used instead.
+func (a *AsciiDocRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	txt := b.Text
+	switch emoji, src := resolveCalloutIcon(b); {
+	case emoji != "":
+		txt = emoji + " " + txt
+	case src != "":
+		icon := fmt.Sprintf(adocInlineImagePattern, adocMacroTargetReplacer.Replace(src),
+			calloutIconAltText)
+		txt = icon + " " + txt
+	}
+	return fmt.Sprintf(adocCalloutPattern, txt)
+}
+
+// RenderQuote for AsciiDocRenderer returns the Block's text within a quote
+// block, delimited by "____". If an override is provided, that function is
+// run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderQuote(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return adocQuoteDelimiter + "\n" + b.Text + "\n" + adocQuoteDelimiter
+}
+
+// RenderCode for AsciiDocRenderer returns the code within a listing block,
+// delimited by "----", with its language set via [source,lang]. The caption,
+// when present, is the block's title. If an override is provided, that
+// function is run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	var r string
+	// when the block isn't a CodeBlock (e.g. passed from a custom override
+	// pipeline), there is no language or caption to read.
+	if cb, ok := b.BlockRef.(*na.CodeBlock); ok {
+		if caption := ResolveCodeCaption(b); caption != "" {
+			r += fmt.Sprintf(adocBlockTitlePattern, caption) + "\n"
+		}
+		language := ResolveLanguageForCodeBlock(cb.Code.Language,
+			resolveRenderConfig(b.Opts...).LanguageOverrides)
+		r += fmt.Sprintf(adocSourcePattern, language) + "\n"
+	}
+
+	return r + adocCodeBlockDelimiter + "\n" + b.Text + "\n" + adocCodeBlockDelimiter
+}
+
+// RenderImage for AsciiDocRenderer returns a block image macro,
+// image::src[alt]. Images hosted in Notion are downloaded. When
+// RenderOptions.CaptionImages is set, the caption is the image's title. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (a *AsciiDocRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ib, ok := b.BlockRef.(*na.ImageBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderImage was passed a %s but expected an ImageBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	var src string
+	switch {
+	case ib.Image.External != nil:
+		src = ib.Image.External.URL
+	case ib.Image.File == nil:
+		return "", errImageWithoutSource(ib)
+	default:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
+		if err != nil {
+			return "", err
+		}
+		src = filePath
+	}
+
+	alt := adocMacroTextReplacer.Replace(resolveImageAltText(ib))
+	img := fmt.Sprintf(adocImagePattern, adocMacroTargetReplacer.Replace(src), alt)
+	if config.CaptionImages && b.Text != "" {
+		img = fmt.Sprintf(adocBlockTitlePattern, b.Text) + "\n" + img
+	}
+
+	return img, nil
+}
+
+// RenderFile for AsciiDocRenderer returns a link to the file. Files hosted in
+// Notion are downloaded and linked to locally, while external files are linked
+// to directly. The caption is used as the link text when present, otherwise
+// the name of the file is used. If an override is provided, that function is
+// run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderFile was passed a %s but expected a FileBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveFileBlockPath(fb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = resolveFileName(filePath)
+	}
+	return renderAdocLink(filePath, linkTxt), nil
+}
+
+// RenderVideo for AsciiDocRenderer returns a link to the video. Videos hosted
+// in Notion are downloaded and linked to locally. When
+// RenderOptions.EmbedVideos is set, the video is embedded using HTML within a
+// passthrough block instead. The caption is used as the link text when
+// present, otherwise the location of the video is used. If an override is
+// provided, that function is run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderVideo was passed a %s but expected a VideoBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveVideoBlockPath(vb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+	if config.EmbedVideos {
+		if embed, ok := resolveVideoEmbed(vb, filePath); ok {
+			return fmt.Sprintf(adocPassthroughPattern, embed), nil
+		}
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = filePath
+	}
+	return renderAdocLink(filePath, linkTxt), nil
+}
+
+// RenderAudio for AsciiDocRenderer returns a link to the audio. Audio hosted
+// in Notion is downloaded and linked to locally. The caption is used as the
+// link text when present, otherwise the name of the audio file is used. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (a *AsciiDocRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = resolveFileName(filePath)
+	}
+	return renderAdocLink(filePath, linkTxt), nil
+}
+
+// RenderBookmark for AsciiDocRenderer returns a link to the bookmarked URL.
+// When the bookmark has a caption, it is used as the link text, otherwise the
+// URL itself is used. If an override is provided, that function is run and
+// returned value is used instead.
+func (a *AsciiDocRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	bb, ok := b.BlockRef.(*na.BookmarkBlock)
+	if !ok {
+		return b.Text
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = bb.Bookmark.URL
+	}
+	return renderAdocLink(bb.Bookmark.URL, linkTxt)
+}
+
+// RenderEquation for AsciiDocRenderer returns the LaTeX expression within a
+// stem block. Rendering it requires the document's :stem: attribute to be
+// set to latexmath. If an override is provided, that function is run and
+// returned value is used instead.
+func (a *AsciiDocRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	return a.renderPattern(adocEquationPattern, b, o...)
+}
+
+// RenderChildPage for AsciiDocRenderer returns a link to the file the subpage
+// was exported to, using the subpage's title as the link text. If an override
+// is provided, that function is run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fileName := ResolveChildPageFile(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = fileName
+	}
+	return renderAdocLink(fileName, linkTxt)
+}
+
+// RenderChildDatabase for AsciiDocRenderer returns a link to the database in
+// Notion, using the database's title as the link text. If an override is
+// provided, that function is run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	url := ResolveChildDatabaseURL(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = url
+	}
+	return renderAdocLink(url, linkTxt)
+}
+
+// RenderLinkToPage for AsciiDocRenderer returns a link to the linked page,
+// using its title as the link text, or the target of the link when the title
+// is unknown. If an override is provided, that function is run and returned
+// value is used instead.
+func (a *AsciiDocRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	target := ResolveLinkToPageTarget(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = target
+	}
+	return renderAdocLink(target, linkTxt)
+}
+
+// RenderColumnList for AsciiDocRenderer returns nothing, as AsciiDoc has no
+// concept of columns and their content is rendered sequentially. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (a *AsciiDocRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderColumn for AsciiDocRenderer returns nothing, as the column's content
+// is rendered sequentially. If an override is provided, that function is run
+// and returned value is used instead.
+func (a *AsciiDocRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderTemplate for AsciiDocRenderer returns the label of a template button
+// in bold, introducing the template's content, which is rendered after it. An
+// empty label returns nothing. If an override is provided, that function is
+// run and returned value is used instead.
+func (a *AsciiDocRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.Text == "" {
+		return ""
+	}
+	return fmt.Sprintf(adocBoldPattern, b.Text)
+}
+
+// RenderUnsupported for AsciiDocRenderer returns a comment naming the type of
+// the block, e.g. // unsupported: breadcrumb, when
+// RenderOptions.MarkUnsupportedBlocks is set. Otherwise, nothing is returned.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (a *AsciiDocRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).MarkUnsupportedBlocks {
+		return ""
+	}
+	return fmt.Sprintf(adocUnsupportedPattern, b.BlockRef.GetType())
+}
+
+// RenderComment for AsciiDocRenderer returns text as a line comment.
+func (a *AsciiDocRenderer) RenderComment(text string) string {
+	return fmt.Sprintf(adocCommentPattern, text)
+}
+
+// RenderTableRow for AsciiDocRenderer returns the row's cells on a single
+// line, each prefixed with "| ". The first row of a table opens it with
+// "|===", marking it as a header when the table has a row header. The table
+// is closed by the first block that follows its rows (see
+// AddSectionSeperation).
+func (a *AsciiDocRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
+	// when a rowOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](cells)
+	}
+
+	var row string
+	var open string
+	for _, c := range cells {
+		if c.tableRef.currentRow == 0 && !a.tableOpen {
+			open = adocTableDelimiter + "\n"
+			if c.isRowHeader {
+				open = adocTableHeaderAttribute + "\n" + open
+			}
+		}
+		txt := adocTableCellReplacer.Replace(c.rowTxt)
+		if c.isColumnHeader && c.tableRef.boldColumnHeader && txt != "" {
+			txt = fmt.Sprintf(adocBoldPattern, txt)
+		}
+		row += fmt.Sprintf(adocTableCellPattern, txt)
+	}
+	if open != "" {
+		a.tableOpen = true
+	}
+
+	return open + strings.TrimSuffix(row, " ")
+}
+
+// RenderText takes the RichText object from the Notion API and converts it to
+// AsciiDoc, rewriting all formatting required. Examples are text that is
+// bold, italicised, or a hyperlink.
+func (a *AsciiDocRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](rt)
+	}
+
+	var parsed string
+	for _, t := range rt {
+		var content string
+		switch t.Type {
+		// text is an inline equation. The Notion API sets the plain text of an
+		// equation to its LaTeX expression.
+		case "equation":
+			parsed += fmt.Sprintf(adocInlineEquationPattern, t.PlainText)
+			continue
+
+		// text is a mention of a page, database, user, or date. Page and
+		// database mentions carry the Notion URL of the target in Href.
+		case "mention":
+			content = resolveMentionText(t)
+
+		default:
+			content = t.Text.Content
+		}
+
+		// each annotation applied to the text wraps it, so text that is both
+		// bold and italicised keeps both. Links are outermost, with the
+		// formatting inside the link text (e.g. link:url[*text*]).
+		address := resolveLinkURL(t)
+		if address == "" {
+			parsed += renderAdocAnnotations(content, t.Annotations)
+			continue
+		}
+		leading, content, trailing := splitSurroundingSpace(content)
+		if content != "" {
+			// the text is escaped before it's formatted, as the markup of
+			// some annotations contains brackets of its own.
+			content = fmt.Sprintf(adocLinkPattern, adocMacroTargetReplacer.Replace(address),
+				renderAdocAnnotations(adocMacroTextReplacer.Replace(content), t.Annotations))
+		}
+		parsed += leading + content + trailing
+	}
+	// Notion uses smart quotes by default, replace them with normal quotes.
+	parsed = unicodeQuoteReplacer.Replace(parsed)
+
+	return parsed
+}
+
+// AddPadding for AsciiDocRenderer returns the Block's text as is. Indentation
+// turns a paragraph into a literal block in AsciiDoc, so nesting is expressed
+// by the list markers instead.
+func (a *AsciiDocRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return b.Text
+}
+
+// AddBlockEnd for AsciiDocRenderer returns nothing, as the blocks it renders
+// with children have no closing syntax. If an override is provided, that
+// function is run and returned value is used instead.
+func (a *AsciiDocRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// AddSectionSeperation for AsciiDocRenderer separates blocks the same as
+// MDRenderer: consecutive list items and table rows by a single line break,
+// and everything else by a blank line. When a table is open and the block
+// isn't one of its rows, the table is closed first.
+func (a *AsciiDocRenderer) AddSectionSeperation(previousType string, currentType string,
+	o ...seperationOverride) string {
+
+	sep := (&MDRenderer{}).AddSectionSeperation(previousType, currentType, o...)
+	if currentType != "table_row" {
+		return a.closeTable() + sep
+	}
+	return sep
+}
+
+// closeTable returns the delimiter closing the table being rendered, or
+// nothing when no table is open.
+func (a *AsciiDocRenderer) closeTable() string {
+	if !a.tableOpen {
+		return ""
+	}
+	a.tableOpen = false
+	return "\n" + adocTableDelimiter
+}
+
+// renderPattern returns the Block's text formatted with pattern. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (a *AsciiDocRenderer) renderPattern(pattern string, b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(pattern, b.Text)
+}
+
+// renderHeading returns the Block's text formatted with pattern, preceded by
+// the heading's anchor when it has one. If an override is provided, that
+// function is run and returned value is used instead.
+func (a *AsciiDocRenderer) renderHeading(pattern string, b *Block, o ...blockOverride) string {
+	heading := a.renderPattern(pattern, b, o...)
+	if len(o) > 0 && o[0] != nil {
+		return heading
+	}
+	if anchor := ResolveHeadingAnchor(b); anchor != "" {
+		return fmt.Sprintf(adocAnchorPattern, anchor) + "\n" + heading
+	}
+	return heading
+}
+
+// resolveAdocListMarker returns marker repeated once for the Block's list and
+// once more for each level of depth, e.g. "**" for a nested bulleted list.
+func resolveAdocListMarker(marker string, b *Block) string {
+	return strings.Repeat(marker, b.Depth+1)
+}
+
+// renderAdocAnnotations returns content wrapped in the syntax for each of the
+// annotations applied to it, with inline code innermost. Underlines and colors
+// aren't rendered.
+func renderAdocAnnotations(content string, a *na.Annotations) string {
+	if a == nil || content == "" {
+		return content
+	}
+	if a.Code {
+		content = fmt.Sprintf(adocInlineCodePattern, content)
+	}
+	if a.Strikethrough {
+		content = fmt.Sprintf(adocStrikeThroughPattern, content)
+	}
+	if a.Italic {
+		content = fmt.Sprintf(adocItalicPattern, content)
+	}
+	if a.Bold {
+		content = fmt.Sprintf(adocBoldPattern, content)
+	}
+	return content
+}
+
+// renderAdocLink returns a link macro to target with text as the link text.
+func renderAdocLink(target, text string) string {
+	return fmt.Sprintf(adocLinkPattern, adocMacroTargetReplacer.Replace(target),
+		adocMacroTextReplacer.Replace(text))
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+func TestAsciiDocRenderTextAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   []na.RichText
+		want string
+	}{
+		{
+			name: "no annotations",
+			rt:   []na.RichText{text("plain")},
+			want: "plain",
+		},
+		{
+			name: "bold and italic",
+			rt:   []na.RichText{annotated("both", na.Annotations{Bold: true, Italic: true})},
+			want: "*_both_*",
+		},
+		{
+			name: "link without annotations",
+			rt:   []na.RichText{linked(text("docs"), "https://example.com")},
+			want: "link:https://example.com[docs]",
+		},
+		{
+			name: "bold link",
+			rt:   []na.RichText{linked(annotated("docs", na.Annotations{Bold: true}), "https://example.com")},
+			want: "link:https://example.com[*docs*]",
+		},
+		{
+			name: "struck link with a bracket",
+			rt: []na.RichText{linked(annotated("a]b", na.Annotations{Strikethrough: true}),
+				"https://example.com")},
+			want: "link:https://example.com[[.line-through]#a\\]b#]",
+		},
+		{
+			name: "link with surrounding spaces",
+			rt:   []na.RichText{text("see"), linked(text(" docs "), "https://example.com"), text("now")},
+			want: "see link:https://example.com[docs] now",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&AsciiDocRenderer{}).RenderText(tt.rt); got != tt.want {
+				t.Errorf("RenderText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAsciiDocGolden(t *testing.T) {
+	out, err := newTestExporter(t, "asciidoc", samplePage()).Render("sample")
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+	assertGolden(t, "golden/sample.adoc", out)
+}
@@ -0,0 +1,173 @@
+package export
+
+// This file contains the logic used to decode audio blocks, which the Notion
+// API client doesn't support, and to link to the audio they contain.
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	htmlAudioPattern = "<audio controls src=\"%s\"></audio>"
+)
+
+// AudioBlock is a Notion audio block. The Notion API client decodes audio
+// blocks as empty UnsupportedBlocks, so the exporter decodes them from the
+// Notion API's responses itself. See audioTransport.
+type AudioBlock struct {
+	na.BasicBlock
+	Audio na.BlockFile `json:"audio"`
+}
+
+// audioTransport is an http.RoundTripper that replaces each audio block in
+// the responses of the Notion API with a file block of the same content,
+// which the Notion API client can decode, and records its ID. resolveBlock
+// turns those file blocks back into AudioBlocks.
+type audioTransport struct {
+	base http.RoundTripper
+
+	mu  sync.Mutex
+	ids map[na.BlockID]bool
+}
+
+// newAudioTransport returns an audioTransport wrapping base.
+func newAudioTransport(base http.RoundTripper) *audioTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &audioTransport{base: base, ids: map[na.BlockID]bool{}}
+}
+
+// RoundTrip sends req and, when it retrieved one or more blocks, replaces the
+// audio blocks in the response as described on audioTransport.
+func (t *audioTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet || resp.StatusCode != http.StatusOK ||
+		!strings.Contains(req.URL.Path, "/blocks/") {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	body = t.replaceAudioBlocks(body)
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// replaceAudioBlocks returns body, a block or a list of blocks, with its audio
+// blocks replaced by file blocks. body is returned as is when it has no audio
+// blocks or can't be decoded, leaving the error to the Notion API client.
+func (t *audioTransport) replaceAudioBlocks(body []byte) []byte {
+	if !bytes.Contains(body, []byte(`"audio"`)) {
+		return body
+	}
+
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return body
+	}
+
+	results, ok := response["results"]
+	if !ok {
+		if !t.replaceAudioBlock(response) {
+			return body
+		}
+		replaced, err := json.Marshal(response)
+		if err != nil {
+			return body
+		}
+		return replaced
+	}
+
+	var blocks []map[string]json.RawMessage
+	if err := json.Unmarshal(results, &blocks); err != nil {
+		return body
+	}
+	found := false
+	for _, b := range blocks {
+		if t.replaceAudioBlock(b) {
+			found = true
+		}
+	}
+	if !found {
+		return body
+	}
+	var err error
+	if response["results"], err = json.Marshal(blocks); err != nil {
+		return body
+	}
+	replaced, err := json.Marshal(response)
+	if err != nil {
+		return body
+	}
+	return replaced
+}
+
+// replaceAudioBlock turns b into a file block, recording its ID, when it's an
+// audio block. false is returned when it isn't.
+func (t *audioTransport) replaceAudioBlock(b map[string]json.RawMessage) bool {
+	var typ string
+	var id na.BlockID
+	if json.Unmarshal(b["type"], &typ) != nil || typ != "audio" ||
+		json.Unmarshal(b["id"], &id) != nil {
+		return false
+	}
+
+	b["type"] = json.RawMessage(`"file"`)
+	b["file"] = b["audio"]
+	delete(b, "audio")
+
+	t.mu.Lock()
+	t.ids[id] = true
+	t.mu.Unlock()
+	return true
+}
+
+// resolveBlock returns b as an AudioBlock when it's a file block that was an
+// audio block, otherwise b as is. b is always returned as is when t is nil,
+// as the exporter's Notion API client wasn't created with an audioTransport.
+func (t *audioTransport) resolveBlock(b na.Block) na.Block {
+	fb, ok := b.(*na.FileBlock)
+	if t == nil || !ok {
+		return b
+	}
+
+	t.mu.Lock()
+	audio := t.ids[fb.ID]
+	t.mu.Unlock()
+	if !audio {
+		return b
+	}
+
+	ab := &AudioBlock{BasicBlock: fb.BasicBlock, Audio: fb.File}
+	ab.Type = "audio"
+	return ab
+}
+
+// resolveBlocks replaces, in place, the blocks in blocks that were audio
+// blocks with AudioBlocks. See resolveBlock.
+func (t *audioTransport) resolveBlocks(blocks *na.GetChildrenResponse) {
+	for i, b := range blocks.Results {
+		blocks.Results[i] = t.resolveBlock(b)
+	}
+}
+
+// resolveAudioBlockPath returns the location an audio block should be linked
+// to. For external audio, this is the URL of the audio. For Notion-hosted
+// audio, the audio is downloaded and this is its path on the local
+// filesystem.
+func resolveAudioBlockPath(ab *AudioBlock, opts ImageSaveOptions) (string, error) {
+	return resolveBlockSourcePath(ab, ab.Audio.File, ab.Audio.External, opts)
+}
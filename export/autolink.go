@@ -0,0 +1,108 @@
+package export
+
+// This file contains the logic used to link URLs typed as plain text, so
+// parsers that don't autolink them still render them as links.
+
+import (
+	"regexp"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	// autolinkRichTextType is the type given to the rich text of a bare URL
+	// linked by autolinkBareURLs. Its Href is set to the URL, so renderers
+	// without an autolink syntax render it as any other link.
+	autolinkRichTextType na.ObjectType = "autolink"
+	// bareURLTrailingPunctuation are the characters trimmed from the end of
+	// a bare URL, as they more likely end the sentence than the URL.
+	bareURLTrailingPunctuation = ".,:;!?'\""
+)
+
+var (
+	// bareURLPattern matches an http or https URL typed as plain text, up to
+	// the next whitespace or angle bracket.
+	bareURLPattern = regexp.MustCompile(`https?://[^\s<>]+`)
+)
+
+// autolinkBareURLs returns rt with every bare URL in its plain, unlinked
+// text split into rich text of its own, of type autolinkRichTextType, linking
+// to the URL. Text that's already a link, a mention, an equation, or inline
+// code is left as is.
+func autolinkBareURLs(rt []na.RichText) []na.RichText {
+	var linked []na.RichText
+	for i, t := range rt {
+		if t.Type != na.ObjectTypeText || t.Href != "" ||
+			(t.Annotations != nil && t.Annotations.Code) {
+			if linked != nil {
+				linked = append(linked, t)
+			}
+			continue
+		}
+		split := splitBareURLs(t)
+		if linked == nil {
+			if len(split) == 1 && split[0].Type == t.Type {
+				continue
+			}
+			linked = append([]na.RichText{}, rt[:i]...)
+		}
+		linked = append(linked, split...)
+	}
+
+	if linked == nil {
+		return rt
+	}
+	return linked
+}
+
+// splitBareURLs returns the text in t split around the bare URLs in it, with
+// each URL linked to itself. When t has no bare URLs, it's returned alone.
+func splitBareURLs(t na.RichText) []na.RichText {
+	content := t.Text.Content
+	var split []na.RichText
+	var end int
+	for _, m := range bareURLPattern.FindAllStringIndex(content, -1) {
+		address := trimBareURL(content[m[0]:m[1]])
+		if m[0] > end {
+			split = append(split, withContent(t, content[end:m[0]]))
+		}
+		link := withContent(t, address)
+		link.Type = autolinkRichTextType
+		link.Href = address
+		link.Text.Link = &na.Link{Url: address}
+		split = append(split, link)
+		end = m[0] + len(address)
+	}
+	if end == 0 {
+		return []na.RichText{t}
+	}
+	if end < len(content) {
+		split = append(split, withContent(t, content[end:]))
+	}
+	return split
+}
+
+// trimBareURL returns address without the punctuation that likely follows,
+// rather than belongs to, the URL. A closing parenthesis is only kept when
+// the URL opened one, e.g. https://en.wikipedia.org/wiki/Go_(game).
+func trimBareURL(address string) string {
+	for {
+		trimmed := strings.TrimRight(address, bareURLTrailingPunctuation)
+		if strings.HasSuffix(trimmed, ")") &&
+			strings.Count(trimmed, ")") > strings.Count(trimmed, "(") {
+			trimmed = strings.TrimSuffix(trimmed, ")")
+		}
+		if trimmed == address {
+			return address
+		}
+		address = trimmed
+	}
+}
+
+// withContent returns a copy of t whose text is content.
+func withContent(t na.RichText, content string) na.RichText {
+	t.Text.Content = content
+	t.PlainText = content
+	return t
+}
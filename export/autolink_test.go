@@ -0,0 +1,69 @@
+package export
+
+import (
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+func TestAutolinkBareURLs(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   []na.RichText
+		want string
+	}{
+		{
+			name: "mid-sentence",
+			rt:   []na.RichText{text("see https://example.com/docs for more")},
+			want: "see <https://example.com/docs> for more",
+		},
+		{
+			name: "start and end of text",
+			rt:   []na.RichText{text("https://example.com/a and https://example.com/b")},
+			want: "<https://example.com/a> and <https://example.com/b>",
+		},
+		{
+			name: "line boundaries",
+			rt:   []na.RichText{text("first\nhttps://example.com/a\nlast")},
+			want: "first\n<https://example.com/a>\nlast",
+		},
+		{
+			name: "end of sentence",
+			rt:   []na.RichText{text("read https://example.com/a.")},
+			want: "read <https://example.com/a>.",
+		},
+		{
+			name: "parenthesized",
+			rt:   []na.RichText{text("(https://en.wikipedia.org/wiki/Go_(game))")},
+			want: "(<https://en.wikipedia.org/wiki/Go_(game)>)",
+		},
+		{
+			name: "already a link",
+			rt:   []na.RichText{linked(text("https://example.com/a"), "https://example.com/a")},
+			want: "[https://example.com/a](https://example.com/a)",
+		},
+		{
+			name: "inline code",
+			rt:   []na.RichText{annotated("https://example.com/a", na.Annotations{Code: true})},
+			want: "`https://example.com/a`",
+		},
+		{
+			name: "bold",
+			rt:   []na.RichText{annotated("at https://example.com/a", na.Annotations{Bold: true})},
+			want: "**at** **<https://example.com/a>**",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notion := fakeNotion{}.page("links", "Links", paragraph("p1", tt.rt...))
+			out, err := newTestExporter(t, "markdown", notion).Render("links",
+				RenderOptions{AutolinkBareURLs: true})
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if want := "# Links\n\n" + tt.want; string(out) != want {
+				t.Errorf("Render() = %q, want %q", out, want)
+			}
+		})
+	}
+}
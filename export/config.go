@@ -4,6 +4,11 @@ package export
 // support rendering of Notion Blocks.
 
 import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+
 	na "github.com/jomei/notionapi"
 )
 
@@ -16,12 +21,314 @@ type RenderOptions struct {
 	// SkipEmptyParagraphs will not send empty paragraphs to the renderer when
 	// true.
 	SkipEmptyParagraphs bool
+	// Frontmatter adds a frontmatter block, generated from the page's
+	// properties, to the top of the page. As the title is part of the
+	// frontmatter, it's omitted from the page header unless a PageHeader
+	// override is provided. The JSONRenderer records the properties in the
+	// Frontmatter of its JSONDocument instead, as a block before the
+	// document wouldn't be valid JSON.
+	Frontmatter bool
+	// FrontmatterFormat is the format the frontmatter added by Frontmatter is
+	// serialized in. When not set, the default is FrontmatterFormatYAML.
+	FrontmatterFormat FrontmatterFormat
+	// ReadingTime adds a reading_time key to the frontmatter added by
+	// Frontmatter, holding the minutes it takes to read the page, rounded up,
+	// at WordsPerMinute. A property named reading_time takes precedence.
+	// The frontmatter is only known once the page's blocks are rendered, so
+	// the page is held in memory until then, rather than streamed.
+	ReadingTime bool
+	// WordsPerMinute is the reading speed ReadingTime is estimated at. When
+	// not set, the default is 200.
+	WordsPerMinute int
+	// ExcludeCodeFromWordCount leaves the contents of code blocks out of the
+	// words counted for ReadingTime and RenderResult.WordCount.
+	ExcludeCodeFromWordCount bool
+	// DateFormat is a Go time layout (e.g. "Jan 2, 2006") date properties
+	// are formatted with, in the frontmatter and in database tables. See
+	// FormatNotionDate. When not set, dates are formatted as 2006-01-02, or
+	// in RFC3339 format when they have a time, and ranges are split into
+	// their start and end in the frontmatter. Date mentions are rendered as
+	// Notion formatted them, as the notionapi client doesn't decode them.
+	DateFormat string
+	// OmitPageHeader leaves the page header (e.g. "# title") out of the
+	// export, even when a PageHeader override is provided. This is useful
+	// when the title is rendered by whatever the export is embedded in.
+	OmitPageHeader bool
+	// IncludeIcon prepends the page's emoji icon to its title. Icons that
+	// aren't emoji are ignored.
+	IncludeIcon bool
+	// IncludeCover adds the page's cover image to the very top of the page.
+	// Covers hosted in Notion are downloaded according to ImageOpts, and are
+	// skipped when ImageOpts.IgnoreImages is set.
+	IncludeCover bool
+	// OmitCalloutIcons leaves the icon of callouts out of the export. By
+	// default, emoji icons prefix the callout's text and image icons are
+	// added as images.
+	OmitCalloutIcons bool
+	// CalloutsAsAlerts renders callouts, in markdown, as GitHub alerts (e.g.
+	// "> [!TIP]"), choosing the type of alert from the callout's emoji icon
+	// (e.g. 💡 is a TIP and ⚠️ a WARNING) or, failing that, its color (e.g.
+	// red is a CAUTION). The icon is left out, as alerts have their own.
+	// Callouts with no fitting alert are rendered as a quote.
+	CalloutsAsAlerts bool
+	// QuoteChildren renders the blocks nested in a quote, in markdown, within
+	// the quote, prefixing each of their lines with "> ", rather than
+	// indented beneath it. Other formats render them as usual, unless their
+	// renderer is a QuoteRenderer.
+	QuoteChildren bool
+	// EmbedVideos embeds videos, using an <iframe> for known providers such
+	// as YouTube and Vimeo, rather than linking to them.
+	EmbedVideos bool
+	// BoldColumnHeaders bolds the text of every cell in the first column of
+	// tables with a column header. This is for formats, such as markdown,
+	// that have no concept of a column header.
+	BoldColumnHeaders bool
+	// TableAlignment is the alignment of every column of markdown tables,
+	// encoded in the separator beneath the header row (e.g. "| ---: |"). As
+	// Notion doesn't store the alignment of cells, it's left to the viewer
+	// when not set.
+	TableAlignment TableAlignment
+	// ColumnAlignments sets the alignment of columns by their index,
+	// starting at 0, taking precedence over TableAlignment. Columns beyond
+	// its length, or set to TableAlignmentDefault, use TableAlignment.
+	ColumnAlignments []TableAlignment
+	// AutolinkBareURLs links http and https URLs typed as plain text, rather
+	// than linked in Notion, so parsers that don't detect URLs still render
+	// them as links. Markdown wraps them in a CommonMark autolink (e.g.
+	// <https://example.com>), while other formats render them as any other
+	// link. URLs in code are left as is.
+	AutolinkBareURLs bool
+	// ResolveInternalLinkTitles replaces the text of links to Notion pages,
+	// when it's the link's URL, with the title of the page linked to. Each
+	// page's title is retrieved from the Notion API once per export. Links to
+	// pages that can't be retrieved are left as is.
+	ResolveInternalLinkTitles bool
+	// ResolveUserNames retrieves the names of the users who created and last
+	// edited the page from the Notion API, as pages only reference users by
+	// ID. They're recorded in RenderResult and, with Frontmatter, added as
+	// created_by and last_edited_by keys, unless properties already use those
+	// keys. Each user is retrieved once per exporter. The integration must
+	// have the capability to read user information. Deleted users are named
+	// "Deleted user", while users that can't be retrieved otherwise are left
+	// out.
+	ResolveUserNames bool
+	// LanguageOverrides maps Notion code block languages (e.g. "shell") to
+	// the name expected by a syntax highlighter (e.g. "bash"). It's merged
+	// over the built-in mapping, taking precedence for any language in both.
+	LanguageOverrides map[string]string
+	// MarkdownFlavor selects the markdown syntax used for task lists and
+	// tables. When not set, the default is MarkdownFlavorGFM.
+	MarkdownFlavor MarkdownFlavor
+	// TodoStyle selects how markdown to-dos show whether they're checked.
+	// When not set, the default is TodoStyleCheckbox.
+	TodoStyle TodoStyle
+	// OrderedListStyle selects the marker of numbered list items in
+	// markdown, org, and text, and the numbering of HTML's <ol>. When not
+	// set, the default is OrderedListStyleDecimal.
+	OrderedListStyle OrderedListStyle
+	// CaptionImages emits the caption of an image, with its formatting,
+	// beneath the image. Regardless of this setting, the caption is used as
+	// the image's alt text.
+	CaptionImages bool
+	// IndentWidth is the number of IndentChar used for each level of depth
+	// when padding nested blocks. When not set, the default is 4, or 1 when
+	// IndentChar is a tab.
+	IndentWidth int
+	// IndentChar is the character used to pad nested blocks, typically " "
+	// or "\t". When not set, the default is a space.
+	IndentChar string
+	// HangingIndent indents the lines of a list item after the first, such
+	// as those wrapped with line breaks in Notion, to align with the item's
+	// text rather than its marker (e.g. by 2 spaces past the item's padding
+	// for "* "). Without it, these lines are only padded by the item's depth,
+	// so strict parsers may render them as a separate paragraph. It applies
+	// to markdown and Org.
+	HangingIndent bool
+	// HTMLColumns wraps the content of column_list and column blocks in HTML
+	// <div> elements using a flexbox layout, so columns render side by side.
+	// When false, the content of each column is rendered sequentially.
+	HTMLColumns bool
+	// FooterSourceLink adds a link to the page in Notion to the footer of
+	// the page. It's ignored when a PageFooter override is provided.
+	FooterSourceLink bool
+	// FooterTimestamp adds the time the page was exported to the footer of
+	// the page. It's ignored when a PageFooter override is provided.
+	FooterTimestamp bool
+	// Separation configures the separation (e.g. "\n") added before a block,
+	// keyed by the block's type (e.g. "paragraph") or, to only apply after a
+	// specific block type, by "previous>current" (e.g. "heading_1>paragraph").
+	// The composite key takes precedence. Transitions with no entry are
+	// separated according to the renderer's AddSectionSeperation. It's
+	// ignored by the JSON renderer.
+	Separation map[string]string
+	// HeadingAnchors gives every heading an anchor, derived from its text
+	// the same way GitHub does (e.g. "Getting Started" is getting-started),
+	// so it can be linked to. Headings with the same text receive a numeric
+	// suffix (e.g. setup-1). In markdown, the anchor is appended as a
+	// heading attribute (e.g. "# Getting Started {#getting-started}"), as
+	// understood by Pandoc, Hugo, and Jekyll, while HTML sets the heading's
+	// id.
+	HeadingAnchors bool
+	// EmbedBlockIDs adds a comment recording the Notion ID of each block
+	// (e.g. "<!-- block: <id> -->") on the line before it, so the export can
+	// be related back to Notion. Table rows, which can't be interrupted, and
+	// blocks that render nothing are left without one. It's ignored by
+	// renderers that aren't a CommentRenderer, such as the text renderer,
+	// which has no comments, and the JSON renderer, which already records
+	// IDs.
+	EmbedBlockIDs bool
+	// MarkUnsupportedBlocks adds a placeholder, such as an HTML comment, in
+	// place of blocks whose type isn't supported, so it's clear where
+	// content was left out.
+	MarkUnsupportedBlocks bool
+	// AnnotateSyncedBlocks adds a comment (e.g. "<!-- synced from <id> -->")
+	// before the content of every synced block, naming the original block
+	// it's synced from, so editors know to change it there. Like
+	// EmbedBlockIDs, it's ignored by renderers that aren't a CommentRenderer.
+	AnnotateSyncedBlocks bool
+	// MaxDepth limits the levels of nested blocks rendered, counting the
+	// page's top-level blocks as the first level, e.g. 1 renders only the
+	// top-level blocks and 2 their children as well. Content that isn't
+	// indented, such as table rows and the content of columns and synced
+	// blocks, doesn't count as a level. When not set, every level is
+	// rendered.
+	MaxDepth int
+	// MarkTruncatedBlocks adds a "..." paragraph, at the depth of the
+	// children, in place of the children left out by MaxDepth, so it's
+	// clear the content continues.
+	MarkTruncatedBlocks bool
+	// RecursePages exports the page referenced by every child_page block to
+	// a file of its own, linking to it from the parent. Files are named
+	// after the page's title, and a Manifest of them is written to
+	// manifest.json in PagesDir.
+	RecursePages bool
+	// PagesDir is the directory pages exported via RecursePages are written
+	// to. Links to these pages are relative to it, so the root page should
+	// also be written here. When not set, the default is the current
+	// directory.
+	PagesDir string
+	// WriteIndex writes an index to PagesDir once every page of a recursive
+	// export, made with RecursePages or DatabaseAsPages, is written. It's
+	// titled after the root page and links to every other page of the
+	// export, each nested beneath the page containing it, as described in
+	// RenderIndex. It's named index, with the extension of the exporter's
+	// Renderer (e.g. index.md).
+	WriteIndex bool
+	// DatabaseAsPages exports each row of a database, when exported with
+	// ExportDatabase, to a file of its own in PagesDir, linking to it from
+	// the row's title in the table.
+	DatabaseAsPages bool
+	// InlineChildDatabases queries every database embedded in a page and
+	// renders its rows as a table beneath the database's title, the same as
+	// ExportDatabase. Rows aren't exported as pages of their own.
+	InlineChildDatabases bool
+	// DryRun renders the export without downloading images and files (see
+	// ImageSaveOptions.DryRun) or writing subpages to PagesDir. What would
+	// have been downloaded and written is recorded in the exporter's
+	// DryRunReport.
+	DryRun bool
+	// EditedSince, when set, skips pages last edited at or before it, so
+	// scheduled exports only render pages that changed. Nothing is written
+	// for a skipped page, leaving the file of a skipped subpage as is. The
+	// subpages of a skipped page are still exported when edited, as editing
+	// a subpage doesn't change its parent. Skipped pages are listed by the
+	// exporter's SkippedPages. It's ignored by RenderAppend.
+	EditedSince time.Time
+	// SkipUnchangedPages skips the pages of a recursive export, made with
+	// RecursePages or DatabaseAsPages, that weren't edited since the export
+	// recorded in the Manifest in PagesDir, so re-exporting to the same
+	// directory only rewrites the pages that changed. As with EditedSince,
+	// nothing is written for a skipped page, leaving its file and the images
+	// it references as is. Pages keep the file recorded in the Manifest, so
+	// links to them from pages that aren't rewritten still resolve. Pages
+	// the Manifest doesn't record are exported. The files of pages no longer
+	// in the export aren't removed.
+	SkipUnchangedPages bool
+	pages              *pageExportState
+	childPageFile      string
+	headingSlugs       headingSlugs
+	// uncounted leaves the text rendered out of the word count, set for
+	// code blocks by ExcludeCodeFromWordCount.
+	uncounted           bool
+	headingAnchor       string
+	codeCaption         string
+	linkTarget          string
 	tableState          tableState
+	numberedListIndex   int
 	previousElementType string
+	previousDepth       int
 	depth               int
 	originalPageRef     *na.Page
 }
 
+// MarkdownFlavor is a dialect of markdown, which decides how syntax not every
+// dialect supports, such as task lists and tables, is rendered.
+type MarkdownFlavor string
+
+const (
+	// MarkdownFlavorGFM is GitHub Flavored Markdown, which is understood by
+	// GitHub and most markdown viewers. To-dos are rendered as task list
+	// items (e.g. "- [ ] todo") and tables as pipe tables.
+	MarkdownFlavorGFM MarkdownFlavor = "gfm"
+	// MarkdownFlavorCommonMark is strict CommonMark, which has neither task
+	// lists nor tables. To-dos are rendered as list items prefixed with an
+	// escaped, literal "[ ]" or "[x]", and tables as HTML. Markdown within a
+	// table's cells is left as is, as CommonMark doesn't parse markdown
+	// inside HTML.
+	MarkdownFlavorCommonMark MarkdownFlavor = "commonmark"
+)
+
+// TodoStyle is how a markdown to-do shows whether it's checked.
+type TodoStyle string
+
+const (
+	// TodoStyleCheckbox renders a checkbox, as a task list item or, for
+	// MarkdownFlavorCommonMark, literal text (e.g. "- [x] todo").
+	TodoStyleCheckbox TodoStyle = "checkbox"
+	// TodoStyleEmoji renders an emoji in place of the checkbox (e.g.
+	// "- ✅ todo" and "- ⬜ todo"), for viewers that show task list syntax
+	// as literal text.
+	TodoStyleEmoji TodoStyle = "emoji"
+)
+
+// OrderedListStyle is the marker numbered list items are rendered with.
+type OrderedListStyle string
+
+const (
+	// OrderedListStyleDecimal numbers items followed by a period, e.g.
+	// "1.".
+	OrderedListStyleDecimal OrderedListStyle = "decimal"
+	// OrderedListStyleLowerAlpha letters items followed by a period, e.g.
+	// "a.", continuing with "aa." after "z.". Only markdown parsers with
+	// fancy list support, such as Pandoc's, understand these, others render
+	// them as plain text.
+	OrderedListStyleLowerAlpha OrderedListStyle = "lower-alpha"
+	// OrderedListStyleLowerRoman numbers items with lowercase roman
+	// numerals followed by a period, e.g. "iv.". As with
+	// OrderedListStyleLowerAlpha, few markdown parsers understand these.
+	OrderedListStyleLowerRoman OrderedListStyle = "lower-roman"
+	// OrderedListStyleParen numbers items followed by a parenthesis, e.g.
+	// "1)", which CommonMark also understands.
+	OrderedListStyleParen OrderedListStyle = "paren"
+)
+
+// TableAlignment is the alignment of the cells in a column of a markdown
+// table.
+type TableAlignment string
+
+const (
+	// TableAlignmentDefault leaves the alignment to the markdown viewer,
+	// which is typically left aligned.
+	TableAlignmentDefault TableAlignment = ""
+	// TableAlignmentLeft aligns cells to the left, e.g. "| :--- |".
+	TableAlignmentLeft TableAlignment = "left"
+	// TableAlignmentCenter centers cells, e.g. "| :---: |".
+	TableAlignmentCenter TableAlignment = "center"
+	// TableAlignmentRight aligns cells to the right, e.g. "| ---: |", which
+	// suits columns of numbers.
+	TableAlignmentRight TableAlignment = "right"
+)
+
 // OverrideOptions contains optional function definitions that can override the
 // default behaviour of a block renderer.
 //
@@ -31,22 +338,35 @@ type RenderOptions struct {
 // to OverrideOptions.Paragraph, the instructions defined in that override will
 // execute instead for every Paragraph Block.
 type OverrideOptions struct {
-	PageHeader   headerFooterOverride
-	PageFooter   headerFooterOverride
-	Header1      blockOverride
-	Header2      blockOverride
-	Header3      blockOverride
-	Paragraph    blockOverride
-	BulletedList blockOverride
-	NumberedList blockOverride
-	Divider      blockOverride
-	Code         blockOverride
-	Todo         blockOverride
-	Quote        blockOverride
-	Callout      blockOverride
-	Image        imageOverride
-	Padding      blockOverride
-	Row          rowOverride
+	PageHeader    headerFooterOverride
+	PageFooter    headerFooterOverride
+	Header1       blockOverride
+	Header2       blockOverride
+	Header3       blockOverride
+	Paragraph     blockOverride
+	BulletedList  blockOverride
+	NumberedList  blockOverride
+	Divider       blockOverride
+	Code          blockOverride
+	Todo          blockOverride
+	Quote         blockOverride
+	Callout       blockOverride
+	Image         imageOverride
+	File          fileOverride
+	Bookmark      blockOverride
+	Equation      blockOverride
+	ChildPage     blockOverride
+	ChildDatabase blockOverride
+	LinkToPage    blockOverride
+	Video         fileOverride
+	Audio         fileOverride
+	ColumnList    blockOverride
+	Column        blockOverride
+	Template      blockOverride
+	BlockEnd      blockOverride
+	Unsupported   blockOverride
+	Padding       blockOverride
+	Row           rowOverride
 }
 
 // ImageSaveOptions define how Image blocks may be handled.
@@ -54,18 +374,144 @@ type ImageSaveOptions struct {
 	// SavePath is the location to persist images downloaded in this library.
 	// When not set, the default is ./images.
 	SavePath string
+	// LinkRelativeTo is the directory links to downloaded images and files
+	// are made relative to, which should be the directory the exported page
+	// is written to. When not set, links are relative to the working
+	// directory, even when SavePath is absolute. Links are only absolute
+	// when no relative path exists.
+	LinkRelativeTo string
 	// IgnoreImages instructs the renderer to not add images to the exported
 	// output.
 	IgnoreImages bool
+	// KeepRemoteImageURLs links to images hosted in Notion at their original
+	// URL, rather than downloading them, for output that's viewed where
+	// Notion can be reached. External images are linked to as usual. Notion
+	// signs these URLs so they expire, typically an hour after the page is
+	// retrieved, after which the images no longer load; only use it when the
+	// output is viewed right away or re-exported regularly.
+	KeepRemoteImageURLs bool
 	// OverwriteExisting forces the redownload of images even if the image
 	// already exists on the local filesystem at the SavePath.
 	OverwriteExisting bool
+	// FilenameFromCaption names images hosted in Notion using a slug of their
+	// caption, rather than the UUID Notion created for them. Images with the
+	// same caption in an export receive a numeric suffix (e.g. cat-2.png).
+	// Images without a caption are still named using their UUID.
+	FilenameFromCaption bool
+	// ImageNamer, when set, names each image block hosted in Notion, such
+	// as to follow a naming scheme (e.g. my-page/001). It's passed the
+	// image, the page it's in, and the name, without extension, the image
+	// would be saved as otherwise, which is its UUID or, with
+	// FilenameFromCaption, a slug of its caption. The name returned is used
+	// in place of it, with the extension added. It may contain directories
+	// within SavePath, which are created. Names used by a different image
+	// receive a numeric suffix, the same as FilenameFromCaption. When an
+	// empty name is returned, the image keeps its name. It's not called by
+	// SaveNotionImageToFilesystem, which isn't passed a block.
+	ImageNamer func(block *na.ImageBlock, page *na.Page, defaultName string) string
+	// DedupeByContent downloads each image and file once per SavePath in an
+	// export, even when its signed URL changes between references,
+	// identifying it by its URL without the query. It also hashes (SHA-256)
+	// each download. When a file with the same content already exists in
+	// SavePath, including from an earlier export, the download is discarded
+	// and the existing file is linked to instead. This saves disk space when
+	// the same image, such as a logo, was uploaded to Notion more than once.
+	DedupeByContent bool
+	// FileMode is the permissions images, files, and subpages are written
+	// with, before the umask is applied. Files that already exist keep their
+	// permissions. When not set, the default is 0644.
+	FileMode os.FileMode
+	// DirMode is the permissions the directories images, files, and
+	// subpages are written to are created with, before the umask is applied.
+	// When not set, the default is 0755.
+	DirMode os.FileMode
+	// DryRun skips downloading images and files, and creating the
+	// directories they're saved in. The path each would be saved to is still
+	// returned, so it's linked to as though it were downloaded. Images whose
+	// extension isn't in their URL are assumed to be a .png, as their
+	// Content-Type can't be known without downloading them.
+	DryRun bool
+	// HTTPClient is used to download images and files. It can be used to
+	// route downloads through a proxy. When not set, a client using Timeout
+	// is created.
+	HTTPClient *http.Client
+	// FS is the filesystem images and files are saved to. It can be used to
+	// save downloads somewhere other than the local filesystem, or to
+	// exercise failures in tests. When not set, the local filesystem is used.
+	FS FileSystem
+	// Timeout bounds how long a single download may take. It's ignored when
+	// HTTPClient is set. When not set, the default is 60 seconds.
+	Timeout time.Duration
+	// MaxRetries is how many times a download is retried when the server
+	// responds with a 429 or 5xx, waiting as long as its Retry-After header
+	// asks, up to a minute. When not set, the default is 3. A negative value
+	// disables retries.
+	MaxRetries int
+	// DownloadConcurrency is how many images and files may be downloaded at
+	// once. When greater than 1, the Notion-hosted files referenced by each
+	// batch of blocks retrieved from Notion are downloaded concurrently
+	// before the blocks are rendered. When not set, files are downloaded one
+	// at a time as they are rendered.
+	DownloadConcurrency int
+	prefetched          prefetchedDownloads
+	// page is the page being rendered, which is passed to ImageNamer.
+	page *na.Page
+	// downloaded, when set, is called with the path of each file downloaded.
+	downloaded func(filePath string)
+	// refreshURL, when set, retrieves the block blockID again and returns
+	// the current URL of the Notion-hosted file it references, as the URL the
+	// block was retrieved with is signed and may expire before the file is
+	// downloaded.
+	refreshURL func(blockID string) (string, error)
+	// blockID is the ID of the block referencing the file being downloaded.
+	blockID string
+	// ctx is the context of the export downloading files, which stops the
+	// download, and any wait before retrying it, when done.
+	ctx context.Context
+	// downloads is the state of the export's downloads. When not set, each
+	// call resolving the options starts with none.
+	downloads *downloads
 }
 
 type tableState struct {
 	tableBlock  *na.TableBlock
 	rowQuantity int
 	currentRow  int
+	// boldColumnHeader is set from RenderOptions.BoldColumnHeaders when the
+	// table starts.
+	boldColumnHeader bool
+	// markdownFlavor is set from RenderOptions.MarkdownFlavor when the table
+	// starts.
+	markdownFlavor MarkdownFlavor
+	// alignment and columnAlignments are set from
+	// RenderOptions.TableAlignment and RenderOptions.ColumnAlignments when
+	// the table starts.
+	alignment        TableAlignment
+	columnAlignments []TableAlignment
+	// padding is the indentation of the table's rows, for renderers that
+	// close the table after its last row.
+	padding string
+}
+
+// newTableState returns the state of the table in tb, whose rows are rendered
+// at the same depth as tb with config.
+func newTableState(tb *na.TableBlock, config RenderOptions) tableState {
+	return tableState{
+		tableBlock:       tb,
+		boldColumnHeader: config.BoldColumnHeaders,
+		markdownFlavor:   config.MarkdownFlavor,
+		alignment:        config.TableAlignment,
+		columnAlignments: config.ColumnAlignments,
+		padding:          createPadding(config.depth, config),
+	}
+}
+
+// columnAlignment returns the alignment of the column at index.
+func (t tableState) columnAlignment(index int) TableAlignment {
+	if index < len(t.columnAlignments) && t.columnAlignments[index] != TableAlignmentDefault {
+		return t.columnAlignments[index]
+	}
+	return t.alignment
 }
 
 type tableCell struct {
@@ -99,6 +545,10 @@ type blockOverride func(*Block) string
 // failure and fail the render job.
 type imageOverride func(*Block) (string, error)
 
+// fileOverride is the same as imageOverride, but for blocks that reference
+// arbitrary files (e.g. PDFs or zips) rather than images.
+type fileOverride func(*Block) (string, error)
+
 // rowOverride enables custom rendering for all table rows in Notion Blocks.
 //
 // It receives a slice of tableCell elements where each slice represents a full
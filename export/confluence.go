@@ -0,0 +1,314 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"path"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	confluenceDividerPattern      = "<hr />"
+	confluenceTaskPattern         = "<ac:task>\n<ac:task-status>%s</ac:task-status>\n<ac:task-body>%s"
+	confluenceTaskComplete        = "complete"
+	confluenceTaskIncomplete      = "incomplete"
+	confluenceMacroOpenPattern    = "<ac:structured-macro ac:name=\"%s\">\n"
+	confluenceMacroClose          = "</ac:structured-macro>"
+	confluenceParameterPattern    = "<ac:parameter ac:name=\"%s\">%s</ac:parameter>\n"
+	confluencePlainBodyPattern    = "<ac:plain-text-body><![CDATA[%s]]></ac:plain-text-body>\n"
+	confluenceRichBodyPattern     = "<ac:rich-text-body><p>%s</p></ac:rich-text-body>\n"
+	confluenceImagePattern        = "<ac:image ac:alt=\"%s\">%s</ac:image>"
+	confluenceImageURLPattern     = "<ri:url ri:value=\"%s\" />"
+	confluenceAttachmentPattern   = "<ri:attachment ri:filename=\"%s\" />"
+	confluenceImageCaptionPattern = "<ac:caption><p>%s</p></ac:caption>"
+
+	// confluenceDefaultPanel is the macro callouts without a matching alert
+	// type are rendered with.
+	confluenceDefaultPanel = "info"
+)
+
+var (
+	// confluencePanelsByAlert maps the GitHub alert type of a callout (see
+	// matchCalloutAlert) to the Confluence panel macro closest in meaning.
+	confluencePanelsByAlert = map[string]string{
+		"NOTE":      "info",
+		"IMPORTANT": "info",
+		"TIP":       "tip",
+		"WARNING":   "note",
+		"CAUTION":   "warning",
+	}
+	// confluenceCDATAReplacer splits the sequence ending a CDATA section
+	// across two sections, so code containing it can't end the section early.
+	confluenceCDATAReplacer = strings.NewReplacer("]]>", "]]]]><![CDATA[>")
+)
+
+// ConfluenceRenderer renders Notion blocks in Confluence's storage format, the
+// XHTML Confluence saves pages as, so exports can be published through its
+// API. It renders most blocks the same as HTMLRenderer, which it embeds, while
+// code blocks, images, callouts, and to-dos are rendered with Confluence's
+// macros and elements. Every element is closed, as the storage format must be
+// well-formed XML.
+type ConfluenceRenderer struct {
+	HTMLRenderer
+}
+
+// RenderPageHeader1 for ConfluenceRenderer wraps the Block's text in a <h1>
+// element. Unlike HTMLRenderer, toggle headings are not wrapped in a
+// <details> element, which Confluence doesn't support, so their children
+// follow the heading. If an override is provided, that function is run and
+// returned value is used instead.
+func (c *ConfluenceRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return renderHTMLHeading(1, htmlHeadingOnePattern, b)
+}
+
+// RenderPageHeader2 for ConfluenceRenderer wraps the Block's text in a <h2>
+// element. If an override is provided, that function is run and returned
+// value is used instead.
+func (c *ConfluenceRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return renderHTMLHeading(2, htmlHeadingTwoPattern, b)
+}
+
+// RenderPageHeader3 for ConfluenceRenderer wraps the Block's text in a <h3>
+// element. If an override is provided, that function is run and returned
+// value is used instead.
+func (c *ConfluenceRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return renderHTMLHeading(3, htmlHeadingThreePattern, b)
+}
+
+// RenderDivider for ConfluenceRenderer returns a self-closing <hr /> element.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (c *ConfluenceRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return confluenceDividerPattern
+}
+
+// RenderTodoList for ConfluenceRenderer returns the Block's text as an
+// (unclosed) <ac:task> with its status. The surrounding <ac:task-list> is
+// added by AddPadding. If an override is provided, that function is run and
+// returned value is used instead.
+func (c *ConfluenceRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a ToDoBlock (e.g. passed from a custom override
+	// pipeline), there is no checked state to read, so render it incomplete.
+	status := confluenceTaskIncomplete
+	if tb, ok := b.BlockRef.(*na.ToDoBlock); ok && tb.ToDo.Checked {
+		status = confluenceTaskComplete
+	}
+	return fmt.Sprintf(confluenceTaskPattern, status, b.Text)
+}
+
+// RenderCallout for ConfluenceRenderer returns an info, tip, note, or warning
+// panel macro, chosen by the callout's icon or color the same as GitHub
+// alerts are (see RenderOptions.CalloutsAsAlerts). Callouts with no match are
+// rendered as an info panel, with their emoji icon kept before the text. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (c *ConfluenceRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	txt := b.Text
+	var panel string
+	if cb, ok := b.BlockRef.(*na.CalloutBlock); ok {
+		panel = confluencePanelsByAlert[matchCalloutAlert(cb)]
+	}
+	if panel == "" {
+		panel = confluenceDefaultPanel
+		if emoji, _ := resolveCalloutIcon(b); emoji != "" {
+			txt = emoji + " " + txt
+		}
+	}
+
+	return fmt.Sprintf(confluenceMacroOpenPattern, panel) +
+		fmt.Sprintf(confluenceRichBodyPattern, txt) + confluenceMacroClose
+}
+
+// RenderImage for ConfluenceRenderer returns an <ac:image> element. External
+// images are referenced by their URL while images hosted in Notion are
+// downloaded and referenced as an attachment of the page, by file name, so
+// they must be uploaded alongside it. When RenderOptions.CaptionImages is set,
+// the caption is added to the image. If an override is provided, that function
+// is run and returned value is used instead.
+func (c *ConfluenceRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.BlockRef.GetType() != "image" {
+		return "", fmt.Errorf("RenderImage was passed a %s but expected an ImageBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	ib := b.BlockRef.(*na.ImageBlock)
+
+	var resource string
+	switch {
+	case ib.Image.External != nil:
+		resource = fmt.Sprintf(confluenceImageURLPattern, html.EscapeString(ib.Image.External.URL))
+	case ib.Image.File == nil:
+		return "", errImageWithoutSource(ib)
+	default:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
+		if err != nil {
+			return "", err
+		}
+		resource = fmt.Sprintf(confluenceAttachmentPattern, html.EscapeString(path.Base(filePath)))
+	}
+
+	if config.CaptionImages && b.Text != "" {
+		resource += fmt.Sprintf(confluenceImageCaptionPattern, b.Text)
+	}
+	return fmt.Sprintf(confluenceImagePattern,
+		html.EscapeString(resolveImageAltText(ib)), resource), nil
+}
+
+// RenderVideo for ConfluenceRenderer returns a paragraph containing a link to
+// the video. Videos are never embedded, as Confluence strips <iframe> and
+// <video> elements. If an override is provided, that function is run and
+// returned value is used instead.
+func (c *ConfluenceRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	config.EmbedVideos = false
+	linked := *b
+	linked.Opts = []RenderOptions{config}
+	return c.HTMLRenderer.RenderVideo(&linked)
+}
+
+// RenderAudio for ConfluenceRenderer returns a paragraph containing a link to
+// the audio, as Confluence strips <audio> elements. The caption is used as the
+// link text when present, otherwise the name of the audio file is used. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (c *ConfluenceRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = html.EscapeString(resolveFileName(filePath))
+	}
+	return fmt.Sprintf(htmlParagraphPattern,
+		fmt.Sprintf(htmlLinkPattern, html.EscapeString(filePath), linkTxt)), nil
+}
+
+// RenderCode for ConfluenceRenderer returns a code macro, with the block's
+// language and caption as its language and title parameters. The code is
+// added as plain text, without its formatting. If an override is provided,
+// that function is run and returned value is used instead.
+func (c *ConfluenceRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	code := fmt.Sprintf(confluenceMacroOpenPattern, "code")
+	body := html.UnescapeString(b.Text)
+	// when the block isn't a CodeBlock (e.g. passed from a custom override
+	// pipeline), there is no language or caption to read.
+	if cb, ok := b.BlockRef.(*na.CodeBlock); ok {
+		language := ResolveLanguageForCodeBlock(cb.Code.Language,
+			resolveRenderConfig(b.Opts...).LanguageOverrides)
+		if language != "" {
+			code += fmt.Sprintf(confluenceParameterPattern, "language", html.EscapeString(language))
+		}
+		if len(cb.Code.Caption) > 0 {
+			code += fmt.Sprintf(confluenceParameterPattern, "title",
+				html.EscapeString(richTextToPlain(cb.Code.Caption)))
+		}
+		body = richTextToPlain(cb.Code.RichText)
+	}
+
+	return code + fmt.Sprintf(confluencePlainBodyPattern, confluenceCDATAReplacer.Replace(body)) +
+		confluenceMacroClose
+}
+
+// AddPadding for ConfluenceRenderer opens and closes grouping elements the
+// same as HTMLRenderer, except to-dos are grouped in an <ac:task-list>. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (c *ConfluenceRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return c.addGroups(b, newConfluenceGroup)
+}
+
+// AddBlockEnd for ConfluenceRenderer closes the <div> elements opened for
+// columns, the same as HTMLRenderer. Nothing is returned for toggle headings,
+// as no <details> element was opened for them.
+func (c *ConfluenceRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	switch b.BlockRef.GetType() {
+	case "heading_1", "heading_2", "heading_3":
+		return ""
+	}
+	return c.HTMLRenderer.AddBlockEnd(b)
+}
+
+// newConfluenceGroup returns the grouping element for a block type, the same
+// as newHTMLGroup except for to-dos, which are grouped in an <ac:task-list>.
+func newConfluenceGroup(blockType string, depth int) (htmlGroup, bool) {
+	if blockType != "to_do" {
+		return newHTMLGroup(blockType, depth)
+	}
+	return htmlGroup{
+		blockType: blockType,
+		depth:     depth,
+		open:      "<ac:task-list>\n",
+		close:     "</ac:task-list>\n",
+		itemClose: "</ac:task-body>\n</ac:task>\n",
+	}, true
+}
@@ -0,0 +1,11 @@
+package export
+
+import "testing"
+
+func TestConfluenceGolden(t *testing.T) {
+	out, err := newTestExporter(t, "confluence", samplePage()).Render("sample")
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+	assertGolden(t, "golden/sample.confluence", out)
+}
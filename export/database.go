@@ -0,0 +1,252 @@
+package export
+
+// This file contains the logic used to export a Notion database, either as a
+// table of its rows' properties or with every row exported as a page.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+// ExportDatabase retrieves every row of the Notion database databaseID and
+// renders the database as a table, with a column for each of its properties
+// and the title property first. Like a page, the table is preceded by a header
+// containing the database's title. The query is paginated, so databases of any
+// size are exported.
+//
+// When RenderOptions.DatabaseAsPages is set, each row is also exported as a
+// page to its own file in RenderOptions.PagesDir, and the title of each row in
+// the table links to that file. Subpages of the rows are exported the same as
+// RenderOptions.RecursePages would.
+//
+// An error is returned if there are issues with client access to the
+// database or its rows, or if rendering fails.
+func (e *exporter) ExportDatabase(databaseID string, opts ...RenderOptions) ([]byte, error) {
+	ctx := context.Background()
+	e.dryRun = nil
+	e.downloads = nil
+	config := e.withDownloads(e.withProgress(e.withDryRun(resolveRenderConfig(opts...))))
+	e.unsupported = nil
+	e.skipped = nil
+	e.root, e.rootTitle = "", ""
+	e.linkTitles = nil
+	e.progress.reset()
+
+	db, err := e.c.Database.Get(ctx, na.DatabaseID(databaseID))
+	if err != nil {
+		if clientErr := resolveClientError("database", databaseID, err); clientErr != nil {
+			return nil, clientErr
+		}
+		return nil, fmt.Errorf("Failed getting Notion database (%s), "+
+			"error from client: %s", databaseID, err)
+	}
+	rows, err := e.queryDatabase(ctx, databaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the file of every row must be known before the table is rendered, so
+	// their titles can link to them.
+	rowFiles := map[string]string{}
+	if config.DatabaseAsPages {
+		config.pages = newPageExportState(databaseID)
+		config.pages.rootTitle = richTextToPlain(db.Title)
+		if err := restorePageExportState(config); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			rowFiles[row.ID.String()] = config.pages.enqueue(row.ID.String(),
+				ResolveTitleInPage(&row), databaseID, ResolvePageFileExtension(e.Renderer))
+		}
+	}
+
+	// renderers expect a page, so the database is passed as a page titled
+	// with the database's title.
+	page := &na.Page{
+		Object: na.ObjectTypePage,
+		ID:     db.ID,
+		URL:    db.URL,
+		Properties: na.Properties{
+			"title": &na.TitleProperty{Type: na.PropertyTypeTitle, Title: db.Title},
+		},
+	}
+	config.originalPageRef = page
+	config.ImageOpts.page = page
+
+	buf := &bytes.Buffer{}
+	e.w = buf
+	headerOverride := config.Overrides.PageHeader
+	if config.OmitPageHeader {
+		headerOverride = func(*na.Page) string { return "" }
+	}
+	err = e.write(e.Renderer.RenderPageHeader(page, headerOverride))
+	if err == nil {
+		blocks := &na.GetChildrenResponse{Results: databaseTableBlocks(db, rows, rowFiles,
+			config.DateFormat)}
+		_, err = e.renderBlocks(ctx, databaseID, blocks, config)
+	}
+	if err == nil {
+		err = e.write(e.Renderer.RenderPageFooter(page, e.resolveFooterOverride(page, config)))
+	}
+	e.page = buf.Bytes()
+	if err != nil {
+		return e.page, fmt.Errorf("Failed rendering Notion database, error: %s", err)
+	}
+
+	if config.DatabaseAsPages {
+		err = e.renderChildPages(ctx, config)
+	}
+	return e.page, err
+}
+
+// renderInlineDatabase renders the rows of the database databaseID, embedded
+// in the page being rendered, as a table at the current depth.
+func (e *exporter) renderInlineDatabase(ctx context.Context, databaseID string,
+	config RenderOptions) error {
+
+	db, err := e.c.Database.Get(ctx, na.DatabaseID(databaseID))
+	if err != nil {
+		if clientErr := resolveClientError("database", databaseID, err); clientErr != nil {
+			return clientErr
+		}
+		return fmt.Errorf("Failed getting Notion database (%s), "+
+			"error from client: %s", databaseID, err)
+	}
+	rows, err := e.queryDatabase(ctx, databaseID)
+	if err != nil {
+		return err
+	}
+
+	blocks := &na.GetChildrenResponse{Results: databaseTableBlocks(db, rows, nil, config.DateFormat)}
+	_, err = e.renderBlocks(ctx, databaseID, blocks, config)
+	return err
+}
+
+// queryDatabase returns every row (page) of the database databaseID,
+// following the query's cursor until no rows remain.
+func (e *exporter) queryDatabase(ctx context.Context, databaseID string) ([]na.Page, error) {
+	var rows []na.Page
+	var cursor na.Cursor
+	for {
+		resp, err := e.c.Database.Query(ctx, na.DatabaseID(databaseID),
+			&na.DatabaseQueryRequest{StartCursor: cursor})
+		if err != nil {
+			if clientErr := resolveClientError("database", databaseID, err); clientErr != nil {
+				return nil, clientErr
+			}
+			return nil, fmt.Errorf("Failed querying Notion database (%s), "+
+				"error from client: %s", databaseID, err)
+		}
+		rows = append(rows, resp.Results...)
+		e.progress.logf("Fetched %d rows of database %s", len(rows), databaseID)
+
+		if !resp.HasMore {
+			return rows, nil
+		}
+		cursor = resp.NextCursor
+	}
+}
+
+// databaseTableBlocks returns a table block, followed by its rows, listing
+// the property values of every row in rows. The first row is a header naming
+// each property. Titles of rows found in rowFiles link to the file named
+// there. Dates are formatted with dateLayout, as described in
+// resolvePropertyValue.
+func databaseTableBlocks(db *na.Database, rows []na.Page, rowFiles map[string]string,
+	dateLayout string) []na.Block {
+
+	columns := databaseColumns(db)
+	table := &na.TableBlock{
+		BasicBlock: na.BasicBlock{Object: na.ObjectTypeBlock, Type: na.BlockTypeTableBlock},
+		Table:      na.Table{TableWidth: len(columns), HasRowHeader: true},
+	}
+	blocks := []na.Block{table}
+
+	header := make([][]na.RichText, len(columns))
+	for i, name := range columns {
+		header[i] = plainRichText(name)
+	}
+	blocks = append(blocks, databaseTableRow(header))
+
+	for _, row := range rows {
+		cells := make([][]na.RichText, len(columns))
+		for i, name := range columns {
+			p, ok := row.Properties[name]
+			if !ok {
+				continue
+			}
+			cells[i] = plainRichText(formatPropertyValue(resolvePropertyValue(p, dateLayout)))
+			if p.GetType() == na.PropertyTypeTitle && len(cells[i]) > 0 {
+				cells[i][0].Href = rowFiles[row.ID.String()]
+			}
+		}
+		blocks = append(blocks, databaseTableRow(cells))
+	}
+
+	return blocks
+}
+
+// databaseTableRow returns a table_row block containing cells.
+func databaseTableRow(cells [][]na.RichText) *na.TableRowBlock {
+	return &na.TableRowBlock{
+		BasicBlock: na.BasicBlock{Object: na.ObjectTypeBlock, Type: na.BlockTypeTableRowBlock},
+		TableRow:   na.TableRow{Cells: cells},
+	}
+}
+
+// databaseColumns returns the names of the properties in the database's
+// schema, ordered by name with the title property first.
+func databaseColumns(db *na.Database) []string {
+	var title string
+	var columns []string
+	for name, p := range db.Properties {
+		if p.GetType() == na.PropertyConfigTypeTitle {
+			title = name
+			continue
+		}
+		columns = append(columns, name)
+	}
+	sort.Strings(columns)
+
+	if title == "" {
+		return columns
+	}
+	return append([]string{title}, columns...)
+}
+
+// plainRichText returns s as RichText with no formatting. Empty strings
+// return no RichText.
+func plainRichText(s string) []na.RichText {
+	if s == "" {
+		return nil
+	}
+	return []na.RichText{{Type: na.ObjectTypeText, Text: na.Text{Content: s},
+		Annotations: &na.Annotations{}, PlainText: s}}
+}
+
+// formatPropertyValue returns a value, as resolved by resolvePropertyValue,
+// as a string. Lists are joined with ", " and date ranges with " → ".
+func formatPropertyValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []string:
+		return strings.Join(val, ", ")
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case map[string]string:
+		return val["start"] + " → " + val["end"]
+	}
+
+	return fmt.Sprint(v)
+}
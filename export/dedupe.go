@@ -0,0 +1,137 @@
+package export
+
+// This file contains the logic used to deduplicate downloaded images and files
+// by their source and content, so a file referenced more than once is only
+// downloaded once, and identical files uploaded to Notion more than once are
+// only kept once.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+)
+
+// resolveDownloadSource returns what identifies the file at address, which is
+// its URL without the query or fragment. Notion signs the URLs of hosted files
+// in their query, which changes every time the file is retrieved, while the
+// rest of the URL stays the same.
+func resolveDownloadSource(address string) string {
+	u, err := url.Parse(address)
+	if err != nil {
+		return address
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String()
+}
+
+// downloadedSource returns the path the file at address was already
+// downloaded to in config.SavePath, so it isn't fetched again. false is
+// returned when config.DedupeByContent isn't set, the file wasn't downloaded,
+// or it's no longer on the filesystem.
+func downloadedSource(address string, config ImageSaveOptions) (string, bool) {
+	if !config.DedupeByContent {
+		return "", false
+	}
+	config.downloads.mu.Lock()
+	filePath, ok := config.downloads.sourceIndex[config.SavePath][resolveDownloadSource(address)]
+	config.downloads.mu.Unlock()
+	if !ok {
+		return "", false
+	}
+	if _, err := config.FS.Stat(filePath); err != nil {
+		return "", false
+	}
+	return filePath, true
+}
+
+// claimSource records that the file at address was saved to filePath in dir.
+func (d *downloads) claimSource(dir, address, filePath string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	index, ok := d.sourceIndex[dir]
+	if !ok {
+		index = map[string]string{}
+		d.sourceIndex[dir] = index
+	}
+	index[resolveDownloadSource(address)] = filePath
+}
+
+// saveDedupedDownload persists the contents of r to filePath, the same as
+// writeToFilesystem, while hashing them. When a file with the same content is
+// already in config.SavePath, the new file is removed and the path of the
+// existing file is returned instead.
+func saveDedupedDownload(r io.Reader, filePath string, config ImageSaveOptions) (string, error) {
+	h := sha256.New()
+	filePath, err := writeToFilesystem(config.FS, io.TeeReader(r, h), filePath, config.FileMode)
+	if err != nil {
+		return "", err
+	}
+
+	existing := config.downloads.claimContent(config.FS, config.SavePath, hex.EncodeToString(h.Sum(nil)), filePath)
+	if existing == filePath {
+		return filePath, nil
+	}
+	if err := config.FS.Remove(filePath); err != nil {
+		return "", err
+	}
+	// files are saved in a directory of their own (see
+	// SaveNotionFileToFilesystem), which is left empty. Removal fails, and is
+	// ignored, when the directory holds other files.
+	if dir := filepath.Dir(filePath); filepath.Clean(dir) != filepath.Clean(config.SavePath) {
+		config.FS.Remove(dir)
+	}
+
+	return existing, nil
+}
+
+// claimContent returns the path of the file in dir, within fsys, whose content
+// hashes to sum. When no such file is known, filePath claims sum and is
+// returned.
+func (d *downloads) claimContent(fsys FileSystem, dir, sum, filePath string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	index, ok := d.contentIndex[dir]
+	if !ok {
+		index = indexContent(fsys, dir, filePath)
+		d.contentIndex[dir] = index
+	}
+	if existing, ok := index[sum]; ok {
+		return existing
+	}
+	index[sum] = filePath
+
+	return filePath
+}
+
+// indexContent returns the SHA-256 of every file in dir, within fsys, and
+// directories beneath it, mapped to the file's path. skip is left out, as it's
+// the file being claimed. Files that can't be read are left out.
+func indexContent(fsys FileSystem, dir, skip string) map[string]string {
+	index := map[string]string{}
+	fsys.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || p == skip {
+			return nil
+		}
+		f, err := fsys.Open(p)
+		if err != nil {
+			return nil
+		}
+		defer f.Close()
+		h := sha256.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return nil
+		}
+		sum := hex.EncodeToString(h.Sum(nil))
+		if _, ok := index[sum]; !ok {
+			index[sum] = p
+		}
+		return nil
+	})
+
+	return index
+}
@@ -0,0 +1,74 @@
+package export
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDedupeDownloads(t *testing.T) {
+	tests := []struct {
+		name string
+		// paths are requested in order, as the path and query of the URL.
+		paths       []string
+		opts        ImageSaveOptions
+		wantFetches int
+		wantSame    bool
+	}{
+		{
+			name: "re-signed URL isn't fetched again",
+			paths: []string{"/secure.notion-static.com/aaa/logo.png?sig=1",
+				"/secure.notion-static.com/aaa/logo.png?sig=2"},
+			opts:        ImageSaveOptions{DedupeByContent: true, OverwriteExisting: true},
+			wantFetches: 1,
+			wantSame:    true,
+		},
+		{
+			name: "same content uploaded twice is kept once",
+			paths: []string{"/secure.notion-static.com/aaa/logo.png?sig=1",
+				"/secure.notion-static.com/bbb/logo.png?sig=1"},
+			opts:        ImageSaveOptions{DedupeByContent: true},
+			wantFetches: 2,
+			wantSame:    true,
+		},
+		{
+			name: "without deduplication",
+			paths: []string{"/secure.notion-static.com/aaa/logo.png?sig=1",
+				"/secure.notion-static.com/aaa/logo.png?sig=2"},
+			opts:        ImageSaveOptions{OverwriteExisting: true},
+			wantFetches: 2,
+			wantSame:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches++
+				w.Write([]byte("logo"))
+			}))
+			defer srv.Close()
+
+			opts := tt.opts
+			opts.SavePath = t.TempDir()
+			opts.HTTPClient = srv.Client()
+			// the downloads are made in the same export.
+			opts.downloads = newDownloads()
+			var saved []string
+			for _, p := range tt.paths {
+				filePath, err := SaveNotionImageToFilesystem(srv.URL+p, opts)
+				if err != nil {
+					t.Fatalf("SaveNotionImageToFilesystem(%q) error: %s", p, err)
+				}
+				saved = append(saved, filePath)
+			}
+
+			if fetches != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", fetches, tt.wantFetches)
+			}
+			if same := saved[0] == saved[1]; same != tt.wantSame {
+				t.Errorf("saved to %q, want same path: %t", saved, tt.wantSame)
+			}
+		})
+	}
+}
@@ -0,0 +1,762 @@
+package export
+
+// This file contains the functionality for downloading files hosted in Notion
+// (e.g. images) to the local filesystem.
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	defaultImageSaveLocation = "images"
+	// notionImageExtension is the extension used for images when it can't be
+	// determined from the image's URL or Content-Type.
+	notionImageExtension = ".png"
+	// defaultDownloadTimeout bounds each image or file download when no
+	// HTTPClient or Timeout is provided.
+	defaultDownloadTimeout = 60 * time.Second
+	// defaultMaxRetries is the number of times a download is retried after a
+	// 429 or 5xx response when MaxRetries isn't set.
+	defaultMaxRetries = 3
+	// defaultFileMode and defaultDirMode are the permissions files and
+	// directories are created with when FileMode and DirMode aren't set.
+	defaultFileMode os.FileMode = 0644
+	defaultDirMode  os.FileMode = 0755
+	// retryBaseDelay is the wait before the first retry of a download when
+	// the response has no Retry-After header.
+	retryBaseDelay = 500 * time.Millisecond
+	// maxRetryDelay is the longest wait before a retry, however long the
+	// Retry-After header asks for.
+	maxRetryDelay = time.Minute
+	// sanitizedNameLength is the length of the hash replacing names that
+	// sanitizeFileName can't make safe.
+	sanitizedNameLength = 16
+)
+
+var (
+	// imageExtensions maps common image types to their preferred extension,
+	// as mime.ExtensionsByType may return several in no particular order
+	// (e.g. .jpe, .jpeg, .jpg).
+	imageExtensions = map[string]string{
+		"image/jpeg":    ".jpg",
+		"image/png":     ".png",
+		"image/gif":     ".gif",
+		"image/webp":    ".webp",
+		"image/svg+xml": ".svg",
+		"image/bmp":     ".bmp",
+		"image/tiff":    ".tiff",
+		"image/avif":    ".avif",
+	}
+)
+
+// errImageWithoutSource returns the error reported when an ImageBlock has
+// neither a Notion-hosted file nor an external URL.
+func errImageWithoutSource(ib *na.ImageBlock) error {
+	return fmt.Errorf("image block %s has neither a Notion-hosted file nor an "+
+		"external URL", ib.GetID())
+}
+
+// createPathIfNonExistent creates the directory path, and any parents, in fsys
+// with the permissions mode when it doesn't exist.
+func createPathIfNonExistent(fsys FileSystem, path string, mode os.FileMode) error {
+	if _, err := fsys.Stat(path); errors.Is(err, os.ErrNotExist) {
+		err := fsys.MkdirAll(path, mode)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveNotionImageToFilesystem takes the URL of a Notion-hosted image. The URL
+// is typically an S3 address. ImageSaveOptions can be optinally provided. If
+// multiple options are provided, only the first is respected. By default the
+// image is save in a ./images directory. If successful, the path the image was
+// saved is returned. An error is returned if the image can not be returned or
+// saved to the filesystem.
+//
+// The image is named using the UUID Notion created for it. Its extension is
+// taken from the URL and, when the URL has none, from the Content-Type of the
+// downloaded image. When neither is known, .png is used.
+//
+// When ImageSaveOptions.DryRun is set, the image is not downloaded and no
+// directories are created. The path the image would be saved to is returned,
+// which uses .png when the URL has no extension.
+//
+// The image is only requested with ImageSaveOptions.HTTPClient and only
+// written within ImageSaveOptions.SavePath of ImageSaveOptions.FS, and the
+// host of the URL isn't checked, so the download can be exercised against an
+// httptest.Server with SavePath in a temporary directory.
+func SaveNotionImageToFilesystem(address string,
+	opts ...ImageSaveOptions) (string, error) {
+
+	return saveNotionImage(address, "", opts...)
+}
+
+// saveImageBlock downloads the Notion-hosted image in ib. With
+// ImageSaveOptions.KeepRemoteImageURLs, nothing is downloaded and the image's
+// URL is returned instead. When
+// ImageSaveOptions.FilenameFromCaption is set and the image has a caption, the
+// image is named using a slug of its caption. Otherwise, it is named as
+// described in SaveNotionImageToFilesystem. The path returned is resolved
+// with resolveLinkPath, so it can be linked to from the exported page.
+func saveImageBlock(ib *na.ImageBlock, opts ImageSaveOptions) (string, error) {
+	if opts.KeepRemoteImageURLs {
+		return ib.Image.File.URL, nil
+	}
+	var filePath string
+	var err error
+	if r, ok := opts.prefetched[ib.GetID().String()]; ok {
+		filePath, err = r.path, r.err
+	} else {
+		opts.blockID = ib.GetID().String()
+		filePath, err = saveNotionImage(ib.Image.File.URL, claimImageBlockName(ib, opts), opts)
+	}
+	if err != nil {
+		return "", err
+	}
+	return resolveLinkPath(filePath, opts), nil
+}
+
+// claimImageBlockName returns the name the Notion-hosted image in ib is saved
+// as. When ImageSaveOptions.FilenameFromCaption is set and the image has a
+// caption, a slug of its caption is claimed using claimImageName. When
+// ImageSaveOptions.ImageNamer is set, the name it returns is claimed instead.
+// Otherwise, an empty string is returned and the image is named using its
+// UUID.
+func claimImageBlockName(ib *na.ImageBlock, opts ImageSaveOptions) string {
+	caption := richTextToPlain(ib.Image.Caption)
+	fromCaption := opts.FilenameFromCaption && caption != ""
+	if !fromCaption && opts.ImageNamer == nil {
+		return ""
+	}
+	resources, err := notionFileURLSegments(ib.Image.File.URL)
+	if err != nil {
+		// the URL is reported as invalid when the image is saved.
+		return ""
+	}
+	config := ResolveImageSaveOptions(opts)
+
+	name := resources[2]
+	if fromCaption {
+		name = config.downloads.claimImageName(config.SavePath, slugify(caption), resources[2])
+	}
+	if opts.ImageNamer != nil {
+		if named := opts.ImageNamer(ib, opts.page, name); named != "" && named != name {
+			name = config.downloads.claimImageName(config.SavePath, named, resources[2])
+		}
+	}
+	return name
+}
+
+// saveNotionImage is the same as SaveNotionImageToFilesystem, except the image
+// is named name. When name is already used by a different image in the same
+// directory, a numeric suffix is added (e.g. cat-2). When name is empty, the
+// UUID Notion created for the image is used.
+func saveNotionImage(address string, name string,
+	opts ...ImageSaveOptions) (string, error) {
+
+	// establish config for image save from options
+	config := ResolveImageSaveOptions(opts...)
+
+	// determine name of image using UUID created by notion
+	resources, err := notionFileURLSegments(address)
+	if err != nil {
+		return "", err
+	}
+	fileName := resources[2]
+	if name != "" {
+		fileName = config.downloads.claimImageName(config.SavePath, name, resources[2])
+	}
+	basePath := filepath.Join(config.SavePath, fileName)
+	// names given by ImageSaveOptions.ImageNamer may be in a directory
+	// within SavePath.
+	if !config.DryRun {
+		if err := createPathIfNonExistent(config.FS, filepath.Dir(basePath), config.DirMode); err != nil {
+			return "", fmt.Errorf("Failed creating directory for image, error: %s", err)
+		}
+	}
+
+	if ext := path.Ext(resources[len(resources)-1]); ext != "" {
+		return downloadToFilesystem(address, basePath+ext, config)
+	}
+
+	// the extension can't be known until the image is downloaded, so look for
+	// an existing copy with any extension.
+	if !config.OverwriteExisting {
+		matches, _ := config.FS.Glob(basePath + ".*")
+		if len(matches) > 0 {
+			return matches[0], nil
+		}
+	}
+	// the Content-Type isn't requested in a dry run, so the image is assumed
+	// to have the default extension.
+	if config.DryRun {
+		return planDownload(basePath+notionImageExtension, config), nil
+	}
+	if existing, ok := downloadedSource(address, config); ok {
+		return existing, nil
+	}
+	resp, err := fetchNotionFile(address, config)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	filePath := basePath + resolveImageExtension(resp.Header.Get("Content-Type"))
+
+	return saveDownload(resp.Body, address, filePath, config)
+}
+
+// SaveNotionFileToFilesystem takes the URL of a Notion-hosted file, such as a
+// PDF or zip attached to a page. It behaves the same as
+// SaveNotionImageToFilesystem, except the file keeps its original name and
+// extension. As different files may share a name, each is saved in a
+// directory named after the UUID Notion created for it, e.g.
+// ./images/<uuid>/report.pdf.
+func SaveNotionFileToFilesystem(address string,
+	opts ...ImageSaveOptions) (string, error) {
+
+	config := ResolveImageSaveOptions(opts...)
+
+	resources, err := notionFileURLSegments(address)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(config.SavePath, resources[2])
+	if !config.DryRun {
+		if err := createPathIfNonExistent(config.FS, dir, config.DirMode); err != nil {
+			return "", fmt.Errorf("Failed creating directory for file, error: %s", err)
+		}
+	}
+	filePath := filepath.Join(dir, resources[len(resources)-1])
+
+	return downloadToFilesystem(address, filePath, config)
+}
+
+// notionFileURLSegments splits the path of a Notion-hosted file's URL into its
+// segments. These URLs take the form
+// https://<bucket>/secure.notion-static.com/<uuid>/<filename>, so the UUID is
+// at index 2 and the filename is last. An error is returned when the URL does
+// not have this shape. As segments are used to name files, each is sanitized
+// with sanitizeFileName.
+func notionFileURLSegments(address string) ([]string, error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	// the path is split before it's unescaped, so an escaped "/" (%2F) stays
+	// within its segment.
+	resources := strings.Split(u.EscapedPath(), "/")
+	if len(resources) < 4 {
+		return nil, fmt.Errorf("Path from Notion file URL was invalid. Path was: %s", address)
+	}
+	for i, r := range resources {
+		if unescaped, err := url.PathUnescape(r); err == nil {
+			r = unescaped
+		}
+		resources[i] = sanitizeFileName(r)
+	}
+	return resources, nil
+}
+
+// sanitizeFileName returns name safe to use as a single element of a path, so
+// a crafted URL can't write outside the directory files are saved to. Path
+// separators and NUL bytes are replaced by "-", and ".." is removed. A name
+// left empty or ".", which would refer to the directory itself, is replaced
+// by a hash of name.
+func sanitizeFileName(name string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == 0 {
+			return '-'
+		}
+		return r
+	}, name)
+	sanitized = strings.ReplaceAll(sanitized, "..", "")
+	if sanitized == "" || sanitized == "." {
+		sum := sha256.Sum256([]byte(name))
+		return hex.EncodeToString(sum[:])[:sanitizedNameLength]
+	}
+	return sanitized
+}
+
+// downloadToFilesystem downloads the file at address and saves it to
+// filePath. When OverwriteExisting is false and a file already exists at
+// filePath, the download is skipped. If successful, filePath is returned. In a
+// dry run, nothing is downloaded and filePath is returned as though it were.
+func downloadToFilesystem(address string, filePath string,
+	config ImageSaveOptions) (string, error) {
+
+	// if file exists, do no more and return the existing file's path. When
+	// it can't be told whether it exists, such as when the directory can't
+	// be read, it's reported rather than overwritten.
+	if !config.OverwriteExisting {
+		_, err := config.FS.Stat(filePath)
+		if err == nil {
+			return filePath, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+	}
+	if config.DryRun {
+		return planDownload(filePath, config), nil
+	}
+	if existing, ok := downloadedSource(address, config); ok {
+		return existing, nil
+	}
+
+	resp, err := fetchNotionFile(address, config)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return saveDownload(resp.Body, address, filePath, config)
+}
+
+// fetchNotionFile requests the file at address using the HTTPClient in config.
+// When the response is a 429 or 5xx, the request is retried up to
+// config.MaxRetries times, waiting as long as the Retry-After header asks or,
+// when it's absent, backing off exponentially. When the response is a 403,
+// which is how an expired signed URL is rejected, and the block referencing
+// the file is known, the block is retrieved again and its fresh URL is
+// requested once. An error is returned when the request fails or is not
+// successful. The caller must close the response body.
+func fetchNotionFile(address string, config ImageSaveOptions) (*http.Response, error) {
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		// download the file from the Notion-provided URL
+		req, err := http.NewRequestWithContext(config.ctx, http.MethodGet, address, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := config.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == 200 {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode == http.StatusForbidden && !refreshed &&
+			config.refreshURL != nil && config.blockID != "" {
+			refreshed = true
+			address, err = config.refreshURL(config.blockID)
+			if err != nil {
+				return nil, fmt.Errorf("Failed refreshing expired URL of file in block %s, "+
+					"error: %s", config.blockID, err)
+			}
+			continue
+		}
+
+		if !isRetryableStatus(resp.StatusCode) || attempt >= config.MaxRetries {
+			return nil, fmt.Errorf("Non 200 status code returned when retrieveing."+
+				"Code was: %d", resp.StatusCode)
+		}
+		timer := time.NewTimer(resolveRetryDelay(resp.Header.Get("Retry-After"), attempt))
+		select {
+		case <-config.ctx.Done():
+			timer.Stop()
+			return nil, config.ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// isRetryableStatus reports whether a response with status code may succeed
+// if requested again.
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// resolveRetryDelay returns how long to wait before the next retry. A
+// Retry-After header, in seconds or as an HTTP date, takes precedence.
+// Otherwise, the delay doubles with every attempt, starting at
+// retryBaseDelay. Either way, the delay is at most maxRetryDelay.
+func resolveRetryDelay(retryAfter string, attempt int) time.Duration {
+	// the backoff is capped once it overflows, which it does for large
+	// attempts.
+	delay := maxRetryDelay
+	if backoff := retryBaseDelay << attempt; backoff>>attempt == retryBaseDelay {
+		delay = backoff
+	}
+	if secs, err := strconv.Atoi(retryAfter); err == nil && secs >= 0 {
+		delay = maxRetryDelay
+		if time.Duration(secs) < maxRetryDelay/time.Second {
+			delay = time.Duration(secs) * time.Second
+		}
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		delay = 0
+		if d := time.Until(t); d > 0 {
+			delay = d
+		}
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+	return delay
+}
+
+// saveDownload persists the downloaded contents of r, the file at address, to
+// filePath, reporting the download when config asks for it. If successful,
+// filePath is returned. When config.DedupeByContent is set, the path of an
+// existing file with the same content may be returned instead, and the path
+// is recorded so later references to address aren't downloaded again.
+func saveDownload(r io.Reader, address, filePath string, config ImageSaveOptions) (string, error) {
+	var err error
+	if config.DedupeByContent {
+		filePath, err = saveDedupedDownload(r, filePath, config)
+		if err == nil {
+			config.downloads.claimSource(config.SavePath, address, filePath)
+		}
+	} else {
+		filePath, err = writeToFilesystem(config.FS, r, filePath, config.FileMode)
+	}
+	if err == nil && config.downloaded != nil {
+		config.downloaded(filePath)
+	}
+	return filePath, err
+}
+
+// planDownload reports filePath as downloaded, without downloading anything,
+// for a dry run. filePath is returned.
+func planDownload(filePath string, config ImageSaveOptions) string {
+	if config.downloaded != nil {
+		config.downloaded(filePath)
+	}
+	return filePath
+}
+
+// writeToFilesystem persists the contents of r to filePath in fsys, which is
+// created with the permissions mode when it doesn't exist. If successful,
+// filePath is returned.
+func writeToFilesystem(fsys FileSystem, r io.Reader, filePath string, mode os.FileMode) (string, error) {
+	f, err := fsys.OpenFile(filePath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	if err != nil {
+		return "", err
+	}
+
+	return filePath, nil
+}
+
+// writeFileAtomic writes data to filePath, with the permissions mode, by
+// writing it to a temporary file in the same directory and renaming it over
+// filePath. Readers see either the old or the new contents, never a partial
+// write.
+func writeFileAtomic(filePath string, data []byte, mode os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".*")
+	if err != nil {
+		return err
+	}
+	// the temporary file is removed unless it was renamed.
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filePath)
+}
+
+// downloads is the state of the images and files downloaded during an
+// export, which is passed to the downloads through ImageSaveOptions. It's
+// created for each export, so the names images receive, and the files they're
+// deduplicated against, don't depend on the exports made before.
+type downloads struct {
+	mu sync.Mutex
+	// imageNames tracks which image (by UUID) claimed each name derived from
+	// a caption, keyed by the name's path without extension. This keeps names
+	// stable when the same image is saved more than once, while images with
+	// the same caption receive distinct names.
+	imageNames map[string]string
+	// contentIndex maps the SHA-256 of every file known in a SavePath to the
+	// path of the first file with that content, keyed by SavePath. A SavePath
+	// is indexed, including files saved by earlier exports, the first time a
+	// download is saved to it.
+	contentIndex map[string]map[string]string
+	// sourceIndex maps the source of every file downloaded to a SavePath, as
+	// returned by resolveDownloadSource, to the path it was saved to, keyed
+	// by SavePath.
+	sourceIndex map[string]map[string]string
+}
+
+// newDownloads returns the state of an export that hasn't downloaded anything.
+func newDownloads() *downloads {
+	return &downloads{
+		imageNames:   map[string]string{},
+		contentIndex: map[string]map[string]string{},
+		sourceIndex:  map[string]map[string]string{},
+	}
+}
+
+// claimImageName returns the name, without extension, the image identified by
+// uuid should be saved as in dir. The first image to claim name receives it,
+// and later claims by the same image receive it again. Claims by other images
+// receive name with the first free numeric suffix, starting at 2.
+func (d *downloads) claimImageName(dir, name, uuid string) string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	candidate := name
+	for i := 2; ; i++ {
+		key := filepath.Join(dir, candidate)
+		if owner, ok := d.imageNames[key]; !ok || owner == uuid {
+			d.imageNames[key] = uuid
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+}
+
+// resolveImageExtension returns the file extension for an image's
+// Content-Type, e.g. image/jpeg returns .jpg. When the type is unknown, .png
+// is returned.
+func resolveImageExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return notionImageExtension
+	}
+	if ext, ok := imageExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return notionImageExtension
+}
+
+// hostedFileURL returns the URL of the Notion-hosted file referenced by b, or
+// an empty string when b doesn't reference one.
+func hostedFileURL(b na.Block) string {
+	var hosted *na.FileObject
+	switch fb := b.(type) {
+	case *na.ImageBlock:
+		hosted = fb.Image.File
+	case *na.FileBlock:
+		hosted = fb.File.File
+	case *na.VideoBlock:
+		hosted = fb.Video.File
+	case *AudioBlock:
+		hosted = fb.Audio.File
+	}
+	if hosted == nil {
+		return ""
+	}
+	return hosted.URL
+}
+
+// resolveFileBlockPath returns the location a file block should be linked to.
+// For external files, this is the URL of the file. For Notion-hosted files,
+// the file is downloaded and this is its path on the local filesystem.
+func resolveFileBlockPath(fb *na.FileBlock, opts ImageSaveOptions) (string, error) {
+	return resolveBlockSourcePath(fb, fb.File.File, fb.File.External, opts)
+}
+
+// resolveBlockSourcePath returns the location of the file referenced by b,
+// which is either hosted in Notion or external. Hosted files are downloaded
+// and their local path is returned, while the URL of external files is
+// returned as is. An error is returned when b has neither.
+func resolveBlockSourcePath(b na.Block, hosted, external *na.FileObject,
+	opts ImageSaveOptions) (string, error) {
+
+	if external != nil {
+		return external.URL, nil
+	}
+	if hosted == nil {
+		return "", fmt.Errorf("%s block %s has neither a Notion-hosted file "+
+			"nor an external URL", b.GetType(), b.GetID())
+	}
+	var filePath string
+	var err error
+	if r, ok := opts.prefetched[b.GetID().String()]; ok {
+		filePath, err = r.path, r.err
+	} else {
+		opts.blockID = b.GetID().String()
+		filePath, err = SaveNotionFileToFilesystem(hosted.URL, opts)
+	}
+	if err != nil {
+		return "", err
+	}
+	return resolveLinkPath(filePath, opts), nil
+}
+
+// resolveLinkPath returns the path used to link to the downloaded file at
+// filePath. When ImageSaveOptions.LinkRelativeTo is set, the path is made
+// relative to it. Otherwise, an absolute filePath is made relative to the
+// working directory, so the export stays portable. When no relative path
+// exists (e.g. the paths are on different Windows volumes), filePath is
+// returned. The path always uses forward slashes.
+func resolveLinkPath(filePath string, opts ImageSaveOptions) string {
+	base := opts.LinkRelativeTo
+	if base == "" && filepath.IsAbs(filePath) {
+		base = "."
+	}
+	if base != "" {
+		if rel, err := relativePath(base, filePath); err == nil {
+			filePath = rel
+		}
+	}
+	return filepath.ToSlash(filePath)
+}
+
+// relativePath returns target relative to base. Unlike filepath.Rel, either
+// may be absolute while the other is relative to the working directory.
+func relativePath(base, target string) (string, error) {
+	if filepath.IsAbs(base) != filepath.IsAbs(target) {
+		var err error
+		if base, err = filepath.Abs(base); err != nil {
+			return "", err
+		}
+		if target, err = filepath.Abs(target); err != nil {
+			return "", err
+		}
+	}
+	return filepath.Rel(base, target)
+}
+
+// resolveFileName returns the name of the file at address, which is the last
+// segment of its path. When no name can be found, the address is returned.
+func resolveFileName(address string) string {
+	u, err := url.Parse(address)
+	if err != nil || path.Base(u.Path) == "/" || path.Base(u.Path) == "." {
+		return address
+	}
+	return path.Base(u.Path)
+}
+
+// ResolveImageSaveOptions takes a list of ImageSaveOptions and sets defaults,
+// overwritting them with any options specified. While it takes multiple
+// arguments, it only respects the first option passed.
+func ResolveImageSaveOptions(opts ...ImageSaveOptions) ImageSaveOptions {
+	// setup default
+	config := ImageSaveOptions{
+		SavePath:     defaultImageSaveLocation,
+		IgnoreImages: false,
+		Timeout:      defaultDownloadTimeout,
+		MaxRetries:   defaultMaxRetries,
+		FileMode:     defaultFileMode,
+		DirMode:      defaultDirMode,
+		ctx:          context.Background(),
+	}
+
+	// No options were provided; return the default
+	if len(opts) < 1 {
+		config.downloads = newDownloads()
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+		config.FS = osFS{}
+		return config
+	}
+
+	if opts[0].SavePath != "" {
+		config.SavePath = opts[0].SavePath
+	}
+
+	if opts[0].IgnoreImages {
+		config.IgnoreImages = opts[0].IgnoreImages
+	}
+
+	if opts[0].KeepRemoteImageURLs {
+		config.KeepRemoteImageURLs = opts[0].KeepRemoteImageURLs
+	}
+
+	if opts[0].OverwriteExisting {
+		config.OverwriteExisting = opts[0].OverwriteExisting
+	}
+
+	if opts[0].FilenameFromCaption {
+		config.FilenameFromCaption = opts[0].FilenameFromCaption
+	}
+
+	if opts[0].DedupeByContent {
+		config.DedupeByContent = opts[0].DedupeByContent
+	}
+
+	if opts[0].DryRun {
+		config.DryRun = opts[0].DryRun
+	}
+
+	if opts[0].FileMode != 0 {
+		config.FileMode = opts[0].FileMode
+	}
+
+	if opts[0].DirMode != 0 {
+		config.DirMode = opts[0].DirMode
+	}
+
+	if opts[0].LinkRelativeTo != "" {
+		config.LinkRelativeTo = opts[0].LinkRelativeTo
+	}
+
+	if opts[0].DownloadConcurrency > 0 {
+		config.DownloadConcurrency = opts[0].DownloadConcurrency
+	}
+
+	if opts[0].ImageNamer != nil {
+		config.ImageNamer = opts[0].ImageNamer
+	}
+
+	config.prefetched = opts[0].prefetched
+	config.page = opts[0].page
+	config.downloaded = opts[0].downloaded
+	config.refreshURL = opts[0].refreshURL
+	config.blockID = opts[0].blockID
+	if opts[0].ctx != nil {
+		config.ctx = opts[0].ctx
+	}
+	config.downloads = opts[0].downloads
+	if config.downloads == nil {
+		config.downloads = newDownloads()
+	}
+
+	if opts[0].Timeout > 0 {
+		config.Timeout = opts[0].Timeout
+	}
+
+	if opts[0].MaxRetries < 0 {
+		config.MaxRetries = 0
+	} else if opts[0].MaxRetries > 0 {
+		config.MaxRetries = opts[0].MaxRetries
+	}
+
+	if opts[0].HTTPClient != nil {
+		config.HTTPClient = opts[0].HTTPClient
+	} else {
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	if opts[0].FS != nil {
+		config.FS = opts[0].FS
+	} else {
+		config.FS = osFS{}
+	}
+
+	return config
+}
@@ -0,0 +1,425 @@
+package export
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	na "github.com/jomei/notionapi"
+)
+
+// failingFS is the local filesystem, except files can't be opened for
+// writing.
+type failingFS struct {
+	osFS
+}
+
+func (failingFS) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	return nil, errors.New("read-only filesystem")
+}
+
+func TestSaveNotionImageToFilesystem(t *testing.T) {
+	tests := []struct {
+		name string
+		// path is requested from the server, which responds with the status
+		// and type query parameters, when set, as the status code and
+		// Content-Type, asking for retries to be made immediately.
+		path string
+		opts ImageSaveOptions
+		// existing is written to the file the image is saved as, relative to
+		// SavePath, before it's saved.
+		existing    string
+		want        string
+		wantContent string
+		wantFetches int
+		wantErr     bool
+	}{
+		{
+			name:        "extension from URL",
+			path:        "/secure.notion-static.com/aaa/cat.gif",
+			want:        "aaa.gif",
+			wantContent: "image",
+			wantFetches: 1,
+		},
+		{
+			name:        "extension from Content-Type",
+			path:        "/secure.notion-static.com/aaa/cat?type=image/jpeg",
+			want:        "aaa.jpg",
+			wantContent: "image",
+			wantFetches: 1,
+		},
+		{
+			name:        "unknown extension",
+			path:        "/secure.notion-static.com/aaa/cat?type=application/x-unknown",
+			want:        "aaa.png",
+			wantContent: "image",
+			wantFetches: 1,
+		},
+		{
+			name:        "dots as UUID",
+			path:        "/x/..../y.png",
+			want:        "dfa3476846ce31ad.png",
+			wantContent: "image",
+			wantFetches: 1,
+		},
+		{
+			name:        "existing image is kept",
+			path:        "/secure.notion-static.com/aaa/cat.gif",
+			existing:    "aaa.gif",
+			want:        "aaa.gif",
+			wantContent: "existing",
+		},
+		{
+			name:        "existing image without extension is kept",
+			path:        "/secure.notion-static.com/aaa/cat?type=image/jpeg",
+			existing:    "aaa.webp",
+			want:        "aaa.webp",
+			wantContent: "existing",
+		},
+		{
+			name:        "existing image is overwritten",
+			path:        "/secure.notion-static.com/aaa/cat.gif",
+			opts:        ImageSaveOptions{OverwriteExisting: true},
+			existing:    "aaa.gif",
+			want:        "aaa.gif",
+			wantContent: "image",
+			wantFetches: 1,
+		},
+		{
+			name:        "not found",
+			path:        "/secure.notion-static.com/aaa/cat.gif?status=404",
+			wantFetches: 1,
+			wantErr:     true,
+		},
+		{
+			name:        "server error is retried",
+			path:        "/secure.notion-static.com/aaa/cat.gif?status=500",
+			opts:        ImageSaveOptions{MaxRetries: 1},
+			wantFetches: 2,
+			wantErr:     true,
+		},
+		{
+			name:        "unwritable filesystem",
+			path:        "/secure.notion-static.com/aaa/cat.gif",
+			opts:        ImageSaveOptions{FS: failingFS{}},
+			wantFetches: 1,
+			wantErr:     true,
+		},
+		{
+			name:    "URL without a UUID",
+			path:    "/cat.gif",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetches := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fetches++
+				if typ := r.URL.Query().Get("type"); typ != "" {
+					w.Header().Set("Content-Type", typ)
+				}
+				w.Header().Set("Retry-After", "0")
+				if status, err := strconv.Atoi(r.URL.Query().Get("status")); err == nil {
+					w.WriteHeader(status)
+				}
+				w.Write([]byte("image"))
+			}))
+			defer srv.Close()
+
+			opts := tt.opts
+			opts.SavePath = t.TempDir()
+			opts.HTTPClient = srv.Client()
+			// retries are only made when asked for.
+			if opts.MaxRetries == 0 {
+				opts.MaxRetries = -1
+			}
+			if tt.existing != "" {
+				if err := os.WriteFile(filepath.Join(opts.SavePath, tt.existing), []byte("existing"),
+					0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := SaveNotionImageToFilesystem(srv.URL+tt.path, opts)
+			if fetches != tt.wantFetches {
+				t.Errorf("fetched %d times, want %d", fetches, tt.wantFetches)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("SaveNotionImageToFilesystem() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SaveNotionImageToFilesystem() error: %s", err)
+			}
+			if want := filepath.Join(opts.SavePath, tt.want); got != want {
+				t.Errorf("SaveNotionImageToFilesystem() = %q, want %q", got, want)
+			}
+			content, err := os.ReadFile(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.wantContent {
+				t.Errorf("saved %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestSaveNotionImageExtensions(t *testing.T) {
+	tests := []struct {
+		name        string
+		file        string
+		contentType string
+		want        string
+	}{
+		{name: "jpg in URL", file: "photo.jpg", want: ".jpg"},
+		{name: "jpeg in URL", file: "photo.jpeg", want: ".jpeg"},
+		{name: "gif in URL", file: "anim.gif", want: ".gif"},
+		{name: "webp in URL", file: "pic.webp", want: ".webp"},
+		{name: "svg in URL", file: "logo.svg", want: ".svg"},
+		{name: "jpeg Content-Type", file: "photo", contentType: "image/jpeg", want: ".jpg"},
+		{name: "gif Content-Type", file: "anim", contentType: "image/gif", want: ".gif"},
+		{name: "webp Content-Type", file: "pic", contentType: "image/webp", want: ".webp"},
+		{name: "svg Content-Type", file: "logo", contentType: "image/svg+xml; charset=utf-8", want: ".svg"},
+		{name: "no Content-Type", file: "photo", want: ".png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				// without a Content-Type, one would be sniffed from the
+				// content.
+				w.Header()["Content-Type"] = nil
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+				w.Write([]byte("image"))
+			}))
+			defer srv.Close()
+
+			savePath := t.TempDir()
+			got, err := SaveNotionImageToFilesystem(srv.URL+"/secure.notion-static.com/aaa/"+tt.file,
+				ImageSaveOptions{SavePath: savePath, HTTPClient: srv.Client()})
+			if err != nil {
+				t.Fatalf("SaveNotionImageToFilesystem() error: %s", err)
+			}
+			if want := filepath.Join(savePath, "aaa"+tt.want); got != want {
+				t.Errorf("SaveNotionImageToFilesystem() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSaveNotionFileSanitizesNames(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "plain name",
+			path: "/secure.notion-static.com/aaa/report.pdf",
+			want: filepath.Join("aaa", "report.pdf"),
+		},
+		{
+			name: "escaped separators",
+			path: "/secure.notion-static.com/aaa/..%2F..%2Fetc%2Fpasswd",
+			want: filepath.Join("aaa", "--etc-passwd"),
+		},
+		{
+			name: "parent directory as UUID",
+			path: "/secure.notion-static.com/../report.pdf",
+			want: filepath.Join("5ec1f7e700f37c3d", "report.pdf"),
+		},
+		{
+			name: "backslashes",
+			path: "/secure.notion-static.com/aaa/..%5C..%5Creport.pdf",
+			want: filepath.Join("aaa", "--report.pdf"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("file"))
+			}))
+			defer srv.Close()
+
+			savePath := t.TempDir()
+			got, err := SaveNotionFileToFilesystem(srv.URL+tt.path,
+				ImageSaveOptions{SavePath: savePath, HTTPClient: srv.Client()})
+			if err != nil {
+				t.Fatalf("SaveNotionFileToFilesystem() error: %s", err)
+			}
+			if want := filepath.Join(savePath, tt.want); got != want {
+				t.Errorf("SaveNotionFileToFilesystem() = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestResolveLinkPath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(wd, "out")
+
+	tests := []struct {
+		name     string
+		filePath string
+		opts     ImageSaveOptions
+		want     string
+	}{
+		{
+			name:     "relative save path",
+			filePath: filepath.Join("images", "aaa.png"),
+			want:     "images/aaa.png",
+		},
+		{
+			name:     "absolute save path",
+			filePath: filepath.Join(wd, "images", "aaa.png"),
+			want:     "images/aaa.png",
+		},
+		{
+			name:     "absolute save path linked from the output file",
+			filePath: filepath.Join(out, "images", "aaa.png"),
+			opts:     ImageSaveOptions{LinkRelativeTo: out},
+			want:     "images/aaa.png",
+		},
+		{
+			name:     "relative save path linked from a sibling directory",
+			filePath: filepath.Join("images", "aaa.png"),
+			opts:     ImageSaveOptions{LinkRelativeTo: "pages"},
+			want:     "../images/aaa.png",
+		},
+		{
+			name:     "absolute save path linked from a relative directory",
+			filePath: filepath.Join(wd, "images", "aaa.png"),
+			opts:     ImageSaveOptions{LinkRelativeTo: "pages"},
+			want:     "../images/aaa.png",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveLinkPath(tt.filePath, tt.opts); got != tt.want {
+				t.Errorf("resolveLinkPath() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveRetryDelay(t *testing.T) {
+	tests := []struct {
+		name       string
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{
+			name:    "backoff",
+			attempt: 2,
+			want:    4 * retryBaseDelay,
+		},
+		{
+			name:       "retry after seconds",
+			retryAfter: "5",
+			want:       5 * time.Second,
+		},
+		{
+			name:       "retry after date in the past",
+			retryAfter: "Mon, 02 Jan 2006 15:04:05 GMT",
+			want:       0,
+		},
+		{
+			name:       "retry after is capped",
+			retryAfter: "86400",
+			want:       maxRetryDelay,
+		},
+		{
+			name:    "backoff is capped",
+			attempt: 40,
+			want:    maxRetryDelay,
+		},
+		{
+			name:    "overflowing backoff is capped",
+			attempt: 100,
+			want:    maxRetryDelay,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveRetryDelay(tt.retryAfter, tt.attempt); got != tt.want {
+				t.Errorf("resolveRetryDelay() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSaveNotionFileCancelledWhileWaiting(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := SaveNotionFileToFilesystem(srv.URL+"/secure.notion-static.com/aaa/report.pdf",
+		ImageSaveOptions{SavePath: t.TempDir(), HTTPClient: srv.Client(), ctx: ctx})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SaveNotionFileToFilesystem() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SaveNotionFileToFilesystem() returned after %s, want it to stop waiting once cancelled",
+			elapsed)
+	}
+}
+
+func TestImageNamesArePerExport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("image"))
+	}))
+	defer srv.Close()
+	hosted := func(id, uuid string) *na.ImageBlock {
+		return &na.ImageBlock{BasicBlock: basicBlock(id, na.BlockTypeImage, false),
+			Image: na.Image{Type: na.FileTypeFile, Caption: []na.RichText{text("Cat")},
+				File: &na.FileObject{URL: srv.URL + "/secure.notion-static.com/" + uuid + "/cat.gif"}}}
+	}
+	notion := fakeNotion{}.
+		page("both", "Both", hosted("i1", "aaa"), hosted("i2", "bbb")).
+		page("second", "Second", hosted("i3", "bbb"))
+
+	e := newTestExporter(t, "markdown", notion)
+	opts := RenderOptions{ImageOpts: ImageSaveOptions{SavePath: t.TempDir(), HTTPClient: srv.Client(),
+		FilenameFromCaption: true}}
+	tests := []struct {
+		pageID string
+		want   []string
+	}{
+		{pageID: "both", want: []string{"cat.gif", "cat-2.gif"}},
+		// the name taken by the first image in the previous export is free.
+		{pageID: "second", want: []string{"cat.gif"}},
+	}
+	for _, tt := range tests {
+		out, err := e.Render(tt.pageID, opts)
+		if err != nil {
+			t.Fatalf("Render(%q) error: %s", tt.pageID, err)
+		}
+		for _, name := range tt.want {
+			if !strings.Contains(string(out), name+")") {
+				t.Errorf("Render(%q) = %q, want it to link to %s", tt.pageID, out, name)
+			}
+		}
+	}
+}
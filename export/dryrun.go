@@ -0,0 +1,106 @@
+package export
+
+// This file contains the logic used to report what an export would download
+// and write, so large exports can be audited before they're run.
+
+import (
+	"sync"
+)
+
+// DryRunReport summarizes an export made with RenderOptions.DryRun set,
+// recording what it would have downloaded and written.
+type DryRunReport struct {
+	// Blocks is the number of blocks rendered.
+	Blocks int
+	// Downloads are the paths images and files hosted in Notion would be
+	// saved to, in the order they were found. Files already saved, which
+	// wouldn't be downloaded again, are left out.
+	Downloads []string
+	// Pages maps the path each subpage and the Manifest would be written to
+	// to its size in bytes. The page passed to Render is left out.
+	Pages map[string]int
+}
+
+// dryRun records what an export made with RenderOptions.DryRun would do. A
+// nil dryRun records nothing.
+type dryRun struct {
+	mu     sync.Mutex
+	report DryRunReport
+}
+
+// blockDone counts a rendered block.
+func (d *dryRun) blockDone() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.report.Blocks++
+}
+
+// fileDownloaded records a file that would be downloaded to filePath. It's
+// safe to call from the goroutines downloading files concurrently.
+func (d *dryRun) fileDownloaded(filePath string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.report.Downloads = append(d.report.Downloads, filePath)
+}
+
+// pageWritten records a page of size bytes that would be written to filePath.
+func (d *dryRun) pageWritten(filePath string, size int) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.report.Pages == nil {
+		d.report.Pages = map[string]int{}
+	}
+	d.report.Pages[filePath] = size
+}
+
+// DryRunReport returns what the most recent Render or ExportDatabase call,
+// and any RenderAppend calls since, would have downloaded and written. It's
+// empty unless RenderOptions.DryRun was set.
+func (e *exporter) DryRunReport() DryRunReport {
+	if e.dryRun == nil {
+		return DryRunReport{}
+	}
+	e.dryRun.mu.Lock()
+	defer e.dryRun.mu.Unlock()
+
+	report := e.dryRun.report
+	report.Downloads = append([]string(nil), report.Downloads...)
+	report.Pages = make(map[string]int, len(e.dryRun.report.Pages))
+	for k, v := range e.dryRun.report.Pages {
+		report.Pages[k] = v
+	}
+	return report
+}
+
+// withDryRun returns config, when config.DryRun is set, set up to download
+// nothing and record the files it would download in the exporter's
+// DryRunReport. It must be called before withResult and withProgress.
+func (e *exporter) withDryRun(config RenderOptions) RenderOptions {
+	if !config.DryRun {
+		return config
+	}
+	if e.dryRun == nil {
+		e.dryRun = &dryRun{}
+	}
+	config.ImageOpts.DryRun = true
+	config.ImageOpts.downloaded = e.dryRun.fileDownloaded
+	return config
+}
+
+// byteCounter is an io.Writer that discards what's written to it, counting
+// the bytes.
+type byteCounter int
+
+func (c *byteCounter) Write(p []byte) (int, error) {
+	*c += byteCounter(len(p))
+	return len(p), nil
+}
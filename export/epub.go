@@ -0,0 +1,349 @@
+package export
+
+// This file contains the logic used to package a page, its subpages, and the
+// images within them as an EPUB, so a wiki can be read as an ebook.
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	epubMimetype = "application/epub+zip"
+	// epubContentDir is the directory, within the EPUB, holding the package
+	// document, pages, and images.
+	epubContentDir = "OEBPS"
+	// epubRootFile is the name of the file the root page is written to.
+	// Subpages are named after their title, the same as a recursive export.
+	epubRootFile = "index.xhtml"
+	epubNavFile  = "nav.xhtml"
+	epubXHTMLExt = ".xhtml"
+	// epubLanguage is the language declared for the book, as Notion doesn't
+	// record the language of a page.
+	epubLanguage = "en"
+
+	epubContainer = `<?xml version="1.0" encoding="UTF-8"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+<rootfiles>
+<rootfile full-path="` + epubContentDir + `/content.opf" media-type="application/oebps-package+xml"/>
+</rootfiles>
+</container>
+`
+	epubPackagePattern = `<?xml version="1.0" encoding="UTF-8"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0" unique-identifier="uid">
+<metadata xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:identifier id="uid">%s</dc:identifier>
+<dc:title>%s</dc:title>
+<dc:language>%s</dc:language>
+<meta property="dcterms:modified">%s</meta>
+</metadata>
+<manifest>
+%s</manifest>
+<spine>
+%s</spine>
+</package>
+`
+	epubManifestItemPattern = "<item id=\"%s\" href=\"%s\" media-type=\"%s\"%s/>\n"
+	epubSpineItemPattern    = "<itemref idref=\"%s\"/>\n"
+	epubDocumentPattern     = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE html>
+<html xmlns="http://www.w3.org/1999/xhtml" xmlns:epub="http://www.idpf.org/2007/ops" lang="%[1]s" xml:lang="%[1]s">
+<head>
+<meta charset="utf-8" />
+<title>%[2]s</title>
+</head>
+<body>
+%[3]s
+</body>
+</html>
+`
+	epubNavPattern = `<nav epub:type="toc" id="toc">
+<h1>Contents</h1>
+%s</nav>`
+	epubNavItemPattern = "<li><a href=\"%s\">%s</a>%s</li>\n"
+)
+
+var (
+	// xhtmlVoidElementPattern matches the elements rendered by HTMLRenderer
+	// that have no closing tag, which XHTML requires to be self-closing.
+	xhtmlVoidElementPattern = regexp.MustCompile(`<(br|hr|img|input|source)\b([^>]*?)\s*/?>`)
+	// xhtmlBooleanAttributePattern matches the attributes HTMLRenderer
+	// renders without a value, which XHTML requires, along with their value
+	// when they have one.
+	xhtmlBooleanAttributePattern = regexp.MustCompile(`\s(disabled|checked|controls|allowfullscreen)\b(="[^"]*")?`)
+	// xhtmlTagPattern matches an opening tag. Text rendered by HTMLRenderer
+	// is escaped, so any match is markup.
+	xhtmlTagPattern = regexp.MustCompile(`<[a-zA-Z][^>]*>`)
+)
+
+// epubRenderer renders pages for an EPUB. It renders the same as
+// HTMLRenderer, except subpages are linked to as .xhtml files, as EPUB
+// content documents are XHTML.
+type epubRenderer struct {
+	HTMLRenderer
+}
+
+// epubPage is a page packaged in an EPUB.
+type epubPage struct {
+	id       string
+	fileName string
+	title    string
+	parent   string
+}
+
+// ExportEPUB exports the page pageID, and every subpage beneath it, as an
+// EPUB 3 written to w. Pages are rendered with HTMLRenderer, regardless of the
+// exporter's Renderer, and converted to XHTML. The spine follows the page
+// hierarchy, starting with pageID, and a navigation document mirroring the
+// hierarchy is generated. Images and files hosted in Notion are downloaded and
+// packaged in the EPUB, while external ones are linked to. opts is applied the
+// same as Render, except RecursePages is always set, DryRun and EditedSince
+// are ignored, as every page must be packaged, and PagesDir and
+// ImageOpts.SavePath are replaced with a temporary directory, which is removed
+// once the EPUB is written. ImageOpts.KeepRemoteImageURLs is ignored too, as
+// images must be packaged, as are ImageOpts.FS, as images are packaged from
+// the local filesystem, and WriteIndex, which the navigation document takes
+// the place of.
+//
+// An error is returned if rendering any page fails, or if writing to w fails.
+func (e *exporter) ExportEPUB(w io.Writer, pageID string, opts ...RenderOptions) error {
+	ctx := context.Background()
+	dir, err := os.MkdirTemp("", "nexp-epub-")
+	if err != nil {
+		return fmt.Errorf("Failed creating directory for EPUB contents, error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := resolveRenderConfig(opts...)
+	config.RecursePages = true
+	config.DryRun = false
+	config.EditedSince = time.Time{}
+	config.PagesDir = dir
+	config.ImageOpts.SavePath = filepath.Join(dir, defaultImageSaveLocation)
+	config.ImageOpts.LinkRelativeTo = dir
+	config.ImageOpts.KeepRemoteImageURLs = false
+	config.ImageOpts.FS = nil
+	config.WriteIndex = false
+	config.pages = newPageExportState(pageID)
+
+	renderer := e.Renderer
+	e.Renderer = &epubRenderer{}
+	defer func() { e.Renderer = renderer }()
+
+	root := &bytes.Buffer{}
+	err = e.RenderToContext(ctx, root, pageID, config)
+	if err != nil {
+		return err
+	}
+
+	p, err := e.c.Page.Get(ctx, na.PageID(pageID))
+	if err != nil {
+		if clientErr := resolveClientError("page", pageID, err); clientErr != nil {
+			return clientErr
+		}
+		return fmt.Errorf("Failed getting Notion page (%s), "+
+			"error from client: %s", pageID, err)
+	}
+	pages := []epubPage{{id: pageID, fileName: epubRootFile, title: ResolveTitleInPage(p)}}
+	for _, found := range config.pages.found {
+		pages = append(pages, epubPage{id: found.id, fileName: found.fileName,
+			title: found.title, parent: found.parent})
+	}
+
+	return writeEPUB(w, dir, root.Bytes(), pages)
+}
+
+// writeEPUB writes an EPUB to w holding pages, in order, with the first page's
+// content being root and every other page's content read from its file in
+// dir. Every file in dir's image directory is also packaged.
+func writeEPUB(w io.Writer, dir string, root []byte, pages []epubPage) error {
+	z := zip.NewWriter(w)
+
+	// the mimetype must be the first file, and must not be compressed, so
+	// readers can identify the format.
+	mt, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mt, epubMimetype); err != nil {
+		return err
+	}
+	if err := writeZipFile(z, "META-INF/container.xml", []byte(epubContainer)); err != nil {
+		return err
+	}
+
+	var manifest, spine string
+	manifest += fmt.Sprintf(epubManifestItemPattern, "nav", epubNavFile, "application/xhtml+xml",
+		" properties=\"nav\"")
+	for i, p := range pages {
+		content := root
+		if i > 0 {
+			content, err = os.ReadFile(filepath.Join(dir, p.fileName))
+			if err != nil {
+				return fmt.Errorf("Failed reading rendered page (%s), error: %s", p.id, err)
+			}
+		}
+		doc := fmt.Sprintf(epubDocumentPattern, epubLanguage, html.EscapeString(p.title),
+			toXHTML(string(content)))
+		if err := writeZipFile(z, epubContentDir+"/"+p.fileName, []byte(doc)); err != nil {
+			return err
+		}
+		id := fmt.Sprintf("page-%d", i+1)
+		manifest += fmt.Sprintf(epubManifestItemPattern, id, escapeEPUBHref(p.fileName),
+			"application/xhtml+xml", "")
+		spine += fmt.Sprintf(epubSpineItemPattern, id)
+	}
+
+	nav := fmt.Sprintf(epubNavPattern, renderEPUBNav(pages))
+	navDoc := fmt.Sprintf(epubDocumentPattern, epubLanguage, html.EscapeString(pages[0].title), nav)
+	if err := writeZipFile(z, epubContentDir+"/"+epubNavFile, []byte(navDoc)); err != nil {
+		return err
+	}
+
+	resources := 0
+	err = filepath.WalkDir(filepath.Join(dir, defaultImageSaveLocation),
+		func(p string, d fs.DirEntry, err error) error {
+			// no images were downloaded.
+			if os.IsNotExist(err) {
+				return nil
+			}
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, p)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if err := writeZipFile(z, epubContentDir+"/"+rel, content); err != nil {
+				return err
+			}
+			resources++
+			manifest += fmt.Sprintf(epubManifestItemPattern, fmt.Sprintf("resource-%d", resources),
+				escapeEPUBHref(rel), resolveMediaType(rel), "")
+			return nil
+		})
+	if err != nil {
+		return fmt.Errorf("Failed packaging downloaded files, error: %s", err)
+	}
+
+	pkg := fmt.Sprintf(epubPackagePattern, html.EscapeString(resolveEPUBIdentifier(pages[0].id)),
+		html.EscapeString(pages[0].title), epubLanguage,
+		time.Now().UTC().Format("2006-01-02T15:04:05Z"), manifest, spine)
+	if err := writeZipFile(z, epubContentDir+"/content.opf", []byte(pkg)); err != nil {
+		return err
+	}
+
+	return z.Close()
+}
+
+// writeZipFile adds a compressed file named name, holding content, to z.
+func writeZipFile(z *zip.Writer, name string, content []byte) error {
+	f, err := z.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(content)
+	return err
+}
+
+// renderEPUBNav returns nested, ordered lists linking to pages, where the
+// first page is the root and every other page is listed beneath its parent.
+// Pages whose parent isn't known are listed beneath the root.
+func renderEPUBNav(pages []epubPage) string {
+	root := normalizePageID(pages[0].id)
+	known := map[string]bool{}
+	for _, p := range pages {
+		known[normalizePageID(p.id)] = true
+	}
+	children := map[string][]epubPage{}
+	for _, p := range pages[1:] {
+		parent := normalizePageID(p.parent)
+		if !known[parent] {
+			parent = root
+		}
+		children[parent] = append(children[parent], p)
+	}
+
+	var renderList func(ps []epubPage) string
+	renderList = func(ps []epubPage) string {
+		if len(ps) < 1 {
+			return ""
+		}
+		list := "<ol>\n"
+		for _, p := range ps {
+			list += fmt.Sprintf(epubNavItemPattern, html.EscapeString(escapeEPUBHref(p.fileName)),
+				html.EscapeString(p.title), renderList(children[normalizePageID(p.id)]))
+		}
+		return list + "</ol>\n"
+	}
+
+	return renderList(pages[:1])
+}
+
+// toXHTML returns s, rendered by HTMLRenderer, as XHTML: void elements (e.g.
+// <hr>) are self-closing and boolean attributes (e.g. disabled) are given a
+// value.
+func toXHTML(s string) string {
+	s = xhtmlTagPattern.ReplaceAllStringFunc(s, func(tag string) string {
+		return xhtmlBooleanAttributePattern.ReplaceAllStringFunc(tag, func(attr string) string {
+			if strings.Contains(attr, "=") {
+				return attr
+			}
+			name := strings.TrimSpace(attr)
+			return fmt.Sprintf(` %s="%s"`, name, name)
+		})
+	})
+	return xhtmlVoidElementPattern.ReplaceAllString(s, "<$1$2 />")
+}
+
+// escapeEPUBHref returns the path p, relative to the package document, escaped
+// for use as a URL, as files (e.g. "report final.pdf") may have spaces.
+func escapeEPUBHref(p string) string {
+	segments := strings.Split(p, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// resolveMediaType returns the media type of the file named name, based on its
+// extension. Unknown types are application/octet-stream.
+func resolveMediaType(name string) string {
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		mediaType, _, err := mime.ParseMediaType(t)
+		if err == nil {
+			return mediaType
+		}
+	}
+	return "application/octet-stream"
+}
+
+// resolveEPUBIdentifier returns the unique identifier of an EPUB whose root is
+// the page pageID, as a UUID URN (e.g. urn:uuid:de4d2477-f321-...).
+func resolveEPUBIdentifier(pageID string) string {
+	id := normalizePageID(pageID)
+	if len(id) != 32 {
+		return "urn:notion:" + id
+	}
+	return fmt.Sprintf("urn:uuid:%s-%s-%s-%s-%s", id[0:8], id[8:12], id[12:16], id[16:20], id[20:])
+}
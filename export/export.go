@@ -1,9 +1,19 @@
 package export
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	na "github.com/jomei/notionapi"
 	"github.com/joshrosso/nexp/config"
@@ -11,7 +21,34 @@ import (
 
 const (
 	notionApiEnvVar = "NOTION_TOKEN"
-	defaultFormat   = "markdown"
+	// invalidTokenMessage explains a 401 from the Notion API, which is
+	// returned when the token is mistyped or has been revoked.
+	invalidTokenMessage = "Notion rejected the token as invalid. Check it was copied in " +
+		"full from the integration's settings at https://www.notion.so/my-integrations"
+	defaultFormat = "markdown"
+	// unsupportedBlockType is passed to AddSectionSeperation, in place of
+	// the block's type, when a placeholder is rendered for an unsupported
+	// block.
+	unsupportedBlockType = "unsupported"
+	// unknownBlockType is the type an unsupported block is recorded and
+	// marked with when its type is unknown, as the client decodes blocks of
+	// types it doesn't know without their type.
+	unknownBlockType = "unknown"
+	// blockIDComment and syncedBlockComment are the text of the comments
+	// added by RenderOptions.EmbedBlockIDs and
+	// RenderOptions.AnnotateSyncedBlocks.
+	blockIDComment     = "block: %s"
+	syncedBlockComment = "synced from %s"
+	// truncatedBlocksMarker is the text of the paragraph added by
+	// RenderOptions.MarkTruncatedBlocks.
+	truncatedBlocksMarker = "..."
+)
+
+var (
+	// registeredRenderers are the renderers added with RegisterRenderer,
+	// keyed by the name NewRenderer selects them with.
+	registeredRenderers   = map[string]func() Renderer{}
+	registeredRenderersMu sync.RWMutex
 )
 
 // Render retrieves a Notion Page, renders its Blocks, and returns a []byte
@@ -29,151 +66,839 @@ const (
 // If there are client issue retrieving the Page, Blocks, or other elements,
 // and error is returned.
 func (e *exporter) Render(pageID string, opts ...RenderOptions) ([]byte, error) {
+	return e.RenderContext(context.Background(), pageID, opts...)
+}
 
-	config := resolveRenderConfig(opts...)
+// RenderContext is the same as Render, except ctx is passed to every call made
+// to the Notion API, enabling an export to be cancelled or given a deadline.
+// See the Render API docs for details on arguments and behavior.
+func (e *exporter) RenderContext(ctx context.Context, pageID string,
+	opts ...RenderOptions) ([]byte, error) {
+
+	buf := &bytes.Buffer{}
+	err := e.RenderToContext(ctx, buf, pageID, opts...)
+	e.page = buf.Bytes()
+
+	return e.page, err
+}
+
+// RenderTo is the same as Render, except each Block's rendered bytes are
+// written to w as they're produced, rather than buffered and returned once the
+// entire page is rendered. This is preferred for very large pages. See the
+// Render API docs for details on arguments and behavior.
+//
+// An error is also returned if writing to w fails. In this case, or when
+// rendering fails part way through a page, w will contain a partial page.
+func (e *exporter) RenderTo(w io.Writer, pageID string, opts ...RenderOptions) error {
+	return e.RenderToContext(context.Background(), w, pageID, opts...)
+}
+
+// RenderToContext is the same as RenderTo, except ctx is passed to every call
+// made to the Notion API. See the Render and RenderTo API docs for details on
+// arguments and behavior.
+//
+// When RenderOptions.RecursePages is set, every subpage is written to its own
+// file in RenderOptions.PagesDir once the page has been written to w.
+func (e *exporter) RenderToContext(ctx context.Context, w io.Writer, pageID string,
+	opts ...RenderOptions) error {
+
+	e.dryRun = nil
+	e.downloads = nil
+	config := e.withDownloads(e.withProgress(e.withResult(e.withDryRun(resolveRenderConfig(opts...)))))
+	e.unsupported = nil
+	e.skipped = nil
+	e.linkTitles = nil
+	e.root, e.rootTitle = pageID, ""
+	e.progress.reset()
+	if config.RecursePages {
+		// the state may be set up by the caller, such as ExportEPUB, which
+		// reads the pages found once the export completes.
+		if config.pages == nil {
+			config.pages = newPageExportState(pageID)
+		}
+		if err := restorePageExportState(config); err != nil {
+			return err
+		}
+	}
+	edited, err := e.pageEditedSince(ctx, pageID, config)
+	if err != nil {
+		return err
+	}
+	if config.RecursePages {
+		// every page in the export must be known before rendering, so links
+		// to pages that come later can be rewritten to their files.
+		err := e.discoverPages(ctx, pageID, pageID, config)
+		if err != nil {
+			return err
+		}
+	}
 
-	e.page = []byte{}
+	if edited {
+		err := e.renderPage(ctx, w, pageID, config)
+		if err != nil {
+			return err
+		}
+	}
 
-	p, err := e.c.Page.Get(context.Background(), na.PageID(pageID))
+	if config.RecursePages {
+		return e.renderChildPages(ctx, config)
+	}
+	return nil
+}
+
+// renderPage renders the header, blocks, and footer of the page pageID,
+// writing them to w.
+func (e *exporter) renderPage(ctx context.Context, w io.Writer, pageID string,
+	config RenderOptions) error {
+
+	e.w = w
+	e.pageWords = 0
+	// anchors are unique within a page, so each page starts with none
+	// claimed.
+	config.headingSlugs = headingSlugs{}
+
+	p, err := e.c.Page.Get(ctx, na.PageID(pageID))
 	if err != nil {
-		return e.page, fmt.Errorf("Failed getting Notion page (%s), "+
+		if clientErr := resolveClientError("page", pageID, err); clientErr != nil {
+			return clientErr
+		}
+		return fmt.Errorf("Failed getting Notion page (%s), "+
 			"error from client: %s", pageID, err)
 	}
-	e.page = append(e.page, e.Renderer.RenderPageHeader(p, config.Overrides.PageHeader)...)
+	e.progress.logf("Fetched page %s (%s)", pageID, ResolveTitleInPage(p))
+	e.result.pageFetched(pageID, p)
+	config.pages.pageRetrieved(pageID, p)
+	e.rootRetrieved(pageID, p)
+	// the names are only retrieved here when they're recorded, as the
+	// frontmatter retrieves them itself.
+	if config.ResolveUserNames && e.result != nil {
+		createdBy, lastEditedBy := e.resolvePageUserNames(ctx, p)
+		e.result.usersResolved(pageID, createdBy, lastEditedBy)
+	}
+
+	// the top of the page is composed of the frontmatter, cover, and header,
+	// in that order, with any that are empty left out.
+	var top []string
+	headerOverride := config.Overrides.PageHeader
+	// the reading time is only known once the page's words are counted, so
+	// when the frontmatter includes it, the frontmatter is rendered last and
+	// the rest of the page is held until then.
+	deferFrontmatter := config.Frontmatter && config.ReadingTime
+	// a StructuredRenderer records the frontmatter in its document, once the
+	// page's words are counted, rather than as a block before it.
+	structured, isStructured := e.Renderer.(StructuredRenderer)
+	if isStructured {
+		deferFrontmatter = false
+	}
+	fmPage := p
+	if config.Frontmatter {
+		if !deferFrontmatter && !isStructured {
+			fm, err := e.renderPageFrontmatter(ctx, p, config)
+			if err != nil {
+				return err
+			}
+			top = append(top, fm)
+		}
+		// the title is already in the frontmatter. The header is still
+		// rendered, as renderers may rely on it to start a new page.
+		if headerOverride == nil {
+			headerOverride = func(*na.Page) string { return "" }
+		}
+	}
+	// the header is still rendered when omitted, as renderers may rely on it
+	// to start a new page, but its output is discarded.
+	if config.OmitPageHeader {
+		headerOverride = func(*na.Page) string { return "" }
+	}
+	if config.IncludeIcon {
+		p = withIconInTitle(p)
+	}
+	// the header must be rendered before the cover, as renderers reset their
+	// state for the new page when rendering the header.
+	header := e.Renderer.RenderPageHeader(p, headerOverride)
+	if config.IncludeCover && p.Cover != nil && !config.ImageOpts.IgnoreImages {
+		config.ImageOpts.page = p
+		cover, err := e.Renderer.RenderImage(&Block{
+			BlockRef: &na.ImageBlock{
+				BasicBlock: na.BasicBlock{Object: na.ObjectTypeBlock, Type: na.BlockTypeImage},
+				Image:      *p.Cover,
+			},
+			Opts:    []RenderOptions{config},
+			PageRef: p,
+		}, config.Overrides.Image)
+		if err != nil {
+			return fmt.Errorf("Failed rendering cover of Notion page, error: %s", err)
+		}
+		top = append(top, cover)
+	}
+	top = append(top, header)
 
-	e.page, err = e.renderFullPage(pageID, "", opts...)
+	var nonEmpty []string
+	for _, s := range top {
+		if s != "" {
+			nonEmpty = append(nonEmpty, s)
+		}
+	}
+	sep := e.Renderer.AddSectionSeperation("image", "heading_1")
+	var body bytes.Buffer
+	if deferFrontmatter {
+		e.w = &body
+	}
+	err = e.write(strings.Join(nonEmpty, sep))
 	if err != nil {
-		return e.page, fmt.Errorf("Failed rendering Notion page, error: %s",
+		return err
+	}
+
+	_, err = e.renderFullPage(ctx, pageID, "", config)
+	if err != nil {
+		return fmt.Errorf("Failed rendering Notion page, error: %s",
 			err)
 	}
 
+	if config.Frontmatter && isStructured {
+		structured.SetFrontmatter(e.pageFrontmatterValues(ctx, fmPage, config))
+	}
+
 	// add footer
-	e.page = append(e.page, e.Renderer.RenderPageFooter(p, config.Overrides.PageFooter)...)
+	err = e.write(e.Renderer.RenderPageFooter(p, e.resolveFooterOverride(p, config)))
+	if err != nil || !deferFrontmatter {
+		return err
+	}
+
+	e.w = w
+	fm, err := e.renderPageFrontmatter(ctx, fmPage, config)
+	if err != nil {
+		return err
+	}
+	if len(nonEmpty) > 0 {
+		fm += sep
+	}
+	return e.write(fm + body.String())
+}
+
+// renderPageFrontmatter returns the frontmatter of page, as added by
+// RenderOptions.Frontmatter, serialized in config.FrontmatterFormat. See
+// pageFrontmatterValues.
+func (e *exporter) renderPageFrontmatter(ctx context.Context, page *na.Page,
+	config RenderOptions) (string, error) {
+
+	return encodeFrontmatter(e.pageFrontmatterValues(ctx, page, config), config.FrontmatterFormat)
+}
+
+// pageFrontmatterValues returns the values in the frontmatter of page. With
+// RenderOptions.ReadingTime, they include the reading time of the words
+// counted for the page so far. With RenderOptions.ResolveUserNames, they
+// include the names of the users who created and last edited the page.
+func (e *exporter) pageFrontmatterValues(ctx context.Context, page *na.Page,
+	config RenderOptions) map[string]interface{} {
+
+	values := frontmatterValues(page, config.DateFormat)
+	if _, ok := values[readingTimeKey]; config.ReadingTime && !ok {
+		values[readingTimeKey] = resolveReadingTime(e.pageWords, config.WordsPerMinute)
+	}
+	if config.ResolveUserNames {
+		createdBy, lastEditedBy := e.resolvePageUserNames(ctx, page)
+		if _, ok := values[createdByKey]; !ok && createdBy != "" {
+			values[createdByKey] = createdBy
+		}
+		if _, ok := values[lastEditedByKey]; !ok && lastEditedBy != "" {
+			values[lastEditedByKey] = lastEditedBy
+		}
+	}
+	return values
+}
+
+// renderChildPages exports every page queued while rendering, each to its own
+// file in config.PagesDir. Subpages found along the way are queued and
+// exported in turn, until no pages remain. The Manifest of the export is then
+// written alongside them.
+func (e *exporter) renderChildPages(ctx context.Context, config RenderOptions) error {
+	dir := resolvePagesDir(config)
+	if config.DryRun {
+		return e.countChildPages(ctx, dir, config)
+	}
+	imageOpts := ResolveImageSaveOptions(config.ImageOpts)
+	if err := createPathIfNonExistent(osFS{}, dir, imageOpts.DirMode); err != nil {
+		return fmt.Errorf("Failed creating directory for subpages, error: %s", err)
+	}
+
+	for next, ok := config.pages.next(); ok; next, ok = config.pages.next() {
+		edited, err := e.pageEditedSince(ctx, next.id, config)
+		if err != nil {
+			return err
+		}
+		if !edited {
+			continue
+		}
+		f, err := os.OpenFile(filepath.Join(dir, next.fileName),
+			os.O_RDWR|os.O_CREATE|os.O_TRUNC, imageOpts.FileMode)
+		if err != nil {
+			return fmt.Errorf("Failed creating file for subpage (%s), "+
+				"error: %s", next.id, err)
+		}
+		err = e.renderPage(ctx, f, next.id, config)
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest, err := json.MarshalIndent(config.pages.manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	// the manifest is replaced in one step, so an export that's interrupted
+	// doesn't leave a partial manifest for SkipUnchangedPages to read.
+	err = writeFileAtomic(filepath.Join(dir, manifestFileName), manifest, imageOpts.FileMode)
+	if err != nil {
+		return fmt.Errorf("Failed writing manifest of exported pages, error: %s", err)
+	}
+
+	if !config.WriteIndex {
+		return nil
+	}
+	var index bytes.Buffer
+	err = e.RenderIndex(&index, config.pages.rootTitle, config.pages.manifest(), config)
+	if err != nil {
+		return fmt.Errorf("Failed rendering index of exported pages, error: %s", err)
+	}
+	err = writeFileAtomic(filepath.Join(dir, indexFileName+ResolvePageFileExtension(e.Renderer)),
+		index.Bytes(), imageOpts.FileMode)
+	if err != nil {
+		return fmt.Errorf("Failed writing index of exported pages, error: %s", err)
+	}
+
+	return nil
+}
+
+// countChildPages renders every page queued while rendering, the same as
+// renderChildPages, for a dry run. Nothing is written; instead, the size of
+// each page is recorded against the file it would be written to in dir.
+func (e *exporter) countChildPages(ctx context.Context, dir string, config RenderOptions) error {
+	for next, ok := config.pages.next(); ok; next, ok = config.pages.next() {
+		edited, err := e.pageEditedSince(ctx, next.id, config)
+		if err != nil {
+			return err
+		}
+		if !edited {
+			continue
+		}
+		var size byteCounter
+		err = e.renderPage(ctx, &size, next.id, config)
+		if err != nil {
+			return err
+		}
+		e.dryRun.pageWritten(filepath.Join(dir, next.fileName), int(size))
+	}
+
+	manifest, err := json.MarshalIndent(config.pages.manifest(), "", "  ")
+	if err != nil {
+		return err
+	}
+	e.dryRun.pageWritten(filepath.Join(dir, manifestFileName), len(manifest))
+
+	if config.WriteIndex {
+		var size byteCounter
+		err = e.RenderIndex(&size, config.pages.rootTitle, config.pages.manifest(), config)
+		if err != nil {
+			return err
+		}
+		e.dryRun.pageWritten(filepath.Join(dir, indexFileName+ResolvePageFileExtension(e.Renderer)),
+			int(size))
+	}
+
+	return nil
+}
+
+// resolvePagesDir returns the directory the pages of a recursive export are
+// written to, which is config.PagesDir or, when not set, the current
+// directory.
+func resolvePagesDir(config RenderOptions) string {
+	if config.PagesDir == "" {
+		return "."
+	}
+	return config.PagesDir
+}
+
+// restorePageExportState restores config.pages from the Manifest of the
+// earlier export in config.PagesDir, when config.SkipUnchangedPages is set.
+func restorePageExportState(config RenderOptions) error {
+	if !config.SkipUnchangedPages {
+		return nil
+	}
+	m, err := ReadManifest(resolvePagesDir(config))
+	if err != nil {
+		return err
+	}
+	config.pages.restore(m)
+	return nil
+}
+
+// pageEditedSince reports whether the page pageID was edited after
+// config.EditedSince and, with config.SkipUnchangedPages, after the edit
+// recorded by the earlier export, recording it in the exporter's
+// SkippedPages when it wasn't. When neither is known, the page is reported as
+// edited.
+func (e *exporter) pageEditedSince(ctx context.Context, pageID string,
+	config RenderOptions) (bool, error) {
+
+	since := config.EditedSince
+	if previous, ok := config.pages.previousEdit(pageID); config.SkipUnchangedPages && ok &&
+		previous.After(since) {
+		since = previous
+	}
+	if since.IsZero() {
+		return true, nil
+	}
+	p, err := e.c.Page.Get(ctx, na.PageID(pageID))
+	if err != nil {
+		if clientErr := resolveClientError("page", pageID, err); clientErr != nil {
+			return false, clientErr
+		}
+		return false, fmt.Errorf("Failed getting Notion page (%s), "+
+			"error from client: %s", pageID, err)
+	}
+	config.pages.pageRetrieved(pageID, p)
+	e.rootRetrieved(pageID, p)
+	if p.LastEditedTime.After(since) {
+		return true, nil
+	}
+	e.skipped = append(e.skipped, pageID)
+	e.progress.logf("Skipped page %s (%s), not edited since %s", pageID,
+		ResolveTitleInPage(p), since.Format(time.RFC3339))
+	return false, nil
+}
+
+// SkippedPages returns the IDs of the pages left out of the most recent
+// Render or ExportDatabase call as they weren't edited since
+// RenderOptions.EditedSince or, with RenderOptions.SkipUnchangedPages, the
+// earlier export.
+func (e *exporter) SkippedPages() []string {
+	return append([]string(nil), e.skipped...)
+}
+
+// ExportedTitle returns the title of the page most recently exported with
+// RenderTo, including when it was skipped as it wasn't edited since
+// RenderOptions.EditedSince. An empty string is returned when the page wasn't
+// retrieved.
+func (e *exporter) ExportedTitle() string {
+	return e.rootTitle
+}
 
-	return e.page, nil
+// rootRetrieved records the title of p when pageID is the page being exported.
+func (e *exporter) rootRetrieved(pageID string, p *na.Page) {
+	if pageID == e.root {
+		e.rootTitle = ResolveTitleInPage(p)
+	}
+}
+
+// discoverPages adds every subpage found in the blocks of blockID, and in the
+// blocks nested under them, to the export in config.pages. blockID is, or is
+// nested in, the page pageID. The blocks retrieved are cached so they aren't
+// retrieved again when rendered.
+func (e *exporter) discoverPages(ctx context.Context, blockID string, pageID string,
+	config RenderOptions) error {
+
+	cursor := ""
+	for {
+		blocks, err := e.c.Block.GetChildren(ctx, na.BlockID(blockID),
+			&na.Pagination{StartCursor: na.Cursor(cursor)})
+		if err != nil {
+			kind := "block"
+			if blockID == pageID {
+				kind = "page"
+			}
+			if clientErr := resolveClientError(kind, blockID, err); clientErr != nil {
+				return clientErr
+			}
+			return fmt.Errorf("failed to retrieve data from Notion. "+
+				"Error: %s.", err)
+		}
+		e.audio.resolveBlocks(blocks)
+		config.pages.cacheChildren(blockID, cursor, blocks)
+		e.progress.logf("Fetched %d blocks of %s", len(blocks.Results), blockID)
+
+		for _, b := range blocks.Results {
+			// the blocks of a child page are the content of the subpage.
+			owner := pageID
+			if in, ok := b.(*na.ChildPageBlock); ok {
+				config.pages.enqueue(string(in.ID), in.ChildPage.Title, pageID,
+					ResolvePageFileExtension(e.Renderer))
+				owner = string(in.ID)
+			}
+			if b.GetHasChildren() {
+				err := e.discoverPages(ctx, string(b.GetID()), owner, config)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if !blocks.HasMore {
+			return nil
+		}
+		cursor = string(blocks.NextCursor)
+	}
+}
+
+// renderText renders rt using the Renderer. During a recursive export, links
+// to pages in the export are first rewritten to point at their files.
+func (e *exporter) renderText(ctx context.Context, rt []na.RichText, config RenderOptions) string {
+	// titles are resolved before links are rewritten to files, as they're
+	// looked up by the page linked to in Notion.
+	if config.ResolveInternalLinkTitles {
+		rt = e.resolveInternalLinkTitles(ctx, rt)
+	}
+	if config.pages != nil {
+		rt = config.pages.rewriteLinks(rt)
+	}
+	if config.AutolinkBareURLs {
+		rt = autolinkBareURLs(rt)
+	}
+	if !config.uncounted {
+		words := countWords(rt)
+		e.pageWords += words
+		e.result.wordsRendered(words)
+	}
+	return e.Renderer.RenderText(rt)
+}
+
+// UnsupportedBlocks returns the number of blocks of each type that were left
+// out of the export as they aren't supported, keyed by block type (e.g.
+// breadcrumb). It covers the most recent Render and any RenderAppend calls
+// since.
+func (e *exporter) UnsupportedBlocks() map[string]int {
+	counts := make(map[string]int, len(e.unsupported))
+	for k, v := range e.unsupported {
+		counts[k] = v
+	}
+	return counts
+}
+
+// recordUnsupported counts a block of blockType that couldn't be rendered.
+func (e *exporter) recordUnsupported(blockType string) {
+	if e.unsupported == nil {
+		e.unsupported = map[string]int{}
+	}
+	e.unsupported[blockType]++
+}
+
+// withDownloads passes the state of the images and files downloaded during
+// the current export to config.ImageOpts, starting it when there is none.
+func (e *exporter) withDownloads(config RenderOptions) RenderOptions {
+	if e.downloads == nil {
+		e.downloads = newDownloads()
+	}
+	config.ImageOpts.downloads = e.downloads
+	return config
 }
 
 // RenderAppend is the same as Render, except it appends to any existing page
-// the exporter has already rendered. See the Render API docs for details on
-// arguments and behavior.
+// the exporter has already rendered, enabling several pages to be combined
+// into one document. Like Render, the appended page starts with its header,
+// unless RenderOptions.OmitPageHeader is set, and ends with its footer.
+// Subpages are not exported, even when RenderOptions.RecursePages is set. See
+// the Render API docs for details on arguments and behavior.
 func (e *exporter) RenderAppend(pageID string, opts ...RenderOptions) ([]byte, error) {
+	buf := bytes.NewBuffer(e.page)
+	e.w = buf
 
 	// before appending, add separation
-	e.page = append(e.page, "\n\n"...)
-	return e.renderFullPage(pageID, "", opts...)
+	err := e.write("\n\n")
+	if err == nil {
+		err = e.renderPage(context.Background(), buf, pageID,
+			e.withDownloads(e.withProgress(e.withDryRun(resolveRenderConfig(opts...)))))
+	}
+	e.page = buf.Bytes()
+
+	return e.page, err
 }
 
 // NewRenderer returns a renderer based on the kind (export format) provided.
-// An error is returned when no renderer for the kind is known.
+// Renderers added with RegisterRenderer are consulted first, falling back to
+// the built-in formats. An error is returned when no renderer for the kind is
+// known.
 func NewRenderer(kind string) (Renderer, error) {
+	registeredRenderersMu.RLock()
+	factory, ok := registeredRenderers[kind]
+	registeredRenderersMu.RUnlock()
+	if ok {
+		return factory(), nil
+	}
+
+	if r := newBuiltinRenderer(kind); r != nil {
+		return r, nil
+	}
+
+	return nil, fmt.Errorf("No renderer support for type %s", kind)
+}
+
+// RegisterRenderer makes a Renderer created by factory available to
+// NewRenderer, and so to the CLI's --format flag, as name. It's typically
+// called from an init function of a binary embedding nexp. It's safe to call
+// concurrently. It panics when name is empty, factory is nil, or name is
+// already used by a built-in or registered renderer.
+func RegisterRenderer(name string, factory func() Renderer) {
+	if name == "" {
+		panic("nexp: RegisterRenderer name is empty")
+	}
+	if factory == nil {
+		panic("nexp: RegisterRenderer factory is nil for " + name)
+	}
+	if newBuiltinRenderer(name) != nil {
+		panic("nexp: RegisterRenderer called for built-in renderer " + name)
+	}
+
+	registeredRenderersMu.Lock()
+	defer registeredRenderersMu.Unlock()
+	if _, ok := registeredRenderers[name]; ok {
+		panic("nexp: RegisterRenderer called twice for renderer " + name)
+	}
+	registeredRenderers[name] = factory
+}
+
+// newBuiltinRenderer returns the built-in renderer for kind, or nil when kind
+// isn't a built-in format.
+func newBuiltinRenderer(kind string) Renderer {
 	switch kind {
 	case "markdown":
-		return &MDRenderer{}, nil
+		return &MDRenderer{}
 	case "md":
-		return &MDRenderer{}, nil
+		return &MDRenderer{}
+	case "html":
+		return &HTMLRenderer{}
+	case "confluence":
+		return &ConfluenceRenderer{}
+	case "json":
+		return &JSONRenderer{}
+	case "asciidoc":
+		return &AsciiDocRenderer{}
+	case "adoc":
+		return &AsciiDocRenderer{}
+	case "org":
+		return &OrgRenderer{}
+	case "latex":
+		return &LaTeXRenderer{}
+	case "tex":
+		return &LaTeXRenderer{}
+	case "text":
+		return &TextRenderer{}
+	case "txt":
+		return &TextRenderer{}
 	}
 
-	return nil, fmt.Errorf("No renderer support for type %s", kind)
+	return nil
 }
 
 // NewExporter returns an exporter instance with an underlying Notion API
 // client attached. The exporter instance is used to call Render functionality.
 func NewExporter(opts ...ExporterOptions) (*exporter, error) {
-	// set up default render. Will be overwritten if provided in options.
-	r, err := NewRenderer(defaultFormat)
+	r, err := resolveExporterRenderer(opts...)
+	if err != nil {
+		return nil, err
+	}
 	var token string
+	var profile string
 	var notionClientOpts na.ClientOption
+	var client *na.Client
+	prog := &progress{}
 
 	// TODO(joshrosso): Clean this up into a dedicated options resolver func
 	if len(opts) > 0 {
+		client = opts[0].Client
+		prog.log = opts[0].Logger
+		prog.report = opts[0].Progress
 		if opts[0].NotionToken != "" {
 			token = opts[0].NotionToken
 		}
+		profile = opts[0].Profile
 		if opts[0].ClientOpts != nil {
 			notionClientOpts = opts[0].ClientOpts
 		}
-		if opts[0].Renderer != nil {
-			r = opts[0].Renderer
-		} else {
-			format := opts[0].Format
-			if format == "" {
-				format = defaultFormat
-			}
-			r, err = NewRenderer(opts[0].Format)
-			if err != nil {
-				return nil, err
-			}
-		}
+	}
+
+	// a pre-built client was provided, so there is no need to construct one
+	if client != nil {
+		return &exporter{c: client, Renderer: r, progress: prog}, nil
 	}
 
 	// when no token is passed, attempt to resolve via env var or ${HOME}/.config/nexp.yaml
 	if token == "" {
-		token, err = resolveNotionToken()
+		token, err = resolveNotionToken(profile)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	// requests that are rate limited are retried, and audio blocks, which
+	// the client can't decode, are decoded. A client set through ClientOpts
+	// replaces this one.
+	var maxAPIRetries int
+	if len(opts) > 0 {
+		maxAPIRetries = opts[0].MaxAPIRetries
+	}
+	audio := newAudioTransport(newRetryTransport(http.DefaultTransport, maxAPIRetries))
+	retryOpt := na.WithHTTPClient(&http.Client{Transport: audio})
+
 	if notionClientOpts == nil {
-		return &exporter{c: na.NewClient(na.Token(token)), Renderer: r}, nil
+		return &exporter{c: na.NewClient(na.Token(token), retryOpt), Renderer: r, progress: prog,
+			audio: audio}, nil
 	}
 
-	return &exporter{c: na.NewClient(na.Token(token), notionClientOpts), Renderer: r}, nil
+	return &exporter{c: na.NewClient(na.Token(token), retryOpt, notionClientOpts), Renderer: r,
+		progress: prog, audio: audio}, nil
+}
+
+// resolveExporterRenderer returns the renderer set in opts. When none is set,
+// the renderer for its Format is created, defaulting to markdown when Format
+// is empty as well. Format isn't parsed when a renderer is set.
+func resolveExporterRenderer(opts ...ExporterOptions) (Renderer, error) {
+	if len(opts) > 0 && opts[0].Renderer != nil {
+		return opts[0].Renderer, nil
+	}
+	format := defaultFormat
+	if len(opts) > 0 && opts[0].Format != "" {
+		format = opts[0].Format
+	}
+	return NewRenderer(format)
 }
 
 // ResolveTitleInPage takes a Notion page object and loops through its
 // properties to find the property which is a title Type. It then returns the
-// plain text representation of that property.
+// plain text representation of that property. An empty string is returned
+// when the page has no title property or its title is empty.
 func ResolveTitleInPage(p *na.Page) string {
+	if p == nil {
+		return ""
+	}
 	// loops through properties attached to the page to find the property of
 	// type title (there can only be one). This is then used as the title for
 	// the document.
 	var title *na.TitleProperty
 	for _, v := range p.Properties {
-		if v.GetType() == "title" {
-			title = v.(*na.TitleProperty)
+		if t, ok := v.(*na.TitleProperty); ok {
+			title = t
 		}
 	}
-	if len(title.Title) < 1 {
+	if title == nil || len(title.Title) < 1 {
 		return ""
 	}
 	return title.Title[0].PlainText
 
 }
 
+// withIconInTitle returns a copy of p with its emoji icon prepended to its
+// title. When p does not have an emoji icon, p is returned.
+func withIconInTitle(p *na.Page) *na.Page {
+	if p.Icon == nil || p.Icon.Emoji == nil {
+		return p
+	}
+	emoji := string(*p.Icon.Emoji)
+
+	props := make(na.Properties, len(p.Properties))
+	for k, v := range p.Properties {
+		if t, ok := v.(*na.TitleProperty); ok {
+			title := *t
+			if len(title.Title) < 1 {
+				title.Title = []na.RichText{{PlainText: emoji}}
+			} else {
+				title.Title = append([]na.RichText{}, t.Title...)
+				title.Title[0].PlainText = emoji + " " + title.Title[0].PlainText
+			}
+			v = &title
+		}
+		props[k] = v
+	}
+
+	page := *p
+	page.Properties = props
+	return &page
+}
+
+// resolveMentionText returns the display text for a RichText element of type
+// mention. The notionapi client does not decode the mention object itself,
+// however the Notion API populates PlainText with the resolved value of every
+// mention type: the title for page mentions, "@name" for user mentions, and
+// the formatted date for date mentions. When no plain text is available
+// (e.g. the integration cannot access a mentioned page), the Href is used.
+func resolveMentionText(t na.RichText) string {
+	if t.PlainText != "" {
+		return t.PlainText
+	}
+	return t.Href
+}
+
+// resolveLinkURL returns the URL the text t links to, or an empty string when
+// it isn't a link. The link set on the text in Notion is preferred, as Href
+// is derived from it by Notion and may differ subtly (e.g. a relative link
+// to a page made absolute). Href is used for text without a link of its own,
+// such as mentions.
+func resolveLinkURL(t na.RichText) string {
+	if t.Text.Link != nil && t.Text.Link.Url != "" {
+		return t.Text.Link.Url
+	}
+	return t.Href
+}
+
+// withLinkURL returns t linking to address instead, replacing both the link
+// set on the text and Href, so resolveLinkURL returns address. t is not
+// modified.
+func withLinkURL(t na.RichText, address string) na.RichText {
+	t.Href = address
+	if t.Text.Link != nil {
+		t.Text.Link = &na.Link{Url: address}
+	}
+	return t
+}
+
+// splitSurroundingSpace returns the whitespace leading s, s without it, and
+// the whitespace trailing s. Renderers keep the whitespace outside of the
+// markup of links, which Notion often includes in the linked text (e.g.
+// "foo [bar ](url)baz" is rendered as "foo [bar](url) baz").
+func splitSurroundingSpace(s string) (leading, inner, trailing string) {
+	inner = strings.TrimLeftFunc(s, unicode.IsSpace)
+	leading = s[:len(s)-len(inner)]
+	trimmed := strings.TrimRightFunc(inner, unicode.IsSpace)
+	return leading, trimmed, inner[len(trimmed):]
+}
+
 // renderBlocks retrieves the blocks that compose a page. It iterates over
 // every block retrieved calling appropriate render functionality. As blocks
 // are rendered into their string representation, they are appended to the
-// []byte stored in the exporter instance. After all blocks are rendered, the
-// resulting []byte is returned. If the caller provided any override functiosn
+// io.Writer stored in the exporter instance. If the caller provided any override functiosn
 // in OverrideOptions, those are passed and will be respected for the
 // appropriate block render(s). An error is returned if there are issues with
 // client access to page, blocks, or other objects.
-func (e *exporter) renderBlocks(pageID string, blocks *na.GetChildrenResponse, opts ...RenderOptions) ([]byte, error) {
+func (e *exporter) renderBlocks(ctx context.Context, pageID string, blocks *na.GetChildrenResponse,
+	opts ...RenderOptions) (RenderOptions, error) {
 	config := resolveRenderConfig(opts...)
 
 	for _, b := range blocks.Results {
 		var rend string
 		var err error
+		sepType := string(b.GetType())
 		switch b.GetType() {
 
 		case "heading_1":
 			in := b.(*na.Heading1Block)
-			txt := e.Renderer.RenderText(in.Heading1.RichText)
+			txt := e.renderText(ctx, in.Heading1.RichText, config)
+			hOpts := resolveHeadingOpts(config, in.Heading1.RichText)
 
-			rend = e.Renderer.RenderPageHeader1(&Block{txt, in, opts, config.depth, config.originalPageRef},
+			rend = e.Renderer.RenderPageHeader1(&Block{txt, in, hOpts, config.depth, config.originalPageRef},
 				config.Overrides.Header1)
 
 		case "heading_2":
 			in := b.(*na.Heading2Block)
-			txt := e.Renderer.RenderText(in.Heading2.RichText)
-			rend = e.Renderer.RenderPageHeader2(&Block{txt, in, opts, config.depth, config.originalPageRef},
+			txt := e.renderText(ctx, in.Heading2.RichText, config)
+			hOpts := resolveHeadingOpts(config, in.Heading2.RichText)
+			rend = e.Renderer.RenderPageHeader2(&Block{txt, in, hOpts, config.depth, config.originalPageRef},
 				config.Overrides.Header2)
 
 		case "heading_3":
 			in := b.(*na.Heading3Block)
-			txt := e.Renderer.RenderText(in.Heading3.RichText)
-			rend = e.Renderer.RenderPageHeader3(&Block{txt, in, opts, config.depth, config.originalPageRef},
+			txt := e.renderText(ctx, in.Heading3.RichText, config)
+			hOpts := resolveHeadingOpts(config, in.Heading3.RichText)
+			rend = e.Renderer.RenderPageHeader3(&Block{txt, in, hOpts, config.depth, config.originalPageRef},
 				config.Overrides.Header3)
 
 		case "paragraph":
@@ -184,25 +909,28 @@ func (e *exporter) renderBlocks(pageID string, blocks *na.GetChildrenResponse, o
 			if config.SkipEmptyParagraphs && len(in.Paragraph.RichText) < 1 {
 				continue
 			}
-			txt := e.Renderer.RenderText(in.Paragraph.RichText)
+			txt := e.renderText(ctx, in.Paragraph.RichText, config)
 			rend = e.Renderer.RenderParagraph(&Block{txt, in, opts, config.depth, config.originalPageRef},
 				config.Overrides.Paragraph)
 
 		case "bulleted_list_item":
 			in := b.(*na.BulletedListItemBlock)
-			txt := e.Renderer.RenderText(in.BulletedListItem.RichText)
+			txt := e.renderText(ctx, in.BulletedListItem.RichText, config)
 			rend = e.Renderer.RenderBulletedList(&Block{txt, in, opts, config.depth, config.originalPageRef},
 				config.Overrides.BulletedList)
 
 		case "numbered_list_item":
 			in := b.(*na.NumberedListItemBlock)
-			txt := e.Renderer.RenderText(in.NumberedListItem.RichText)
-			rend = e.Renderer.RenderNumberedList(&Block{txt, in, opts, config.depth, config.originalPageRef},
-				config.Overrides.NumberedList)
+			txt := e.renderText(ctx, in.NumberedListItem.RichText, config)
+			// this item continues (or starts) the list. Pass the current
+			// state so the renderer can resolve this item's number.
+			config.numberedListIndex++
+			rend = e.Renderer.RenderNumberedList(&Block{txt, in, []RenderOptions{config}, config.depth,
+				config.originalPageRef}, config.Overrides.NumberedList)
 
 		case "to_do":
 			in := b.(*na.ToDoBlock)
-			txt := e.Renderer.RenderText(in.ToDo.RichText)
+			txt := e.renderText(ctx, in.ToDo.RichText, config)
 			rend = e.Renderer.RenderTodoList(&Block{txt, in, opts, config.depth, config.originalPageRef},
 				config.Overrides.Todo)
 
@@ -213,15 +941,24 @@ func (e *exporter) renderBlocks(pageID string, blocks *na.GetChildrenResponse, o
 
 		case "code":
 			in := b.(*na.CodeBlock)
-			txt := e.Renderer.RenderText(in.Code.RichText)
-			rend = e.Renderer.RenderCode(&Block{txt, in, opts, config.depth, config.originalPageRef},
-				config.Overrides.Code)
+			// URLs in code are left as is, as the code is rendered verbatim.
+			codeConfig := config
+			codeConfig.AutolinkBareURLs = false
+			codeConfig.uncounted = config.ExcludeCodeFromWordCount
+			txt := e.renderText(ctx, in.Code.RichText, codeConfig)
+			// the caption is rendered like other text, rather than verbatim.
+			captionConfig := config
+			captionConfig.codeCaption = e.renderText(ctx, in.Code.Caption, config)
+			rend = e.Renderer.RenderCode(&Block{txt, in, []RenderOptions{captionConfig}, config.depth,
+				config.originalPageRef}, config.Overrides.Code)
 
 		// new table detected. setup table state to support rendering
-		// future rows
+		// future rows. Rows are normally the table's children, rendered with
+		// a copy of this config, so every table, including one nested in a
+		// list item, has state of its own. The rows of database tables
+		// follow the table instead, sharing its config.
 		case "table":
-			config.tableState.tableBlock = b.(*na.TableBlock)
-			config.tableState.currentRow = 0
+			config.tableState = newTableState(b.(*na.TableBlock), config)
 
 		case "table_row":
 			in := b.(*na.TableRowBlock)
@@ -242,7 +979,7 @@ func (e *exporter) renderBlocks(pageID string, blocks *na.GetChildrenResponse, o
 				}
 
 				tc := tableCell{
-					rowTxt:         e.Renderer.RenderText(c),
+					rowTxt:         e.renderText(ctx, c, config),
 					isRowHeader:    rHeader,
 					isColumnHeader: cHeader,
 					tableRef:       config.tableState,
@@ -257,13 +994,13 @@ func (e *exporter) renderBlocks(pageID string, blocks *na.GetChildrenResponse, o
 
 		case "quote":
 			in := b.(*na.QuoteBlock)
-			txt := e.Renderer.RenderText(in.Quote.RichText)
+			txt := e.renderText(ctx, in.Quote.RichText, config)
 			rend = e.Renderer.RenderQuote(&Block{txt, in, opts, config.depth, config.originalPageRef},
 				config.Overrides.Quote)
 
 		case "callout":
 			in := b.(*na.CalloutBlock)
-			txt := e.Renderer.RenderText(in.Callout.RichText)
+			txt := e.renderText(ctx, in.Callout.RichText, config)
 			rend = e.Renderer.RenderCallout(&Block{txt, in, opts, config.depth, config.originalPageRef},
 				config.Overrides.Callout)
 
@@ -274,92 +1011,574 @@ func (e *exporter) renderBlocks(pageID string, blocks *na.GetChildrenResponse, o
 				continue
 			}
 			in := b.(*na.ImageBlock)
-			rend, err = e.Renderer.RenderImage(&Block{BlockRef: in, Opts: opts, PageRef: config.originalPageRef},
+			txt := e.renderText(ctx, in.Image.Caption, config)
+			rend, err = e.Renderer.RenderImage(&Block{txt, in, opts, config.depth, config.originalPageRef},
 				config.Overrides.Image)
 			if err != nil {
-				return e.page, err
+				return config, err
+			}
+
+		case "file":
+			in := b.(*na.FileBlock)
+			txt := e.renderText(ctx, in.File.Caption, config)
+			rend, err = e.Renderer.RenderFile(&Block{txt, in, opts, config.depth, config.originalPageRef},
+				config.Overrides.File)
+			if err != nil {
+				return config, err
+			}
+
+		case "video":
+			in := b.(*na.VideoBlock)
+			txt := e.renderText(ctx, in.Video.Caption, config)
+			rend, err = e.Renderer.RenderVideo(&Block{txt, in, opts, config.depth, config.originalPageRef},
+				config.Overrides.Video)
+			if err != nil {
+				return config, err
+			}
+
+		case "audio":
+			in := b.(*AudioBlock)
+			txt := e.renderText(ctx, in.Audio.Caption, config)
+			rend, err = e.Renderer.RenderAudio(&Block{txt, in, opts, config.depth, config.originalPageRef},
+				config.Overrides.Audio)
+			if err != nil {
+				return config, err
+			}
+
+		case "bookmark":
+			in := b.(*na.BookmarkBlock)
+			txt := e.renderText(ctx, in.Bookmark.Caption, config)
+			rend = e.Renderer.RenderBookmark(&Block{txt, in, opts, config.depth, config.originalPageRef},
+				config.Overrides.Bookmark)
+
+		case "equation":
+			in := b.(*na.EquationBlock)
+			rend = e.Renderer.RenderEquation(&Block{in.Equation.Expression, in, opts,
+				config.depth, config.originalPageRef}, config.Overrides.Equation)
+
+		// the content of columns is rendered sequentially, in the order of
+		// the columns.
+		case "column_list":
+			in := b.(*na.ColumnListBlock)
+			rend = e.Renderer.RenderColumnList(&Block{"", in, opts, config.depth, config.originalPageRef},
+				config.Overrides.ColumnList)
+
+		case "column":
+			in := b.(*na.ColumnBlock)
+			rend = e.Renderer.RenderColumn(&Block{"", in, opts, config.depth, config.originalPageRef},
+				config.Overrides.Column)
+
+		// templates are rendered as their label, followed by the content the
+		// template duplicates.
+		case "template":
+			in := b.(*na.TemplateBlock)
+			txt := e.renderText(ctx, in.Template.RichText, config)
+			rend = e.Renderer.RenderTemplate(&Block{txt, in, opts, config.depth, config.originalPageRef},
+				config.Overrides.Template)
+
+		case "child_page":
+			// without RecursePages, subpages are left out entirely, along with
+			// their blocks, rather than leaving separation behind.
+			if config.pages == nil {
+				continue
+			}
+			in := b.(*na.ChildPageBlock)
+			// pages discovered ahead of rendering were already queued with
+			// their parent, so their existing file name is returned.
+			fileName := config.pages.enqueue(string(in.ID), in.ChildPage.Title, "",
+				ResolvePageFileExtension(e.Renderer))
+			// the page is the root of this export, which is already being
+			// written wherever the caller chose, so there's no file to link to.
+			if fileName == "" {
+				continue
+			}
+			childConfig := config
+			childConfig.childPageFile = fileName
+			rend = e.Renderer.RenderChildPage(&Block{in.ChildPage.Title, in, []RenderOptions{childConfig},
+				config.depth, config.originalPageRef}, config.Overrides.ChildPage)
+
+		case "child_database":
+			in := b.(*na.ChildDatabaseBlock)
+			rend = e.Renderer.RenderChildDatabase(&Block{in.ChildDatabase.Title, in, opts, config.depth,
+				config.originalPageRef}, config.Overrides.ChildDatabase)
+
+		case "link_to_page":
+			in := b.(*na.LinkToPageBlock)
+			// the client only decodes links to pages, so the target of a link
+			// to a database is unknown and the block is treated as unsupported.
+			if in.LinkToPage.PageID == "" {
+				e.recordUnsupported(string(b.GetType()))
+				rend = e.Renderer.RenderUnsupported(&Block{"", b, opts, config.depth, config.originalPageRef},
+					config.Overrides.Unsupported)
+				if rend == "" {
+					continue
+				}
+				sepType = unsupportedBlockType
+				break
+			}
+			linkConfig := config
+			var title string
+			title, linkConfig.linkTarget = e.resolveLinkToPage(ctx, string(in.LinkToPage.PageID), config)
+			rend = e.Renderer.RenderLinkToPage(&Block{title, in, []RenderOptions{linkConfig},
+				config.depth, config.originalPageRef}, config.Overrides.LinkToPage)
+
+		// synced blocks have no content of their own; their children are
+		// rendered in place, as though they weren't nested.
+		case "synced_block":
+			in := b.(*na.SyncedBlock)
+			if config.AnnotateSyncedBlocks {
+				rend = renderSyncedBlockNote(e.Renderer, resolveSyncedBlockSource(in))
+			}
+
+		// the block type isn't supported. It's recorded so callers can tell
+		// content was left out, and the renderer may add a placeholder.
+		default:
+			if b.GetType() == "" {
+				b = &na.UnsupportedBlock{BasicBlock: na.BasicBlock{Type: unknownBlockType}}
+			}
+			e.recordUnsupported(string(b.GetType()))
+			rend = e.Renderer.RenderUnsupported(&Block{"", b, opts, config.depth, config.originalPageRef},
+				config.Overrides.Unsupported)
+			if rend != "" {
+				sepType = unsupportedBlockType
 			}
 		}
 
-		rend = e.Renderer.AddPadding(&Block{Text: rend, BlockRef: b,
-			Depth: config.depth})
+		// a synced block without a note leaves nothing, not even separation,
+		// so its children are separated from the blocks before it.
+		if b.GetType() == "synced_block" && rend == "" {
+			e.progress.blockDone()
+			e.dryRun.blockDone()
+		} else {
+			if config.EmbedBlockIDs && rend != "" && b.GetType() != "table_row" {
+				if marker := renderBlockID(e.Renderer, b.GetID().String()); marker != "" {
+					rend = marker + "\n" + rend
+				}
+			}
 
-		e.page = append(e.page,
-			e.Renderer.AddSectionSeperation(config.previousElementType,
-				string(b.GetType()))...)
+			rend = e.Renderer.AddPadding(&Block{Text: rend, BlockRef: b,
+				Opts: []RenderOptions{config}, Depth: config.depth})
 
-		e.page = append(e.page, rend...)
-		config.previousElementType = string(b.GetType())
+			previousType := config.previousElementType
+			// a list nested directly beneath a list item continues its
+			// parent's list, so it's separated as though it were the same
+			// type of item, rather than by a blank line that, in many
+			// parsers, ends the list. The same goes for the item following
+			// a nested list.
+			if isListItemType(previousType) && isListItemType(sepType) &&
+				config.previousDepth != config.depth {
+				previousType = sepType
+			}
+			err = e.write(e.Renderer.AddSectionSeperation(previousType,
+				sepType, resolveSeparationOverride(e.Renderer, config)) + rend)
+			if err != nil {
+				return config, err
+			}
+			e.progress.blockDone()
+			e.dryRun.blockDone()
+			config.previousElementType = string(b.GetType())
+			config.previousDepth = config.depth
+			// any block other than a numbered list item breaks the list, so
+			// the next numbered list item starts back at 1.
+			if b.GetType() != "numbered_list_item" {
+				config.numberedListIndex = 0
+			}
+		}
+		if b.GetType() == "child_database" && config.InlineChildDatabases {
+			if err := e.renderInlineDatabase(ctx, string(b.GetID()), config); err != nil {
+				return config, err
+			}
+		}
 		// When a child exists, recursively call r.ParseBlocks with the padding
 		// value incremented.
-		if b.GetHasChildren() {
+		// the blocks of a child page are exported to their own file when
+		// recursing, rather than nested in this page.
+		if b.GetHasChildren() && !(b.GetType() == "child_page" && config.pages != nil) {
 			configCopy := config
-			// when the type is table, it has children (rows) but not with
-			// increased depth
-			if b.GetType() != "table" {
+			// tables have children (rows) but not with increased depth. The
+			// same is true of columns, toggle headings, and templates, as
+			// their content isn't indented.
+			switch b.GetType() {
+			case "table", "column_list", "column", "heading_1", "heading_2", "heading_3",
+				"synced_block", "template":
+			default:
 				configCopy.depth += 1
 			}
-			_, err := e.renderFullPage(string(b.GetID()), "", configCopy)
+			// children are a new list with their own numbering
+			configCopy.numberedListIndex = 0
+			// the children of a reference to a synced block are those of the
+			// original block.
+			childrenID := string(b.GetID())
+			if sb, ok := b.(*na.SyncedBlock); ok {
+				childrenID = resolveSyncedBlockSource(sb)
+			}
+			// children beyond MaxDepth are left out, optionally marked by a
+			// paragraph, rendered the same as any other, in their place.
+			var err error
+			if config.MaxDepth > 0 && configCopy.depth >= config.MaxDepth {
+				if config.MarkTruncatedBlocks {
+					marker := &na.GetChildrenResponse{Results: []na.Block{truncatedBlocksParagraph()}}
+					configCopy, err = e.renderBlocks(ctx, pageID, marker, configCopy)
+				}
+			} else if q, ok := e.Renderer.(QuoteRenderer); ok && config.QuoteChildren &&
+				b.GetType() == "quote" {
+				configCopy, err = e.renderQuotedChildren(ctx, q, childrenID, configCopy)
+			} else {
+				configCopy, err = e.renderFullPage(ctx, childrenID, "", configCopy)
+			}
 			if err != nil {
-				return e.page, err
+				return config, err
+			}
+			// the next sibling follows the last child rendered, rather than
+			// this block, so it's separated from that child.
+			config.previousElementType = configCopy.previousElementType
+			config.previousDepth = configCopy.previousDepth
+			err = e.write(e.Renderer.AddBlockEnd(&Block{BlockRef: b, Opts: []RenderOptions{config},
+				Depth: config.depth, PageRef: config.originalPageRef}, config.Overrides.BlockEnd))
+			if err != nil {
+				return config, err
 			}
 		}
 	}
 
-	return e.page, nil
+	return config, nil
+}
+
+// renderQuotedChildren renders the children of the quote block quoteID
+// within the quote, per RenderOptions.QuoteChildren. The children are
+// rendered unindented, then quoted by q and padded to the depth of the quote.
+// The returned RenderOptions record the quote as the last block rendered.
+func (e *exporter) renderQuotedChildren(ctx context.Context, q QuoteRenderer, quoteID string,
+	config RenderOptions) (RenderOptions, error) {
+
+	quoteDepth := config.depth - 1
+	// levels are counted from the quote's children, as they're rendered
+	// from the root.
+	if config.MaxDepth > 0 {
+		config.MaxDepth -= config.depth
+	}
+	children := config
+	children.depth = 0
+	children.previousElementType = ""
+	children.previousDepth = 0
+
+	w := e.w
+	var buf bytes.Buffer
+	e.w = &buf
+	_, err := e.renderFullPage(ctx, quoteID, "", children)
+	e.w = w
+	if err != nil {
+		return config, err
+	}
+
+	config.previousElementType = "quote"
+	config.previousDepth = quoteDepth
+	txt := strings.Trim(buf.String(), "\n")
+	if txt == "" {
+		return config, nil
+	}
+	err = e.write("\n" + e.Renderer.AddPadding(&Block{Text: q.RenderQuotedChildren(txt),
+		Opts: []RenderOptions{config}, Depth: quoteDepth}))
+	return config, err
+}
+
+// truncatedBlocksParagraph returns the paragraph added in place of children
+// left out by RenderOptions.MaxDepth.
+func truncatedBlocksParagraph() *na.ParagraphBlock {
+	return &na.ParagraphBlock{
+		BasicBlock: na.BasicBlock{Object: na.ObjectTypeBlock, Type: na.BlockTypeParagraph},
+		Paragraph:  na.Paragraph{RichText: plainRichText(truncatedBlocksMarker)},
+	}
 }
 
-func (e *exporter) renderFullPage(pageID string, startCursor string, opts ...RenderOptions) ([]byte, error) {
+func (e *exporter) renderFullPage(ctx context.Context, pageID string, startCursor string,
+	opts ...RenderOptions) (RenderOptions, error) {
 	config := resolveRenderConfig(opts...)
 
 	if config.originalPageRef == nil {
 		// Retrieve page object to pass to renderer in case render behavior depends
 		// on looking up metadata about the page.
-		page, err := e.c.Page.Get(context.Background(), na.PageID(pageID))
+		page, err := e.c.Page.Get(ctx, na.PageID(pageID))
 		if err != nil {
-			return e.page, fmt.Errorf("failed to retrieve page from Notion. "+
+			if clientErr := resolveClientError("page", pageID, err); clientErr != nil {
+				return config, clientErr
+			}
+			return config, fmt.Errorf("failed to retrieve page from Notion. "+
 				"Error: %s.", err)
 		}
 		config.originalPageRef = page
 	}
+	config.ImageOpts.page = config.originalPageRef
+	config.ImageOpts.refreshURL = e.refreshFileURL(ctx)
+	config.ImageOpts.ctx = ctx
+
+	blocks, err := e.getChildren(ctx, pageID, startCursor, config)
+	if err != nil {
+		return config, err
+	}
+
+	// download the files referenced by these blocks ahead of rendering them,
+	// so they can be fetched concurrently.
+	if config.ImageOpts.DownloadConcurrency > 1 {
+		config.ImageOpts.prefetched = prefetchDownloads(blocks.Results, config)
+	}
+
+	config, err = e.renderBlocks(ctx, pageID, blocks, config)
+	if err != nil {
+		return config, err
+	}
+
+	if blocks.HasMore {
+		return e.renderFullPage(ctx, pageID, blocks.NextCursor, config)
+	}
+
+	return config, nil
+}
+
+// getChildren returns the blocks of pageID starting at startCursor. Blocks
+// already retrieved while discovering the pages of a recursive export are
+// used when available.
+func (e *exporter) getChildren(ctx context.Context, pageID string, startCursor string,
+	config RenderOptions) (*na.GetChildrenResponse, error) {
+
+	if config.pages != nil {
+		if blocks, ok := config.pages.cachedChildren(pageID, startCursor); ok {
+			return blocks, nil
+		}
+	}
 
 	// retrieve all blocks from Notion API for page. The max & default page size is 100
 	// (https://developers.notion.com/reference/pagination).
-	blocks, err := e.c.Block.GetChildren(context.Background(),
+	blocks, err := e.c.Block.GetChildren(ctx,
 		na.BlockID(pageID), &na.Pagination{
 			StartCursor: na.Cursor(startCursor),
 		})
 
 	if err != nil {
-		return e.page, fmt.Errorf("failed to retrieve data from Notion. "+
+		if clientErr := resolveClientError("block", pageID, err); clientErr != nil {
+			return nil, clientErr
+		}
+		return nil, fmt.Errorf("failed to retrieve data from Notion. "+
 			"Error: %s.", err)
 	}
+	e.audio.resolveBlocks(blocks)
+	e.progress.logf("Fetched %d blocks of %s", len(blocks.Results), pageID)
+
+	return blocks, nil
+}
+
+// refreshFileURL returns a function, used as ImageSaveOptions.refreshURL, that
+// retrieves a block again and returns the current URL of the Notion-hosted
+// file it references.
+func (e *exporter) refreshFileURL(ctx context.Context) func(blockID string) (string, error) {
+	return func(blockID string) (string, error) {
+		b, err := e.c.Block.Get(ctx, na.BlockID(blockID))
+		if err != nil {
+			return "", err
+		}
+		address := hostedFileURL(e.audio.resolveBlock(b))
+		if address == "" {
+			return "", fmt.Errorf("block %s no longer references a Notion-hosted file", blockID)
+		}
+		e.progress.logf("Refreshed expired URL of the file in block %s", blockID)
+		return address, nil
+	}
+}
+
+// renderBlockID returns a comment, in the format of r, recording the ID of a
+// block. Nothing is returned when r isn't a CommentRenderer, such as for
+// plain text, or JSON, which already records IDs.
+func renderBlockID(r Renderer, id string) string {
+	if c, ok := r.(CommentRenderer); ok {
+		return c.RenderComment(fmt.Sprintf(blockIDComment, id))
+	}
+	return ""
+}
+
+// renderSyncedBlockNote returns a comment, in the format of r, noting the
+// content that follows is synced from the block sourceID. Like
+// renderBlockID, nothing is returned when r isn't a CommentRenderer.
+func renderSyncedBlockNote(r Renderer, sourceID string) string {
+	if c, ok := r.(CommentRenderer); ok {
+		return c.RenderComment(fmt.Sprintf(syncedBlockComment, sourceID))
+	}
+	return ""
+}
+
+// resolveSyncedBlockSource returns the ID of the block the content of sb is
+// synced from, which holds its children. This is the original synced block
+// when sb references one, otherwise sb itself.
+func resolveSyncedBlockSource(sb *na.SyncedBlock) string {
+	if sb.SyncedBlock.SyncedFrom != nil && sb.SyncedBlock.SyncedFrom.BlockID != "" {
+		return string(sb.SyncedBlock.SyncedFrom.BlockID)
+	}
+	return string(sb.ID)
+}
 
-	_, err = e.renderBlocks(pageID, blocks, config)
+// resolveLinkToPage returns the title of the page pageID, linked to by a
+// link_to_page block, and the target of the link. The target is the file the
+// page is exported to when it's part of a recursive export, otherwise its URL
+// in Notion. When the page can't be retrieved, such as when it isn't shared
+// with the integration, the title is empty.
+func (e *exporter) resolveLinkToPage(ctx context.Context, pageID string,
+	config RenderOptions) (title, target string) {
+
+	target = resolveNotionURL(pageID)
+	if config.pages != nil {
+		if fileName, ok := config.pages.resolveLink(target); ok {
+			target = fileName
+		}
+	}
+
+	p, err := e.c.Page.Get(ctx, na.PageID(pageID))
 	if err != nil {
-		return e.page, err
+		e.progress.logf("Failed getting linked page %s, error from client: %s", pageID, err)
+		return "", target
 	}
+	return ResolveTitleInPage(p), target
+}
 
-	if blocks.HasMore {
-		_, err := e.renderFullPage(pageID, blocks.NextCursor, config)
-		if err != nil {
-			return e.page, err
+// resolveInternalLinkTitles returns rt with the text of every link to a Notion
+// page, whose text is the link's URL, replaced by the title of the page.
+// Titles are retrieved once per export. Links to pages that can't be
+// retrieved, and page mentions, which are already titled, are left as is. rt
+// is not modified.
+func (e *exporter) resolveInternalLinkTitles(ctx context.Context, rt []na.RichText) []na.RichText {
+	var resolved []na.RichText
+	for i, t := range rt {
+		if t.Type == "mention" || strings.TrimSpace(t.PlainText) != t.Href {
+			continue
+		}
+		pageID, ok := parseNotionPageLink(t.Href)
+		if !ok {
+			continue
 		}
+		title := e.resolveLinkTitle(ctx, pageID)
+		if title == "" {
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]na.RichText{}, rt...)
+		}
+		resolved[i].PlainText = title
+		resolved[i].Text.Content = title
 	}
 
-	return e.page, err
+	if resolved == nil {
+		return rt
+	}
+	return resolved
+}
+
+// resolveLinkTitle returns the title of the page pageID, retrieving it only
+// the first time it's linked to. An empty string is returned when the page
+// can't be retrieved, such as when it isn't shared with the integration.
+func (e *exporter) resolveLinkTitle(ctx context.Context, pageID string) string {
+	if title, ok := e.linkTitles[pageID]; ok {
+		return title
+	}
+	if e.linkTitles == nil {
+		e.linkTitles = map[string]string{}
+	}
+	p, err := e.c.Page.Get(ctx, na.PageID(pageID))
+	if err != nil {
+		e.progress.logf("Failed getting linked page %s, error from client: %s", pageID, err)
+		e.linkTitles[pageID] = ""
+		return ""
+	}
+	e.linkTitles[pageID] = ResolveTitleInPage(p)
+	return e.linkTitles[pageID]
+}
+
+// isListItemType returns whether blockType is a type of list item.
+func isListItemType(blockType string) bool {
+	switch blockType {
+	case "bulleted_list_item", "numbered_list_item", "to_do":
+		return true
+	}
+	return false
+}
+
+// resolveSeparationOverride returns a seperationOverride that looks up the
+// separation between two blocks in config.Separation, first by the
+// "previous>current" type and then by the current type alone, falling back to
+// r's own rules. It returns nil when config.Separation is empty or r is a
+// StructuredRenderer, whose blocks aren't separated by text.
+func resolveSeparationOverride(r Renderer, config RenderOptions) seperationOverride {
+	if len(config.Separation) == 0 {
+		return nil
+	}
+	if _, ok := r.(StructuredRenderer); ok {
+		return nil
+	}
+	return func(p string, c string) string {
+		if sep, ok := config.Separation[p+">"+c]; ok {
+			return sep
+		}
+		if sep, ok := config.Separation[c]; ok {
+			return sep
+		}
+		return r.AddSectionSeperation(p, c)
+	}
+}
+
+// write writes the rendered string s to the exporter's io.Writer.
+func (e *exporter) write(s string) error {
+	_, err := io.WriteString(e.w, s)
+	return err
+}
+
+// VerifyToken checks that the exporter's Notion token is accepted by the
+// Notion API by making a single, small search request, so a mistyped or
+// revoked token is caught before an export starts. An error explaining the
+// problem is returned when the token is rejected or the request fails.
+func (e *exporter) VerifyToken(ctx context.Context) error {
+	_, err := e.c.Search.Do(ctx, &na.SearchRequest{PageSize: 1})
+	if err == nil {
+		return nil
+	}
+	var apiErr *na.Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+		return errors.New(invalidTokenMessage)
+	}
+	return fmt.Errorf("Failed verifying Notion token, error from client: %s", err)
+}
+
+// resolveClientError returns an error explaining how to fix err, returned by
+// the Notion client when retrieving the object id of kind (e.g. page), when
+// err is a common mistake. The API responds to objects that aren't shared
+// with the integration as though they don't exist, so a 404 or 403 is
+// explained as the object not being shared, and a 401 as the token being
+// invalid. Otherwise, nil is returned.
+func resolveClientError(kind, id string, err error) error {
+	var apiErr *na.Error
+	if !errors.As(err, &apiErr) {
+		return nil
+	}
+	switch apiErr.Status {
+	case http.StatusUnauthorized:
+		return errors.New(invalidTokenMessage)
+	case http.StatusNotFound, http.StatusForbidden:
+		return fmt.Errorf("Notion %s (%s) could not be accessed, which usually means "+
+			"it isn't shared with your integration. Share it by opening the page in "+
+			"Notion, choosing Connections from the ••• menu, and adding your "+
+			"integration. Error from client: %s", kind, id, apiErr.Message)
+	}
+	return nil
 }
 
 // resolveNotionToken attempts to find a Notion integration token
-// (https://developers.notion.com/docs/authorization). It will prefer a token
-// set in the NOTION_TOKEN environment variable. If not present, it looks for
-// this token in ${HOME}/.config/nexp.yaml. An error is returned when
-// no token is found.
-func resolveNotionToken() (string, error) {
+// (https://developers.notion.com/docs/authorization). When profile is set, the
+// token of that profile in ${HOME}/.config/nexp.yaml is used. Otherwise, it
+// will prefer a token set in the NOTION_TOKEN environment variable. If not
+// present, it looks for the token of the default profile, or the token, in
+// ${HOME}/.config/nexp.yaml. An error is returned when no token is found.
+func resolveNotionToken(profile string) (string, error) {
 	var t string
-	t = os.Getenv(notionApiEnvVar)
+	if profile == "" {
+		t = os.Getenv(notionApiEnvVar)
+	}
 	if t != "" {
-		fmt.Println(t)
 		return t, nil
 	}
 
@@ -367,11 +1586,8 @@ func resolveNotionToken() (string, error) {
 	if err != nil {
 		return t, err
 	}
-	if conf.Token == "" {
-		return t, fmt.Errorf("Token retrieved from configuration was empty")
-	}
 
-	return conf.Token, nil
+	return conf.ResolveToken(profile)
 }
 
 // resolveRenderConfig takes a set of RenderOptions and returns the first
@@ -0,0 +1,742 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+// update rewrites the golden files in testdata with the output of the tests
+// comparing against them, e.g. go test ./export -update.
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+// fakeNotion is a Notion API serving the objects it holds, keyed by the path
+// of each request below /v1/ (e.g. pages/<id> or blocks/<id>/children).
+// Objects that aren't strings are encoded as JSON. Requests for paths it
+// doesn't hold are answered with a 404, as Notion does for unknown objects.
+type fakeNotion map[string]any
+
+func (f fakeNotion) RoundTrip(r *http.Request) (*http.Response, error) {
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	status := http.StatusOK
+	body, ok := f[path]
+	if !ok {
+		status = http.StatusNotFound
+		body = map[string]any{"object": "error", "status": status, "code": "object_not_found",
+			"message": "Could not find " + path}
+	}
+	b, ok := body.(string)
+	if !ok {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		b = string(encoded)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(b)),
+		Request:    r,
+	}, nil
+}
+
+// page adds a page titled title, whose blocks are blocks, to f.
+func (f fakeNotion) page(id, title string, blocks ...na.Block) fakeNotion {
+	f["pages/"+id] = &na.Page{
+		Object: na.ObjectTypePage,
+		ID:     na.ObjectID(id),
+		Properties: na.Properties{
+			"title": &na.TitleProperty{Type: na.PropertyTypeTitle, Title: plainRichText(title)},
+		},
+	}
+	return f.children(id, blocks...)
+}
+
+// children adds blocks to f as the children of the block id.
+func (f fakeNotion) children(id string, blocks ...na.Block) fakeNotion {
+	f["blocks/"+id+"/children"] = &na.GetChildrenResponse{Object: "list", Results: blocks}
+	return f
+}
+
+// newTestExporter returns an exporter rendering format, whose Notion API is f.
+func newTestExporter(t *testing.T, format string, f fakeNotion) *exporter {
+	t.Helper()
+	e, err := NewExporter(ExporterOptions{
+		Format: format,
+		Client: na.NewClient("test-token", na.WithHTTPClient(&http.Client{Transport: f})),
+	})
+	if err != nil {
+		t.Fatalf("NewExporter() error: %s", err)
+	}
+	return e
+}
+
+// basicBlock returns the fields common to every block, for a block of type typ.
+func basicBlock(id string, typ na.BlockType, hasChildren bool) na.BasicBlock {
+	return na.BasicBlock{Object: na.ObjectTypeBlock, ID: na.BlockID(id), Type: typ,
+		HasChildren: hasChildren}
+}
+
+// text returns s as plain rich text with no annotations, as Notion sends for
+// some text, such as the text of mentions.
+func text(s string) na.RichText {
+	return na.RichText{Type: na.ObjectTypeText, Text: na.Text{Content: s}, PlainText: s}
+}
+
+// annotated returns s as rich text with the annotations a.
+func annotated(s string, a na.Annotations) na.RichText {
+	t := text(s)
+	t.Annotations = &a
+	return t
+}
+
+// linked returns t linking to url.
+func linked(t na.RichText, url string) na.RichText {
+	t.Text.Link = &na.Link{Url: url}
+	t.Href = url
+	return t
+}
+
+func paragraph(id string, rt ...na.RichText) na.Block {
+	return &na.ParagraphBlock{BasicBlock: basicBlock(id, na.BlockTypeParagraph, false),
+		Paragraph: na.Paragraph{RichText: rt}}
+}
+
+func bulletedListItem(id string, hasChildren bool, rt ...na.RichText) na.Block {
+	return &na.BulletedListItemBlock{BasicBlock: basicBlock(id, na.BlockTypeBulletedListItem, hasChildren),
+		BulletedListItem: na.ListItem{RichText: rt}}
+}
+
+func numberedListItem(id string, rt ...na.RichText) na.Block {
+	return &na.NumberedListItemBlock{BasicBlock: basicBlock(id, na.BlockTypeNumberedListItem, false),
+		NumberedListItem: na.ListItem{RichText: rt}}
+}
+
+// samplePage returns a Notion API holding the page "sample", which contains a
+// block of most types, for comparing the output of renderers against their
+// golden files.
+func samplePage() fakeNotion {
+	f := fakeNotion{}.page("sample", "Sample Page",
+		&na.Heading1Block{BasicBlock: basicBlock("h1", na.BlockTypeHeading1, false),
+			Heading1: na.Heading{RichText: []na.RichText{text("Introduction")}}},
+		paragraph("p1", text("Plain, "), annotated("bold", na.Annotations{Bold: true}), text(", "),
+			annotated("italic", na.Annotations{Italic: true}), text(", "),
+			annotated("code", na.Annotations{Code: true}), text(", "),
+			annotated("struck", na.Annotations{Strikethrough: true}), text(" and "),
+			linked(annotated("a bold link", na.Annotations{Bold: true}), "https://example.com/a"),
+			text(" with 50% & $5 of {special} #characters_.")),
+		paragraph("p2", text("See"), linked(text(" the docs "), "https://example.com/docs"),
+			text("for more.")),
+		&na.Heading2Block{BasicBlock: basicBlock("h2", na.BlockTypeHeading2, false),
+			Heading2: na.Heading{RichText: []na.RichText{text("Lists")}}},
+		bulletedListItem("l1", true, text("First")),
+		bulletedListItem("l2", false, text("Second")),
+		numberedListItem("n1", text("One")),
+		numberedListItem("n2", text("Two")),
+		&na.ToDoBlock{BasicBlock: basicBlock("t1", na.BlockTypeToDo, false),
+			ToDo: na.ToDo{RichText: []na.RichText{text("Done")}, Checked: true}},
+		&na.Heading3Block{BasicBlock: basicBlock("h3", na.BlockTypeHeading3, false),
+			Heading3: na.Heading{RichText: []na.RichText{text("Other blocks")}}},
+		&na.CodeBlock{BasicBlock: basicBlock("c1", na.BlockTypeCode, false),
+			Code: na.Code{RichText: []na.RichText{text("echo \"hi\"\necho bye")}, Language: "shell"}},
+		&na.QuoteBlock{BasicBlock: basicBlock("q1", "quote", false),
+			Quote: na.Quote{RichText: []na.RichText{text("A quote")}}},
+		&na.EquationBlock{BasicBlock: basicBlock("e1", na.BlockTypeEquation, false),
+			Equation: na.Equation{Expression: "e=mc^2"}},
+		&na.DividerBlock{BasicBlock: basicBlock("d1", na.BlockTypeDivider, false)},
+		&na.TableBlock{BasicBlock: basicBlock("tb1", na.BlockTypeTableBlock, true),
+			Table: na.Table{TableWidth: 2, HasColumnHeader: true}},
+		&na.ImageBlock{BasicBlock: basicBlock("i1", na.BlockTypeImage, false),
+			Image: na.Image{Type: na.FileTypeExternal, External: &na.FileObject{URL: "https://example.com/cat.jpg"},
+				Caption: []na.RichText{text("A cat")}}},
+	)
+	f.children("l1", bulletedListItem("l1a", false, text("Nested")))
+	f.children("tb1",
+		&na.TableRowBlock{BasicBlock: basicBlock("r1", na.BlockTypeTableRowBlock, false),
+			TableRow: na.TableRow{Cells: [][]na.RichText{{text("Name")}, {text("Value")}}}},
+		&na.TableRowBlock{BasicBlock: basicBlock("r2", na.BlockTypeTableRowBlock, false),
+			TableRow: na.TableRow{Cells: [][]na.RichText{{text("a")}, {annotated("1", na.Annotations{Bold: true})}}}},
+	)
+	return f
+}
+
+// assertGolden fails t when got differs from the golden file name in testdata,
+// or rewrites the file with got when the tests are run with -update.
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed reading golden file, error: %s", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n--- got\n%s\n--- want\n%s", path, got, want)
+	}
+}
+
+func TestRenderFrontmatterByFormat(t *testing.T) {
+	notion := fakeNotion{}.page("trip", "Trip", paragraph("p1", text("three words here")))
+	notion["pages/trip"].(*na.Page).Properties["Tags"] = &na.MultiSelectProperty{
+		Type: na.PropertyTypeMultiSelect, MultiSelect: []na.Option{{Name: "a"}, {Name: "b"}}}
+
+	tests := []struct {
+		name   string
+		format string
+		opts   RenderOptions
+		// want is the start of the output for formats other than json, and
+		// the frontmatter of the JSONDocument for json.
+		want string
+	}{
+		{
+			name:   "markdown",
+			format: "markdown",
+			opts:   RenderOptions{Frontmatter: true},
+			want:   "---\ntags:\n  - a\n  - b\ntitle: Trip\n---\n\nthree words here",
+		},
+		{
+			name:   "json",
+			format: "json",
+			opts:   RenderOptions{Frontmatter: true},
+			want:   `{"tags":["a","b"],"title":"Trip"}`,
+		},
+		{
+			name:   "json ignores the frontmatter format",
+			format: "json",
+			opts:   RenderOptions{Frontmatter: true, FrontmatterFormat: FrontmatterFormatTOML},
+			want:   `{"tags":["a","b"],"title":"Trip"}`,
+		},
+		{
+			name:   "json with reading time",
+			format: "json",
+			opts:   RenderOptions{Frontmatter: true, ReadingTime: true},
+			want:   `{"reading_time":1,"tags":["a","b"],"title":"Trip"}`,
+		},
+		{
+			name:   "json without frontmatter",
+			format: "json",
+			want:   `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := newTestExporter(t, tt.format, notion).Render("trip", tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if tt.format != "json" {
+				if !strings.HasPrefix(string(out), tt.want) {
+					t.Errorf("Render() = %q, want it to start with %q", out, tt.want)
+				}
+				return
+			}
+			var doc JSONDocument
+			if err := json.Unmarshal(out, &doc); err != nil {
+				t.Fatalf("Render() isn't valid JSON, error: %s\n%s", err, out)
+			}
+			got, _ := json.Marshal(doc.Frontmatter)
+			if string(got) != tt.want {
+				t.Errorf("Frontmatter = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderChildPages(t *testing.T) {
+	notion := fakeNotion{}.page("parent", "Parent",
+		paragraph("p1", text("before")),
+		&na.ChildPageBlock{BasicBlock: basicBlock("sub", "child_page", true),
+			ChildPage: struct {
+				Title string `json:"title"`
+			}{Title: "Sub Page"}},
+		paragraph("p2", text("after")),
+	).page("sub", "Sub Page", paragraph("s1", text("inside")))
+
+	tests := []struct {
+		name   string
+		format string
+		opts   RenderOptions
+		want   string
+	}{
+		{
+			name:   "markdown leaves subpages out",
+			format: "markdown",
+			want:   "before\n\nafter",
+		},
+		{
+			name:   "html leaves subpages out",
+			format: "html",
+			want:   "<p>before</p>\n<p>after</p>",
+		},
+		{
+			name:   "markdown links to recursed subpages",
+			format: "markdown",
+			opts:   RenderOptions{RecursePages: true},
+			want:   "before\n\n[Sub Page](sub-page.md)\n\nafter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.OmitPageHeader = true
+			tt.opts.PagesDir = t.TempDir()
+			out, err := newTestExporter(t, tt.format, notion).Render("parent", tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if got := strings.TrimSpace(string(out)); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveNotionToken(t *testing.T) {
+	const secret = "secret_token"
+	tests := []struct {
+		name    string
+		env     string
+		profile string
+		// config is written to ${HOME}/.config/nexp.yaml, when set.
+		config  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "environment variable",
+			env:  secret,
+			want: secret,
+		},
+		{
+			name:   "configuration file",
+			config: "token: " + secret,
+			want:   secret,
+		},
+		{
+			name:    "profile",
+			env:     "other",
+			profile: "work",
+			config:  "profiles:\n  work: " + secret,
+			want:    secret,
+		},
+		{
+			name:    "missing profile",
+			profile: "home",
+			config:  "profiles:\n  work: " + secret,
+			wantErr: true,
+		},
+		{
+			name:    "invalid configuration file",
+			config:  "token: " + secret + "\n\t- [",
+			wantErr: true,
+		},
+		{
+			name:    "no token",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv(notionApiEnvVar, tt.env)
+			if tt.config != "" {
+				if err := os.MkdirAll(filepath.Join(home, ".config"), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(home, ".config", "nexp.yaml"), []byte(tt.config),
+					0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// stdout is captured, as the token must never be printed to it.
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stdout := os.Stdout
+			os.Stdout = w
+			got, err := resolveNotionToken(tt.profile)
+			os.Stdout = stdout
+			w.Close()
+			printed, _ := io.ReadAll(r)
+
+			if len(printed) > 0 {
+				t.Errorf("resolveNotionToken() printed %q, want nothing", printed)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("resolveNotionToken() = %q, want an error", got)
+				}
+				if strings.Contains(err.Error(), secret) {
+					t.Errorf("resolveNotionToken() error %q contains the token", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveNotionToken() error: %s", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveNotionToken() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderImageWithoutSource(t *testing.T) {
+	image := &na.ImageBlock{BasicBlock: basicBlock("i1", na.BlockTypeImage, false),
+		Image: na.Image{Type: na.FileTypeFile}}
+	notion := fakeNotion{}.page("image", "Image", image)
+
+	for _, format := range []string{"markdown", "html", "confluence", "asciidoc", "org", "latex", "json"} {
+		t.Run(format, func(t *testing.T) {
+			out, err := newTestExporter(t, format, notion).Render("image")
+			if err == nil {
+				t.Fatalf("Render() = %q, want an error", out)
+			}
+			if !strings.Contains(err.Error(), "i1") {
+				t.Errorf("Render() error %q doesn't name the block", err)
+			}
+		})
+	}
+}
+
+func TestResolveTitleInPage(t *testing.T) {
+	tests := []struct {
+		name string
+		page *na.Page
+		want string
+	}{
+		{
+			name: "nil page",
+		},
+		{
+			name: "no properties",
+			page: &na.Page{ID: "untitled"},
+		},
+		{
+			name: "no title property",
+			page: &na.Page{ID: "untitled", Properties: na.Properties{
+				"Notes": &na.RichTextProperty{Type: na.PropertyTypeRichText, RichText: plainRichText("notes")}}},
+		},
+		{
+			name: "empty title",
+			page: &na.Page{ID: "untitled", Properties: na.Properties{
+				"title": &na.TitleProperty{Type: na.PropertyTypeTitle}}},
+		},
+		{
+			name: "title",
+			page: &na.Page{ID: "titled", Properties: na.Properties{
+				"Notes": &na.RichTextProperty{Type: na.PropertyTypeRichText, RichText: plainRichText("notes")},
+				"Name":  &na.TitleProperty{Type: na.PropertyTypeTitle, Title: plainRichText("Trip")}}},
+			want: "Trip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ResolveTitleInPage(tt.page); got != tt.want {
+				t.Errorf("ResolveTitleInPage() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderPageWithoutTitle(t *testing.T) {
+	notion := fakeNotion{}.page("untitled", "", paragraph("p1", text("body")))
+	notion["pages/untitled"].(*na.Page).Properties = na.Properties{}
+
+	out, err := newTestExporter(t, "markdown", notion).Render("untitled")
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+	if want := "body"; !strings.HasSuffix(string(out), want) {
+		t.Errorf("Render() = %q, want it to end with %q", out, want)
+	}
+}
+
+func TestRenderToggleHeading(t *testing.T) {
+	notion := fakeNotion{}.page("toggle", "Toggle",
+		&na.Heading2Block{BasicBlock: basicBlock("h2", na.BlockTypeHeading2, true),
+			Heading2: na.Heading{RichText: []na.RichText{text("Details")}}},
+		paragraph("p2", text("after")))
+	notion.children("h2", paragraph("p1", text("hidden")))
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "html",
+			want: "<h1>Toggle</h1>\n<details>\n<summary><h2>Details</h2></summary>\n<p>hidden</p>\n" +
+				"</details>\n<p>after</p>",
+		},
+		{
+			format: "markdown",
+			want:   "# Toggle\n\n## Details\n\nhidden\n\nafter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := newTestExporter(t, tt.format, notion).Render("toggle")
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Render() = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewExporterRenderer(t *testing.T) {
+	client := na.NewClient("test-token", na.WithHTTPClient(&http.Client{Transport: fakeNotion{}}))
+	custom := &HTMLRenderer{}
+
+	tests := []struct {
+		name    string
+		opts    ExporterOptions
+		want    reflect.Type
+		wantErr bool
+	}{
+		{
+			name: "neither set",
+			want: reflect.TypeOf(&MDRenderer{}),
+		},
+		{
+			name: "format only",
+			opts: ExporterOptions{Format: "org"},
+			want: reflect.TypeOf(&OrgRenderer{}),
+		},
+		{
+			name:    "unknown format",
+			opts:    ExporterOptions{Format: "docx"},
+			wantErr: true,
+		},
+		{
+			name: "renderer only",
+			opts: ExporterOptions{Renderer: custom},
+			want: reflect.TypeOf(custom),
+		},
+		{
+			name: "both set",
+			opts: ExporterOptions{Format: "docx", Renderer: custom},
+			want: reflect.TypeOf(custom),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := tt.opts
+			opts.Client = client
+			e, err := NewExporter(opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewExporter() = %T, want an error", e.Renderer)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewExporter() error: %s", err)
+			}
+			if got := reflect.TypeOf(e.Renderer); got != tt.want {
+				t.Errorf("NewExporter() renderer = %s, want %s", got, tt.want)
+			}
+			if tt.opts.Renderer != nil && e.Renderer != tt.opts.Renderer {
+				t.Errorf("NewExporter() renderer isn't the one set")
+			}
+		})
+	}
+}
+
+func TestRenderTemplate(t *testing.T) {
+	notion := fakeNotion{}.page("template", "Template",
+		&na.TemplateBlock{BasicBlock: basicBlock("tp1", "template", true),
+			Template: na.Template{RichText: []na.RichText{text("Add a task")}}},
+		paragraph("p2", text("after")))
+	notion.children("tp1",
+		&na.ToDoBlock{BasicBlock: basicBlock("td1", na.BlockTypeToDo, false),
+			ToDo: na.ToDo{RichText: []na.RichText{text("Task")}}},
+		paragraph("p1", text("Notes")))
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "markdown",
+			want:   "# Template\n\n**Add a task**\n\n- [ ] Task\n\nNotes\n\nafter",
+		},
+		{
+			format: "html",
+			want: "<h1>Template</h1>\n<p><strong>Add a task</strong></p>\n<ul>\n" +
+				"<li><input type=\"checkbox\" disabled> Task\n</li>\n</ul>\n<p>Notes</p>\n<p>after</p>",
+		},
+		{
+			format: "text",
+			want:   "Template\n\nAdd a task\n\n- Task\n\nNotes\n\nafter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			out, err := newTestExporter(t, tt.format, notion).Render("template")
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Render() = %q, want %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderAudio(t *testing.T) {
+	audio := func(id string, caption ...na.RichText) map[string]any {
+		return map[string]any{"object": "block", "id": id, "type": "audio",
+			"audio": map[string]any{"type": "external", "caption": caption,
+				"external": map[string]any{"url": "https://example.com/podcast/episode.mp3"}}}
+	}
+	notion := fakeNotion{}.page("audio", "Audio")
+	notion["blocks/audio/children"] = map[string]any{"object": "list", "results": []any{
+		audio("a1"), audio("a2", text("Episode 1")), paragraph("p1", text("after"))}}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{
+			format: "markdown",
+			want: "# Audio\n\n[episode.mp3](https://example.com/podcast/episode.mp3)\n\n" +
+				"[Episode 1](https://example.com/podcast/episode.mp3)\n\nafter",
+		},
+		{
+			format: "html",
+			want: "<h1>Audio</h1>\n<audio controls src=\"https://example.com/podcast/episode.mp3\"></audio>\n" +
+				"<audio controls src=\"https://example.com/podcast/episode.mp3\"></audio>\n<p>Episode 1</p>\n" +
+				"<p>after</p>",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			// audio blocks are only decoded by clients the exporter creates,
+			// whose transport is an audioTransport.
+			transport := newAudioTransport(notion)
+			e, err := NewExporter(ExporterOptions{
+				Format: tt.format,
+				Client: na.NewClient("test-token", na.WithHTTPClient(&http.Client{Transport: transport})),
+			})
+			if err != nil {
+				t.Fatalf("NewExporter() error: %s", err)
+			}
+			e.audio = transport
+
+			out, err := e.Render("audio")
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if string(out) != tt.want {
+				t.Errorf("Render() = %q, want %q", out, tt.want)
+			}
+			if len(e.UnsupportedBlocks()) != 0 {
+				t.Errorf("UnsupportedBlocks() = %v, want none", e.UnsupportedBlocks())
+			}
+		})
+	}
+}
+
+func TestRenderUnknownBlockType(t *testing.T) {
+	notion := fakeNotion{}.page("unknown", "Unknown")
+	notion["blocks/unknown/children"] = map[string]any{"object": "list", "results": []any{
+		map[string]any{"object": "block", "id": "u1", "type": "not_yet_released",
+			"not_yet_released": map[string]any{}},
+		paragraph("p1", text("after"))}}
+
+	e := newTestExporter(t, "markdown", notion)
+	out, err := e.Render("unknown", RenderOptions{MarkUnsupportedBlocks: true})
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+	if want := "# Unknown\n\n<!-- unsupported: unknown -->\n\nafter"; string(out) != want {
+		t.Errorf("Render() = %q, want %q", out, want)
+	}
+	if want := map[string]int{"unknown": 1}; !reflect.DeepEqual(e.UnsupportedBlocks(), want) {
+		t.Errorf("UnsupportedBlocks() = %v, want %v", e.UnsupportedBlocks(), want)
+	}
+}
+
+// lispRenderer is a renderer, as would be added with RegisterRenderer, whose
+// comments are Lisp's.
+type lispRenderer struct {
+	TextRenderer
+}
+
+func (l *lispRenderer) RenderComment(text string) string {
+	return ";; " + text
+}
+
+func TestRenderBlockID(t *testing.T) {
+	tests := []struct {
+		name       string
+		r          Renderer
+		wantID     string
+		wantSynced string
+	}{
+		{
+			name:       "markdown",
+			r:          &MDRenderer{},
+			wantID:     "<!-- block: b1 -->",
+			wantSynced: "<!-- synced from s1 -->",
+		},
+		{
+			name:       "latex",
+			r:          &LaTeXRenderer{},
+			wantID:     "% block: b1",
+			wantSynced: "% synced from s1",
+		},
+		{
+			name:       "confluence",
+			r:          &ConfluenceRenderer{},
+			wantID:     "<!-- block: b1 -->",
+			wantSynced: "<!-- synced from s1 -->",
+		},
+		{
+			name: "json",
+			r:    &JSONRenderer{},
+		},
+		{
+			name: "text",
+			r:    &TextRenderer{},
+		},
+		{
+			name:       "registered renderer with comments",
+			r:          &lispRenderer{},
+			wantID:     ";; block: b1",
+			wantSynced: ";; synced from s1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderBlockID(tt.r, "b1"); got != tt.wantID {
+				t.Errorf("renderBlockID() = %q, want %q", got, tt.wantID)
+			}
+			if got := renderSyncedBlockNote(tt.r, "s1"); got != tt.wantSynced {
+				t.Errorf("renderSyncedBlockNote() = %q, want %q", got, tt.wantSynced)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package export
+
+// This file contains the filesystem images and files are downloaded to, which
+// can be replaced, such as to exercise failures in tests.
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileSystem is the filesystem downloaded images and files are saved to,
+// set with ImageSaveOptions.FS. Names are paths of the operating system, as
+// used by the os package. When FS isn't set, the local filesystem is used.
+type FileSystem interface {
+	// Stat returns the FileInfo of the file name. An error wrapping
+	// fs.ErrNotExist is returned when it doesn't exist.
+	Stat(name string) (fs.FileInfo, error)
+	// Open opens the file name for reading.
+	Open(name string) (io.ReadCloser, error)
+	// OpenFile opens the file name for writing, the same as os.OpenFile.
+	OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error)
+	// MkdirAll creates the directory path, and any parents, with perm.
+	MkdirAll(path string, perm os.FileMode) error
+	// Remove removes the file or empty directory name.
+	Remove(name string) error
+	// Glob returns the names of files matching pattern, the same as
+	// filepath.Glob.
+	Glob(pattern string) ([]string, error)
+	// WalkDir walks the directory tree at root, the same as
+	// filepath.WalkDir.
+	WalkDir(root string, fn fs.WalkDirFunc) error
+}
+
+// osFS is the FileSystem of the local filesystem.
+type osFS struct{}
+
+func (osFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (osFS) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (osFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (osFS) Glob(pattern string) ([]string, error) {
+	return filepath.Glob(pattern)
+}
+
+func (osFS) WalkDir(root string, fn fs.WalkDirFunc) error {
+	return filepath.WalkDir(root, fn)
+}
@@ -0,0 +1,138 @@
+package export
+
+// This file contains the logic used to add provenance, a link back to the
+// page in Notion and when it was exported, to the footer of a page.
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	notionPageURLPrefix = "https://www.notion.so/"
+	sourceLinkText      = "Source"
+	exportedPattern     = "exported %s"
+	provenanceSeparator = " · "
+)
+
+// resolveFooterOverride returns the override used when rendering the footer of
+// page. A PageFooter override always takes precedence. Otherwise, when
+// FooterSourceLink or FooterTimestamp is set, the footer is the provenance of
+// the page, formatted for the exporter's Renderer. nil is returned when the
+// Renderer's default footer should be used. It must be called after the
+// page's header is rendered, as the JSONRenderer records the provenance in
+// its document rather than in a footer.
+func (e *exporter) resolveFooterOverride(page *na.Page,
+	config RenderOptions) headerFooterOverride {
+
+	if config.Overrides.PageFooter != nil ||
+		!(config.FooterSourceLink || config.FooterTimestamp) {
+		return config.Overrides.PageFooter
+	}
+	var source, exported string
+	if config.FooterSourceLink {
+		source = resolvePageURL(page)
+	}
+	if config.FooterTimestamp {
+		exported = time.Now().Format(time.RFC3339)
+	}
+
+	if structured, ok := e.Renderer.(StructuredRenderer); ok {
+		structured.SetProvenance(source, exported)
+		return nil
+	}
+	footer := renderProvenance(e.Renderer, source, exported)
+	return func(*na.Page) string { return footer }
+}
+
+// renderProvenance returns a footer, in the format of r, separated from the
+// page by a divider, that links to source and notes the time the page was
+// exported. Either may be empty, in which case it's left out.
+func renderProvenance(r Renderer, source, exported string) string {
+	var parts []string
+	switch r.(type) {
+	case *HTMLRenderer, *ConfluenceRenderer, *epubRenderer:
+		// the storage format is XML, so the divider must be closed.
+		divider := htmlDividerPattern
+		if _, ok := r.(*ConfluenceRenderer); ok {
+			divider = confluenceDividerPattern
+		}
+		if source != "" {
+			parts = append(parts, fmt.Sprintf(htmlLinkPattern, html.EscapeString(source),
+				sourceLinkText))
+		}
+		if exported != "" {
+			parts = append(parts, fmt.Sprintf(exportedPattern, exported))
+		}
+		return "\n" + divider + "\n" +
+			fmt.Sprintf(htmlParagraphPattern, strings.Join(parts, provenanceSeparator))
+	case *OrgRenderer:
+		if source != "" {
+			parts = append(parts, renderOrgLink(source, sourceLinkText))
+		}
+		if exported != "" {
+			parts = append(parts, fmt.Sprintf(exportedPattern, exported))
+		}
+		return "\n\n" + orgDividerPattern + "\n" + strings.Join(parts, provenanceSeparator)
+	case *LaTeXRenderer:
+		if source != "" {
+			parts = append(parts, renderLaTeXLink(source, sourceLinkText))
+		}
+		if exported != "" {
+			parts = append(parts, fmt.Sprintf(exportedPattern, exported))
+		}
+		return "\n\n" + latexDividerPattern + "\n\n" + strings.Join(parts, provenanceSeparator)
+	case *TextRenderer:
+		if source != "" {
+			parts = append(parts, sourceLinkText+": "+source)
+		}
+	case *AsciiDocRenderer:
+		if source != "" {
+			parts = append(parts, renderAdocLink(source, sourceLinkText))
+		}
+		if exported != "" {
+			parts = append(parts, fmt.Sprintf(exportedPattern, exported))
+		}
+		return "\n\n" + adocDividerPattern + "\n\n" + strings.Join(parts, provenanceSeparator)
+	default:
+		if source != "" {
+			parts = append(parts, fmt.Sprintf(mdLinkPattern, sourceLinkText, source))
+		}
+	}
+	if exported != "" {
+		parts = append(parts, fmt.Sprintf(exportedPattern, exported))
+	}
+
+	return "\n\n" + mdDividerPattern + "\n" + strings.Join(parts, provenanceSeparator)
+}
+
+// resolvePageURL returns the URL of page in Notion. When the page doesn't
+// carry its URL, it's reconstructed from the page's ID.
+func resolvePageURL(page *na.Page) string {
+	if page == nil {
+		return ""
+	}
+	if page.URL != "" {
+		return page.URL
+	}
+	return resolveNotionURL(page.ID.String())
+}
+
+// resolveNotionURL returns the URL, in Notion, of the page or database id.
+func resolveNotionURL(id string) string {
+	return notionPageURLPrefix + normalizePageID(id)
+}
+
+// ResolveChildDatabaseURL returns the URL, in Notion, of the database in a
+// child_database Block. An empty string is returned when the Block doesn't
+// reference a ChildDatabaseBlock.
+func ResolveChildDatabaseURL(b *Block) string {
+	if b.BlockRef == nil || b.BlockRef.GetType() != na.BlockTypeChildDatabase {
+		return ""
+	}
+	return resolveNotionURL(b.BlockRef.GetID().String())
+}
@@ -0,0 +1,434 @@
+package export
+
+// This file contains the logic used to serialize a page's properties into
+// frontmatter, as consumed by static site generators such as Hugo, Jekyll,
+// and Zola.
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	na "github.com/jomei/notionapi"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	frontmatterDelimiter     = "---"
+	tomlFrontmatterDelimiter = "+++"
+	// frontmatterDateLayout is used for dates without a time, which the
+	// notionapi client parses as midnight UTC.
+	frontmatterDateLayout = "2006-01-02"
+	// dateRangeSeparator separates the start and end of a date range
+	// formatted by FormatNotionDate.
+	dateRangeSeparator = " – "
+)
+
+var (
+	// tomlBareKeyPattern matches the keys TOML allows without quotes.
+	tomlBareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// timeLayoutPattern matches the time elements of a Go time layout, along
+	// with the separator before them (e.g. "T15:04:05Z07:00" or
+	// " 3:04 PM MST").
+	timeLayoutPattern = regexp.MustCompile(
+		`[T ,]*(15|0?3):0?4(:0?5(\.[09]+)?)?( ?(PM|pm))?( ?(Z07:00|Z0700|Z07|-07:00|-0700|-07|MST))?`)
+)
+
+// FrontmatterFormat is the format frontmatter is serialized in.
+type FrontmatterFormat string
+
+const (
+	// FrontmatterFormatYAML is YAML delimited by "---", as understood by
+	// Hugo and Jekyll. It's the default.
+	FrontmatterFormatYAML FrontmatterFormat = "yaml"
+	// FrontmatterFormatTOML is TOML delimited by "+++", as understood by Hugo
+	// and Zola.
+	FrontmatterFormatTOML FrontmatterFormat = "toml"
+	// FrontmatterFormatJSON is a JSON object, without delimiters, as
+	// understood by Hugo.
+	FrontmatterFormatJSON FrontmatterFormat = "json"
+)
+
+// RenderFrontmatter serializes the properties of page into a YAML frontmatter
+// block delimited by "---". Every property is added using its name, lowercased
+// with spaces replaced by "_", as the key. Static site generators expect a few
+// well-known keys, so the title property is always keyed as title, and unless
+// properties named date or tags exist, the first date and multi-select
+// properties (ordered by name) are keyed as date and tags. Properties without a
+// value are omitted.
+func RenderFrontmatter(page *na.Page) (string, error) {
+	return RenderFrontmatterAs(page, FrontmatterFormatYAML)
+}
+
+// RenderFrontmatterAs is the same as RenderFrontmatter, except the
+// frontmatter is serialized in format. The properties are keyed the same in
+// every format. When format is empty, YAML is used. An error is returned when
+// format isn't known.
+func RenderFrontmatterAs(page *na.Page, format FrontmatterFormat) (string, error) {
+	return encodeFrontmatter(frontmatterValues(page, ""), format)
+}
+
+// encodeFrontmatter serializes values, as returned by frontmatterValues, into
+// a frontmatter block in format.
+func encodeFrontmatter(values map[string]interface{}, format FrontmatterFormat) (string, error) {
+	switch format {
+	case FrontmatterFormatYAML, "":
+		var out strings.Builder
+		enc := yaml.NewEncoder(&out)
+		enc.SetIndent(2)
+		err := enc.Encode(values)
+		if err != nil {
+			return "", fmt.Errorf("failed serializing page properties to frontmatter: %s", err)
+		}
+		return frontmatterDelimiter + "\n" + out.String() + frontmatterDelimiter, nil
+	case FrontmatterFormatTOML:
+		return tomlFrontmatterDelimiter + "\n" + encodeTOML(values) + tomlFrontmatterDelimiter, nil
+	case FrontmatterFormatJSON:
+		out, err := json.MarshalIndent(values, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed serializing page properties to frontmatter: %s", err)
+		}
+		return string(out), nil
+	}
+
+	return "", fmt.Errorf("unknown frontmatter format %s, expected yaml, toml, or json", format)
+}
+
+// frontmatterValues returns the value of every property of page, keyed as
+// described in RenderFrontmatter. Dates are formatted with dateLayout, as
+// described in resolvePropertyValue.
+func frontmatterValues(page *na.Page, dateLayout string) map[string]interface{} {
+	names := make([]string, 0, len(page.Properties))
+	for name := range page.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// well-known keys are only claimed when no property already uses them.
+	claimed := map[string]bool{}
+	for _, name := range names {
+		claimed[frontmatterKey(name)] = true
+	}
+
+	values := map[string]interface{}{}
+	for _, name := range names {
+		p := page.Properties[name]
+		key := frontmatterKey(name)
+		switch p.GetType() {
+		case na.PropertyTypeTitle:
+			key = "title"
+		case na.PropertyTypeDate:
+			if !claimed["date"] {
+				key = "date"
+				claimed["date"] = true
+			}
+		case na.PropertyTypeMultiSelect:
+			if !claimed["tags"] {
+				key = "tags"
+				claimed["tags"] = true
+			}
+		}
+
+		if v := resolvePropertyValue(p, dateLayout); v != nil {
+			values[key] = v
+		}
+	}
+
+	return values
+}
+
+// encodeTOML serializes values, as returned by frontmatterValues, into TOML
+// key/value pairs, one per line, ordered by key. Maps, such as date ranges,
+// are serialized as inline tables.
+func encodeTOML(values map[string]interface{}) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var out strings.Builder
+	for _, k := range keys {
+		out.WriteString(encodeTOMLKey(k) + " = " + encodeTOMLValue(values[k]) + "\n")
+	}
+	return out.String()
+}
+
+// encodeTOMLKey returns k as a bare key or, when it contains characters bare
+// keys can't, as a quoted key.
+func encodeTOMLKey(k string) string {
+	if tomlBareKeyPattern.MatchString(k) {
+		return k
+	}
+	return encodeTOMLString(k)
+}
+
+// encodeTOMLValue returns v, one of the values returned by
+// resolvePropertyValue or an int, as a TOML value.
+func encodeTOMLValue(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return encodeTOMLString(val)
+	case bool:
+		return strconv.FormatBool(val)
+	case int:
+		return strconv.Itoa(val)
+	case float64:
+		switch {
+		case math.IsNaN(val):
+			return "nan"
+		case math.IsInf(val, 1):
+			return "inf"
+		case math.IsInf(val, -1):
+			return "-inf"
+		}
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case []string:
+		items := make([]string, 0, len(val))
+		for _, item := range val {
+			items = append(items, encodeTOMLString(item))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case map[string]string:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		pairs := make([]string, 0, len(val))
+		for _, k := range keys {
+			pairs = append(pairs, encodeTOMLKey(k)+" = "+encodeTOMLString(val[k]))
+		}
+		return "{ " + strings.Join(pairs, ", ") + " }"
+	}
+	return encodeTOMLString(fmt.Sprint(v))
+}
+
+// encodeTOMLString returns s as a TOML basic string, escaping quotes,
+// backslashes, and control characters.
+func encodeTOMLString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"':
+			b.WriteString(`\"`)
+		case r == '\\':
+			b.WriteString(`\\`)
+		case r == '\n':
+			b.WriteString(`\n`)
+		case r == '\t':
+			b.WriteString(`\t`)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, `\u%04X`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// frontmatterKey returns the frontmatter key for the property named name.
+func frontmatterKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_")
+}
+
+// resolvePropertyValue returns a value representing p that can be serialized
+// into frontmatter. Text is returned as plain text, options and users by
+// their names, and dates as strings. When dateLayout is set, dates, including
+// ranges, are formatted with FormatNotionDate. nil is returned when the
+// property has no value or its type can't be represented.
+func resolvePropertyValue(p na.Property, dateLayout string) interface{} {
+	switch prop := p.(type) {
+	case *na.TitleProperty:
+		return nilIfEmpty(richTextToPlain(prop.Title))
+	case *na.RichTextProperty:
+		return nilIfEmpty(richTextToPlain(prop.RichText))
+	case *na.TextProperty:
+		return nilIfEmpty(richTextToPlain(prop.Text))
+	case *na.NumberProperty:
+		return prop.Number
+	case *na.SelectProperty:
+		return nilIfEmpty(prop.Select.Name)
+	case *na.StatusProperty:
+		return nilIfEmpty(prop.Status.Name)
+	case *na.MultiSelectProperty:
+		if len(prop.MultiSelect) < 1 {
+			return nil
+		}
+		var tags []string
+		for _, o := range prop.MultiSelect {
+			tags = append(tags, o.Name)
+		}
+		return tags
+	case *na.DateProperty:
+		return resolveDateValue(prop.Date, dateLayout)
+	case *na.FormulaProperty:
+		switch prop.Formula.Type {
+		case "string":
+			return nilIfEmpty(prop.Formula.String)
+		case "number":
+			return prop.Formula.Number
+		case "boolean":
+			return prop.Formula.Boolean
+		case "date":
+			return resolveDateValue(prop.Formula.Date, dateLayout)
+		}
+	case *na.RollupProperty:
+		switch prop.Rollup.Type {
+		case "number":
+			return prop.Rollup.Number
+		case "date":
+			return resolveDateValue(prop.Rollup.Date, dateLayout)
+		}
+	case *na.RelationProperty:
+		if len(prop.Relation) < 1 {
+			return nil
+		}
+		var ids []string
+		for _, r := range prop.Relation {
+			ids = append(ids, string(r.ID))
+		}
+		return ids
+	case *na.PeopleProperty:
+		if len(prop.People) < 1 {
+			return nil
+		}
+		var people []string
+		for _, u := range prop.People {
+			people = append(people, u.Name)
+		}
+		return people
+	case *na.FilesProperty:
+		if len(prop.Files) < 1 {
+			return nil
+		}
+		var files []string
+		for _, f := range prop.Files {
+			files = append(files, f.Name)
+		}
+		return files
+	case *na.CheckboxProperty:
+		return prop.Checkbox
+	case *na.URLProperty:
+		return nilIfEmpty(prop.URL)
+	case *na.EmailProperty:
+		return nilIfEmpty(prop.Email)
+	case *na.PhoneNumberProperty:
+		return nilIfEmpty(prop.PhoneNumber)
+	case *na.CreatedTimeProperty:
+		return prop.CreatedTime.Format(time.RFC3339)
+	case *na.LastEditedTimeProperty:
+		return prop.LastEditedTime.Format(time.RFC3339)
+	case *na.CreatedByProperty:
+		return nilIfEmpty(prop.CreatedBy.Name)
+	case *na.LastEditedByProperty:
+		return nilIfEmpty(prop.LastEditedBy.Name)
+	}
+
+	return nil
+}
+
+// resolveDateValue returns the start of d as a string. When d is a range, a
+// map containing its start and end is returned instead. When dateLayout is
+// set, d is returned as formatted by FormatNotionDate. nil is returned when d
+// has no start.
+func resolveDateValue(d *na.DateObject, dateLayout string) interface{} {
+	if d == nil || d.Start == nil {
+		return nil
+	}
+	if dateLayout != "" {
+		return FormatNotionDate(d, dateLayout)
+	}
+	if d.End == nil {
+		return formatFrontmatterDate(d.Start)
+	}
+
+	return map[string]string{
+		"start": formatFrontmatterDate(d.Start),
+		"end":   formatFrontmatterDate(d.End),
+	}
+}
+
+// formatFrontmatterDate returns d as a date (e.g. 2022-10-06) when it has no
+// time, otherwise it is returned in RFC3339 format.
+func formatFrontmatterDate(d *na.Date) string {
+	t := time.Time(*d)
+	if isDateOnly(t) {
+		return t.Format(frontmatterDateLayout)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// FormatNotionDate returns d formatted with layout, a Go time layout (e.g.
+// "Jan 2, 2006"). A range is returned as its start and end separated by an en
+// dash (e.g. "2024-01-02 – 2024-01-05"). Times are formatted in the time zone
+// Notion returned them in. As Notion returns dates without a time as midnight
+// UTC, they're formatted with the date of layout alone when layout includes a
+// time, so no midnight is made up. When layout is empty, dates are formatted
+// as 2006-01-02 and times in RFC3339 format. An empty string is returned when
+// d has no start.
+func FormatNotionDate(d *na.DateObject, layout string) string {
+	if d == nil || d.Start == nil {
+		return ""
+	}
+	out := formatNotionTime(time.Time(*d.Start), layout)
+	if d.End != nil {
+		out += dateRangeSeparator + formatNotionTime(time.Time(*d.End), layout)
+	}
+	return out
+}
+
+// formatNotionTime returns t formatted with layout, as described in
+// FormatNotionDate.
+func formatNotionTime(t time.Time, layout string) string {
+	switch {
+	case layout == "" && isDateOnly(t):
+		return t.Format(frontmatterDateLayout)
+	case layout == "":
+		return t.Format(time.RFC3339)
+	case isDateOnly(t):
+		return t.Format(dateOnlyLayout(layout))
+	}
+	return t.Format(layout)
+}
+
+// isDateOnly returns whether t is a date without a time, which the notionapi
+// client parses as midnight UTC.
+func isDateOnly(t time.Time) bool {
+	return t.Equal(t.Truncate(24*time.Hour)) && t.Location() == time.UTC
+}
+
+// dateOnlyLayout returns layout with its time elements (e.g. "15:04" or
+// "3:04 PM MST") removed, for formatting dates without a time.
+func dateOnlyLayout(layout string) string {
+	dateOnly := strings.TrimSpace(timeLayoutPattern.ReplaceAllString(layout, ""))
+	if dateOnly == "" {
+		return frontmatterDateLayout
+	}
+	return dateOnly
+}
+
+// richTextToPlain returns the plain text content of rt, with no stylization.
+func richTextToPlain(rt []na.RichText) string {
+	var txt string
+	for _, t := range rt {
+		txt += t.PlainText
+	}
+	return txt
+}
+
+// nilIfEmpty returns nil when s is empty, so the property is omitted from
+// frontmatter.
+func nilIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
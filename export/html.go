@@ -0,0 +1,806 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	htmlHeadingOnePattern      = "<h1>%s</h1>"
+	htmlHeadingTwoPattern      = "<h2>%s</h2>"
+	htmlHeadingThreePattern    = "<h3>%s</h3>"
+	htmlAnchoredHeadingPattern = "<h%d id=\"%s\">%s</h%d>"
+	htmlParagraphPattern       = "<p>%s</p>"
+	htmlLinkPattern            = "<a href=\"%s\">%s</a>"
+	htmlBoldPattern            = "<strong>%s</strong>"
+	htmlItalicPattern          = "<em>%s</em>"
+	htmlStrikeThroughPattern   = "<del>%s</del>"
+	htmlInlineCodePattern      = "<code>%s</code>"
+	htmlUnderlinePattern       = "<u>%s</u>"
+	htmlColorPattern           = "<span style=\"color:%s\">%s</span>"
+	htmlBackgroundPattern      = "<span style=\"background-color:%s\">%s</span>"
+	htmlListItemPattern        = "<li>%s"
+	htmlOrderedListTypePattern = "<ol type=\"%s\">\n"
+	htmlTodoUncheckedPattern   = "<li><input type=\"checkbox\" disabled> %s"
+	htmlTodoCheckedPattern     = "<li><input type=\"checkbox\" disabled checked> %s"
+	htmlCodeBlockPattern       = "<pre><code class=\"language-%s\">%s</code></pre>"
+	htmlImagePattern           = "<img src=\"%s\" alt=\"%s\">"
+	htmlFigurePattern          = "<figure>%s<figcaption>%s</figcaption></figure>"
+	htmlTableCellPattern       = "<td>%s</td>"
+	htmlTableHeaderPattern     = "<th>%s</th>"
+	htmlTableRowPattern        = "<tr>%s</tr>"
+	htmlDividerPattern         = "<hr>"
+	htmlQuotePattern           = "<blockquote>%s</blockquote>"
+	htmlCalloutPattern         = "<blockquote class=\"callout\">%s</blockquote>"
+	htmlCalloutIconPattern     = "<img class=\"callout-icon\" src=\"%s\" alt=\"%s\">"
+	htmlEquationPattern        = "<div class=\"equation\">$$%s$$</div>"
+	htmlInlineEquationPattern  = "<span class=\"equation\">$%s$</span>"
+	htmlColumnListOpen         = "<div style=\"display:flex\">"
+	htmlColumnOpen             = "<div>"
+	htmlDivClose               = "</div>"
+	htmlToggleHeadingPattern   = "<details>\n<summary>%s</summary>"
+	htmlDetailsClose           = "</details>"
+	htmlUnsupportedPattern     = "<!-- unsupported: %s -->"
+	htmlCommentPattern         = "<!-- %s -->"
+
+	// htmlBackgroundColorSuffix is the suffix of Notion colors (e.g.
+	// red_background) that apply to the background of text.
+	htmlBackgroundColorSuffix = "_background"
+)
+
+var (
+	// htmlOrderedListTypes maps an OrderedListStyle to the type attribute of
+	// <ol> numbering items the same way. Styles HTML has no equivalent of,
+	// such as OrderedListStyleParen, are left out.
+	htmlOrderedListTypes = map[OrderedListStyle]string{
+		OrderedListStyleLowerAlpha: "a",
+		OrderedListStyleLowerRoman: "i",
+	}
+)
+
+// htmlGroup is an element that wraps a run of sibling blocks, such as the
+// <ul> around bulleted list items or the <table> around table rows.
+type htmlGroup struct {
+	blockType string
+	depth     int
+	open      string
+	close     string
+	// itemClose is emitted before each subsequent item in the group and when
+	// the group is closed. List items are left open so nested children are
+	// rendered inside of them.
+	itemClose string
+}
+
+// HTMLRenderer renders Notion blocks as an HTML fragment. Unlike MDRenderer,
+// it is stateful: Notion has no block for a list or table, only for its items
+// and rows, so HTMLRenderer tracks which grouping elements are open and
+// closes them as the block type and depth change.
+type HTMLRenderer struct {
+	groups []htmlGroup
+}
+
+// RenderPageHeader for HTMLRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, it
+// defaults to returning the title of the page in a <h1> element.
+func (h *HTMLRenderer) RenderPageHeader(page *na.Page,
+	o ...headerFooterOverride) string {
+
+	// a new page is starting; drop any state from a previous render.
+	h.groups = nil
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return fmt.Sprintf(htmlHeadingOnePattern, html.EscapeString(ResolveTitleInPage(page)))
+}
+
+// RenderPageFooter for HTMLRenderer closes any list or table elements still
+// open from the final blocks of the page. It then returns the results of a
+// client's custom pageOverrider definition, or nothing when one is not
+// provided.
+func (h *HTMLRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	footer := h.closeGroups(func(g htmlGroup) bool { return true })
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return footer + o[0](page)
+	}
+
+	return footer
+}
+
+// RenderPageHeader1 for HTMLRenderer wraps the Block's text in a <h1>
+// element. Toggle headings are wrapped in a <details> element, closed by
+// AddBlockEnd, so their children collapse beneath them. If an override is
+// provided, that function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return wrapToggleHeading(b, renderHTMLHeading(1, htmlHeadingOnePattern, b))
+}
+
+// RenderPageHeader2 for HTMLRenderer wraps the Block's text in a <h2>
+// element. Toggle headings are wrapped in a <details> element, closed by
+// AddBlockEnd, so their children collapse beneath them. If an override is
+// provided, that function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return wrapToggleHeading(b, renderHTMLHeading(2, htmlHeadingTwoPattern, b))
+}
+
+// RenderPageHeader3 for HTMLRenderer wraps the Block's text in a <h3>
+// element. Toggle headings are wrapped in a <details> element, closed by
+// AddBlockEnd, so their children collapse beneath them. If an override is
+// provided, that function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return wrapToggleHeading(b, renderHTMLHeading(3, htmlHeadingThreePattern, b))
+}
+
+// RenderUnsupported for HTMLRenderer returns an HTML comment naming the type
+// of the block, e.g. <!-- unsupported: breadcrumb -->, when
+// RenderOptions.MarkUnsupportedBlocks is set. Otherwise, nothing is returned.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (h *HTMLRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).MarkUnsupportedBlocks {
+		return ""
+	}
+	return fmt.Sprintf(htmlUnsupportedPattern, b.BlockRef.GetType())
+}
+
+// RenderComment for HTMLRenderer returns text as an HTML comment.
+func (h *HTMLRenderer) RenderComment(text string) string {
+	return fmt.Sprintf(htmlCommentPattern, text)
+}
+
+// RenderParagraph for HTMLRenderer wraps the Block's text in a <p> element.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (h *HTMLRenderer) RenderParagraph(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(htmlParagraphPattern, b.Text)
+}
+
+// RenderDivider for HTMLRenderer returns a <hr> element. If an override is
+// provided, that function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return htmlDividerPattern
+}
+
+// RenderNumberedList for HTMLRenderer returns the Block's text in an
+// (unclosed) <li> element. The surrounding <ol> is added by AddPadding. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (h *HTMLRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(htmlListItemPattern, b.Text)
+}
+
+// RenderBulletedList for HTMLRenderer returns the Block's text in an
+// (unclosed) <li> element. The surrounding <ul> is added by AddPadding. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (h *HTMLRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(htmlListItemPattern, b.Text)
+}
+
+// RenderTableRow for HTMLRenderer returns a <tr> element. Unlike markdown,
+// HTML supports both row and column headers, so cells in a header row or
+// header column are rendered as <th>. The surrounding <table> is added by
+// AddPadding.
+func (h *HTMLRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
+	// when a rowOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](cells)
+	}
+
+	var row string
+	for _, c := range cells {
+		if c.isRowHeader || c.isColumnHeader {
+			row += fmt.Sprintf(htmlTableHeaderPattern, c.rowTxt)
+			continue
+		}
+		row += fmt.Sprintf(htmlTableCellPattern, c.rowTxt)
+	}
+	return fmt.Sprintf(htmlTableRowPattern, row)
+}
+
+// RenderTodoList for HTMLRenderer returns the Block's text in an (unclosed)
+// <li> element prefixed with a disabled checkbox. If an override is
+// provided, that function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a ToDoBlock (e.g. passed from a custom override
+	// pipeline), there is no checked state to read, so render it unchecked.
+	tb, ok := b.BlockRef.(*na.ToDoBlock)
+	if ok && tb.ToDo.Checked {
+		return fmt.Sprintf(htmlTodoCheckedPattern, b.Text)
+	}
+	return fmt.Sprintf(htmlTodoUncheckedPattern, b.Text)
+}
+
+func (h *HTMLRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	txt := b.Text
+	switch emoji, src := resolveCalloutIcon(b); {
+	case emoji != "":
+		txt = emoji + " " + txt
+	case src != "":
+		icon := fmt.Sprintf(htmlCalloutIconPattern, html.EscapeString(src), calloutIconAltText)
+		txt = icon + " " + txt
+	}
+	return fmt.Sprintf(htmlCalloutPattern, txt)
+}
+
+func (h *HTMLRenderer) RenderQuote(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(htmlQuotePattern, b.Text)
+}
+
+func (h *HTMLRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.BlockRef.GetType() != "image" {
+		return "", fmt.Errorf("RenderImage was passed a %s but expected an ImageBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	ib := b.BlockRef.(*na.ImageBlock)
+
+	var src string
+	switch {
+	// image was not uploaded to Notion, but is referenced from an
+	// external URL.
+	case ib.Image.External != nil:
+		src = ib.Image.External.URL
+	// image has no source. Rather than emitting an empty image reference,
+	// fail the render so the malformed block is surfaced.
+	case ib.Image.File == nil:
+		return "", errImageWithoutSource(ib)
+	// image was uploaded to Notion, need to download to local
+	// filesystem.
+	default:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
+		if err != nil {
+			return "", err
+		}
+		src = filePath
+	}
+
+	img := fmt.Sprintf(htmlImagePattern, html.EscapeString(src),
+		html.EscapeString(resolveImageAltText(ib)))
+	if config.CaptionImages && b.Text != "" {
+		return fmt.Sprintf(htmlFigurePattern, img, b.Text), nil
+	}
+
+	return img, nil
+}
+
+// RenderFile for HTMLRenderer returns a link to the file, downloading files
+// hosted in Notion. The caption is used as the link text when present,
+// otherwise the name of the file is used.
+func (h *HTMLRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderFile was passed a %s but expected a FileBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveFileBlockPath(fb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = html.EscapeString(resolveFileName(filePath))
+	}
+	return fmt.Sprintf(htmlParagraphPattern,
+		fmt.Sprintf(htmlLinkPattern, html.EscapeString(filePath), linkTxt)), nil
+}
+
+func (h *HTMLRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a BookmarkBlock (e.g. passed from a custom
+	// override pipeline), there is no URL to link, so return the text as is.
+	bb, ok := b.BlockRef.(*na.BookmarkBlock)
+	if !ok {
+		return b.Text
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = html.EscapeString(bb.Bookmark.URL)
+	}
+	return fmt.Sprintf(htmlParagraphPattern,
+		fmt.Sprintf(htmlLinkPattern, html.EscapeString(bb.Bookmark.URL), linkTxt))
+}
+
+// RenderChildPage for HTMLRenderer returns a paragraph containing a link to the
+// file the subpage was exported to, using the subpage's title as the link
+// text. If an override is provided, that function is run and returned value is
+// used instead.
+func (h *HTMLRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fileName := html.EscapeString(ResolveChildPageFile(b))
+	linkTxt := html.EscapeString(b.Text)
+	if linkTxt == "" {
+		linkTxt = fileName
+	}
+	return fmt.Sprintf(htmlParagraphPattern, fmt.Sprintf(htmlLinkPattern, fileName, linkTxt))
+}
+
+// RenderChildDatabase for HTMLRenderer returns a paragraph containing a link
+// to the database in Notion, using the database's title as the link text. If
+// an override is provided, that function is run and returned value is used
+// instead.
+func (h *HTMLRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	url := html.EscapeString(ResolveChildDatabaseURL(b))
+	linkTxt := html.EscapeString(b.Text)
+	if linkTxt == "" {
+		linkTxt = url
+	}
+	return fmt.Sprintf(htmlParagraphPattern, fmt.Sprintf(htmlLinkPattern, url, linkTxt))
+}
+
+// RenderLinkToPage for HTMLRenderer returns a paragraph containing a link to
+// the linked page, using its title as the link text, or the target of the
+// link when the title is unknown. If an override is provided, that function
+// is run and returned value is used instead.
+func (h *HTMLRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	target := html.EscapeString(ResolveLinkToPageTarget(b))
+	linkTxt := html.EscapeString(b.Text)
+	if linkTxt == "" {
+		linkTxt = target
+	}
+	return fmt.Sprintf(htmlParagraphPattern, fmt.Sprintf(htmlLinkPattern, target, linkTxt))
+}
+
+// RenderVideo for HTMLRenderer returns a paragraph containing a link to the
+// video. Videos hosted in Notion are downloaded and linked to locally. When
+// RenderOptions.EmbedVideos is set, the video is embedded instead, with an
+// <iframe> for known providers (e.g. YouTube) and a <video> element for
+// downloaded videos. If an override is provided, that function is run and
+// returned value is used instead.
+func (h *HTMLRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderVideo was passed a %s but expected a VideoBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveVideoBlockPath(vb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+	if config.EmbedVideos {
+		if embed, ok := resolveVideoEmbed(vb, filePath); ok {
+			return embed, nil
+		}
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = html.EscapeString(filePath)
+	}
+	return fmt.Sprintf(htmlParagraphPattern,
+		fmt.Sprintf(htmlLinkPattern, html.EscapeString(filePath), linkTxt)), nil
+}
+
+// RenderAudio for HTMLRenderer returns an <audio> element playing the audio,
+// followed by its caption, when present, in a paragraph. Audio hosted in
+// Notion is downloaded and played locally. If an override is provided, that
+// function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	audio := fmt.Sprintf(htmlAudioPattern, html.EscapeString(filePath))
+	if b.Text == "" {
+		return audio, nil
+	}
+	return audio + "\n" + fmt.Sprintf(htmlParagraphPattern, b.Text), nil
+}
+
+// RenderColumnList for HTMLRenderer opens a flexbox <div> when
+// RenderOptions.HTMLColumns is set, so the columns within it render side by
+// side. Otherwise, nothing is returned. If an override is provided, that
+// function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).HTMLColumns {
+		return ""
+	}
+	return htmlColumnListOpen
+}
+
+// RenderColumn for HTMLRenderer opens a <div> for the column's content when
+// RenderOptions.HTMLColumns is set. Otherwise, nothing is returned. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (h *HTMLRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).HTMLColumns {
+		return ""
+	}
+	return htmlColumnOpen
+}
+
+// RenderTemplate for HTMLRenderer returns the label of a template button as a
+// bold paragraph, introducing the template's content, which is rendered after
+// it. An empty label returns nothing. If an override is provided, that
+// function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.Text == "" {
+		return ""
+	}
+	return fmt.Sprintf(htmlParagraphPattern, fmt.Sprintf(htmlBoldPattern, b.Text))
+}
+
+// RenderEquation for HTMLRenderer wraps the LaTeX expression in "$$" within a
+// <div>, which can be typeset by MathJax or KaTeX. If an override is
+// provided, that function is run and returned value is used instead.
+func (h *HTMLRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(htmlEquationPattern, html.EscapeString(b.Text))
+}
+
+func (h *HTMLRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a CodeBlock (e.g. passed from a custom override
+	// pipeline), there is no language to read, so leave it unspecified.
+	var language string
+	if cb, ok := b.BlockRef.(*na.CodeBlock); ok {
+		language = ResolveLanguageForCodeBlock(cb.Code.Language,
+			resolveRenderConfig(b.Opts...).LanguageOverrides)
+	}
+
+	code := fmt.Sprintf(htmlCodeBlockPattern, html.EscapeString(language), b.Text)
+
+	// the caption is emitted as an italic paragraph below the code block.
+	if caption := ResolveCodeCaption(b); caption != "" {
+		code += "\n" + fmt.Sprintf(htmlParagraphPattern, fmt.Sprintf(htmlItalicPattern, caption))
+	}
+
+	return code
+}
+
+// RenderText takes the RichText object from the Notion API and converts it to
+// HTML, escaping any special characters in the content.
+func (h *HTMLRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](rt)
+	}
+
+	var parsed string
+	for _, t := range rt {
+		var content string
+		switch t.Type {
+		// text is an inline equation. The Notion API sets the plain text of an
+		// equation to its LaTeX expression.
+		case "equation":
+			content = fmt.Sprintf(htmlInlineEquationPattern, html.EscapeString(t.PlainText))
+
+		// text is a mention of a page, database, user, or date.
+		case "mention":
+			content = html.EscapeString(resolveMentionText(t))
+
+		default:
+			content = html.EscapeString(t.Text.Content)
+		}
+
+		// unlike markdown, HTML can represent every annotation, so each one
+		// applied to the text wraps it, with the link outermost.
+		address := resolveLinkURL(t)
+		if address == "" {
+			parsed += renderHTMLAnnotations(content, t.Annotations)
+			continue
+		}
+		leading, content, trailing := splitSurroundingSpace(content)
+		if content != "" {
+			content = fmt.Sprintf(htmlLinkPattern, html.EscapeString(address),
+				renderHTMLAnnotations(content, t.Annotations))
+		}
+		parsed += leading + content + trailing
+	}
+
+	return parsed
+}
+
+// renderHTMLAnnotations returns content wrapped in an element for each of the
+// annotations applied to it. Text colors are set with a <span>, using the
+// Notion color's name (e.g. red) as the CSS color; background colors (e.g.
+// red_background) set the background-color instead.
+func renderHTMLAnnotations(content string, a *na.Annotations) string {
+	if a == nil {
+		return content
+	}
+	if a.Code {
+		content = fmt.Sprintf(htmlInlineCodePattern, content)
+	}
+	if a.Strikethrough {
+		content = fmt.Sprintf(htmlStrikeThroughPattern, content)
+	}
+	if a.Underline {
+		content = fmt.Sprintf(htmlUnderlinePattern, content)
+	}
+	if a.Italic {
+		content = fmt.Sprintf(htmlItalicPattern, content)
+	}
+	if a.Bold {
+		content = fmt.Sprintf(htmlBoldPattern, content)
+	}
+	switch color := string(a.Color); {
+	case color == "" || color == string(na.ColorDefault):
+	case strings.HasSuffix(color, htmlBackgroundColorSuffix):
+		content = fmt.Sprintf(htmlBackgroundPattern,
+			html.EscapeString(strings.TrimSuffix(color, htmlBackgroundColorSuffix)), content)
+	default:
+		content = fmt.Sprintf(htmlColorPattern, html.EscapeString(color), content)
+	}
+
+	return content
+}
+
+// AddPadding for HTMLRenderer does not indent blocks, as whitespace is
+// insignificant in HTML (and significant in <pre>). Instead, it is where
+// grouping elements are opened and closed, as it's called for every block
+// with both its type and depth. Before returning the block's text, any list
+// or table that the block does not belong to is closed and, when the block is
+// the first item of a list or table, the grouping element is opened.
+func (h *HTMLRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// the numbering of lists follows RenderOptions.OrderedListStyle, where
+	// HTML has an equivalent.
+	listType := htmlOrderedListTypes[resolveRenderConfig(b.Opts...).OrderedListStyle]
+	return h.addGroups(b, func(blockType string, depth int) (htmlGroup, bool) {
+		g, ok := newHTMLGroup(blockType, depth)
+		if blockType == "numbered_list_item" && listType != "" {
+			g.open = fmt.Sprintf(htmlOrderedListTypePattern, listType)
+		}
+		return g, ok
+	})
+}
+
+// addGroups returns the Block's text preceded by the markup closing any group
+// it doesn't belong to and, when it's the first item of a group, opening the
+// group returned by newGroup.
+func (h *HTMLRenderer) addGroups(b *Block,
+	newGroup func(blockType string, depth int) (htmlGroup, bool)) string {
+
+	blockType := string(b.BlockRef.GetType())
+	group, isGroupItem := newGroup(blockType, b.Depth)
+
+	// close groups that are deeper than this block, or at the same depth but
+	// of a different type. Groups at a lower depth remain open as this block
+	// is nested inside of them.
+	out := h.closeGroups(func(g htmlGroup) bool {
+		return g.depth > b.Depth ||
+			(g.depth == b.Depth && g.blockType != blockType)
+	})
+
+	if isGroupItem {
+		if len(h.groups) > 0 && h.groups[len(h.groups)-1].depth == b.Depth {
+			// another item in an already open group
+			out += h.groups[len(h.groups)-1].itemClose
+		} else {
+			out += group.open
+			h.groups = append(h.groups, group)
+		}
+	}
+
+	return out + b.Text
+}
+
+// AddBlockEnd for HTMLRenderer closes the <div> opened for column_list and
+// column blocks when RenderOptions.HTMLColumns is set, and the <details>
+// opened for toggle headings. As their content is at the same depth as the
+// block, groups left open by that content (e.g. a trailing list) are closed
+// first so they don't span the closing tag. Nothing is returned for other
+// blocks.
+func (h *HTMLRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	switch b.BlockRef.GetType() {
+	case "column_list", "column":
+		if !resolveRenderConfig(b.Opts...).HTMLColumns {
+			return ""
+		}
+		return "\n" + h.closeGroups(func(g htmlGroup) bool { return g.depth >= b.Depth }) +
+			htmlDivClose
+	case "heading_1", "heading_2", "heading_3":
+		return "\n" + h.closeGroups(func(g htmlGroup) bool { return g.depth >= b.Depth }) +
+			htmlDetailsClose
+	}
+
+	return ""
+}
+
+// wrapToggleHeading returns heading, the rendered heading in b, opening a
+// <details> element with heading as its summary when b is a toggle heading.
+// Only toggle headings can have children.
+func wrapToggleHeading(b *Block, heading string) string {
+	if b.BlockRef == nil || !b.BlockRef.GetHasChildren() {
+		return heading
+	}
+	return fmt.Sprintf(htmlToggleHeadingPattern, heading)
+}
+
+// renderHTMLHeading returns the Block's text formatted with pattern, the
+// heading element of level. When the heading has an anchor, it's set as the
+// element's id.
+func renderHTMLHeading(level int, pattern string, b *Block) string {
+	if anchor := ResolveHeadingAnchor(b); anchor != "" {
+		return fmt.Sprintf(htmlAnchoredHeadingPattern, level, html.EscapeString(anchor), b.Text, level)
+	}
+	return fmt.Sprintf(pattern, b.Text)
+}
+
+// AddSectionSeperation for HTMLRenderer adds a single line break between
+// rendered blocks.
+func (h *HTMLRenderer) AddSectionSeperation(previousType string, currentType string, o ...seperationOverride) string {
+	// when a seperationOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](previousType, currentType)
+	}
+
+	return "\n"
+}
+
+// closeGroups pops open groups off the top of the stack while shouldClose
+// returns true for them. It returns the closing markup for every group
+// popped.
+func (h *HTMLRenderer) closeGroups(shouldClose func(htmlGroup) bool) string {
+	var out string
+	for len(h.groups) > 0 {
+		g := h.groups[len(h.groups)-1]
+		if !shouldClose(g) {
+			break
+		}
+		out += g.itemClose + g.close
+		h.groups = h.groups[:len(h.groups)-1]
+	}
+	return out
+}
+
+// newHTMLGroup returns the grouping element for a block type. The returned
+// bool is false when blocks of this type are not rendered within a group.
+func newHTMLGroup(blockType string, depth int) (htmlGroup, bool) {
+	g := htmlGroup{blockType: blockType, depth: depth}
+	switch blockType {
+	case "bulleted_list_item", "to_do":
+		g.open, g.close, g.itemClose = "<ul>\n", "</ul>\n", "</li>\n"
+	case "numbered_list_item":
+		g.open, g.close, g.itemClose = "<ol>\n", "</ol>\n", "</li>\n"
+	case "table_row":
+		g.open, g.close = "<table>\n", "</table>\n"
+	default:
+		return g, false
+	}
+	return g, true
+}
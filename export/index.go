@@ -0,0 +1,117 @@
+package export
+
+// This file contains the logic used to write an index of the pages of a
+// recursive export, giving the files of the export an entry point.
+
+import (
+	"context"
+	"io"
+	"sort"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	// indexFileName is the name, without extension, of the file in
+	// RenderOptions.PagesDir the index added by RenderOptions.WriteIndex is
+	// written to.
+	indexFileName = "index"
+	// indexBlockIDPrefix prefixes the IDs of the list items in an index, so
+	// their children aren't mistaken for the blocks of the pages they link
+	// to.
+	indexBlockIDPrefix = "index-"
+)
+
+// RenderIndex writes an index titled title to w, rendered with the
+// exporter's Renderer, linking to the Path of every page in m as a bulleted
+// list. Each page is nested beneath the page containing it, while pages
+// whose parent isn't in m, such as the subpages of the root page of a
+// recursive export, are listed at the top level. Pages beside each other are
+// ordered by title. The links are relative to the directory m was written
+// to, where the index should be written too. Nothing is retrieved from the
+// Notion API. Page headers and footers are rendered as with ExportDatabase.
+func (e *exporter) RenderIndex(w io.Writer, title string, m Manifest, opts ...RenderOptions) error {
+	config := resolveRenderConfig(opts...)
+	// the index isn't a page in Notion, so it has no properties, blocks, or
+	// source to record.
+	config.Frontmatter = false
+	config.EmbedBlockIDs = false
+	config.FooterSourceLink = false
+	config.FooterTimestamp = false
+	// the nested items are cached as the children of their parents, where
+	// rendering looks for them before retrieving them from Notion.
+	config.pages = newPageExportState("")
+
+	page := &na.Page{
+		Object: na.ObjectTypePage,
+		Properties: na.Properties{
+			"title": &na.TitleProperty{Type: na.PropertyTypeTitle, Title: plainRichText(title)},
+		},
+	}
+	config.originalPageRef = page
+
+	e.w = w
+	headerOverride := config.Overrides.PageHeader
+	if config.OmitPageHeader {
+		headerOverride = func(*na.Page) string { return "" }
+	}
+	err := e.write(e.Renderer.RenderPageHeader(page, headerOverride))
+	if err != nil {
+		return err
+	}
+	blocks := &na.GetChildrenResponse{Results: indexBlocks(m, "", config.pages)}
+	_, err = e.renderBlocks(context.Background(), indexFileName, blocks, config)
+	if err != nil {
+		return err
+	}
+	return e.write(e.Renderer.RenderPageFooter(page, e.resolveFooterOverride(page, config)))
+}
+
+// indexBlocks returns a bulleted list item linking to every page in m whose
+// parent is parent or, when parent is empty, isn't in m. The items of the
+// pages nested beneath each page are cached in s as its children.
+func indexBlocks(m Manifest, parent string, s *pageExportState) []na.Block {
+	var ids []string
+	for id, p := range m.Pages {
+		// pages whose parent isn't in m are listed at the top level.
+		pageParent := p.Parent
+		if _, ok := m.Pages[pageParent]; !ok {
+			pageParent = ""
+		}
+		if pageParent == parent {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := m.Pages[ids[i]].Title, m.Pages[ids[j]].Title
+		if ti != tj {
+			return ti < tj
+		}
+		return m.Pages[ids[i]].Path < m.Pages[ids[j]].Path
+	})
+
+	blocks := make([]na.Block, 0, len(ids))
+	for _, id := range ids {
+		p := m.Pages[id]
+		title := p.Title
+		if title == "" {
+			title = untitledPageSlug
+		}
+		link := plainRichText(title)
+		link[0].Href = p.Path
+		item := &na.BulletedListItemBlock{
+			BasicBlock: na.BasicBlock{
+				Object: na.ObjectTypeBlock,
+				ID:     na.BlockID(indexBlockIDPrefix + id),
+				Type:   na.BlockTypeBulletedListItem,
+			},
+			BulletedListItem: na.ListItem{RichText: link},
+		}
+		if children := indexBlocks(m, id, s); len(children) > 0 {
+			item.HasChildren = true
+			s.cacheChildren(string(item.ID), "", &na.GetChildrenResponse{Results: children})
+		}
+		blocks = append(blocks, item)
+	}
+	return blocks
+}
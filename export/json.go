@@ -0,0 +1,467 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+// JSONDocument is the top-level object emitted by JSONRenderer. Its schema is:
+//
+//	{
+//	  "title": "page title",
+//	  "frontmatter": { "tags": ["a", "b"], ... },
+//	  "blocks": [ JSONBlock, ... ]
+//	}
+//
+// Blocks are listed in the order they appear on the page. Nested blocks (e.g.
+// a list item indented under another) follow their parent and carry a
+// greater depth, mirroring how they're sent to every other Renderer.
+type JSONDocument struct {
+	Title string `json:"title"`
+	// Frontmatter holds the values of the page's properties, keyed as in the
+	// frontmatter of other formats (see RenderFrontmatter). It's set when
+	// RenderOptions.Frontmatter is set.
+	Frontmatter map[string]interface{} `json:"frontmatter,omitempty"`
+	// Source is the URL of the page in Notion, set when
+	// RenderOptions.FooterSourceLink is set.
+	Source string `json:"source,omitempty"`
+	// Exported is the time the page was exported, set when
+	// RenderOptions.FooterTimestamp is set.
+	Exported string      `json:"exported,omitempty"`
+	Blocks   []JSONBlock `json:"blocks"`
+}
+
+// JSONBlock is a single rendered Notion block. Its schema is:
+//
+//	{
+//	  "id": "notion block id",
+//	  "type": "notion block type, e.g. paragraph or heading_1",
+//	  "text": "plain text content of the block, with no markup",
+//	  "depth": 0,
+//	  "checked": true,           // to_do only
+//	  "language": "go",          // code only
+//	  "url": "images/bmo.png",   // image, file, video, bookmark, child_page,
+//	                             // child_database, and link_to_page only
+//	  "cells": ["a", "b"],       // table_row only
+//	  "header": true,            // table_row only, when the row is a header
+//	  "caption": "main.go",      // code only
+//	  "icon": "💡",               // callout only, an emoji or image location
+//	  "anchor": "setup"          // headings only, when HeadingAnchors is set
+//	}
+//
+// Type-specific fields are omitted for blocks of other types. For image, file,
+// video, and bookmark blocks, text is the block's caption.
+type JSONBlock struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"`
+	Text     string   `json:"text"`
+	Depth    int      `json:"depth"`
+	Checked  *bool    `json:"checked,omitempty"`
+	Language string   `json:"language,omitempty"`
+	URL      string   `json:"url,omitempty"`
+	Cells    []string `json:"cells,omitempty"`
+	Header   bool     `json:"header,omitempty"`
+	Caption  string   `json:"caption,omitempty"`
+	Icon     string   `json:"icon,omitempty"`
+	Anchor   string   `json:"anchor,omitempty"`
+}
+
+// JSONRenderer renders a Notion page as a JSONDocument. As a JSON document
+// can't be composed from independently rendered strings, JSONRenderer
+// accumulates a JSONBlock for each Render call, returning an empty string,
+// and flushes the entire document from RenderPageFooter.
+type JSONRenderer struct {
+	doc JSONDocument
+}
+
+// RenderPageHeader for JSONRenderer resets the document being accumulated. It
+// returns the results of a client's custom pageOverrider definition, or
+// nothing when one is not provided.
+func (j *JSONRenderer) RenderPageHeader(page *na.Page,
+	o ...headerFooterOverride) string {
+
+	// a new page is starting; drop any state from a previous render.
+	j.doc = JSONDocument{Blocks: []JSONBlock{}}
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return ""
+}
+
+// RenderPageFooter for JSONRenderer returns the accumulated JSONDocument
+// marshalled as indented JSON. When a pageOverrider is provided, its results
+// are appended after the document.
+func (j *JSONRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	j.doc.Title = ResolveTitleInPage(page)
+	if j.doc.Blocks == nil {
+		j.doc.Blocks = []JSONBlock{}
+	}
+	// encoding can not fail as JSONDocument only contains strings, numbers,
+	// bools, and lists and maps of them. HTML escaping is disabled as the
+	// output isn't embedded in HTML.
+	var out strings.Builder
+	enc := json.NewEncoder(&out)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(j.doc)
+	doc := strings.TrimSuffix(out.String(), "\n")
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return doc + o[0](page)
+	}
+
+	return doc
+}
+
+// SetFrontmatter for JSONRenderer records fields as the document's
+// Frontmatter.
+func (j *JSONRenderer) SetFrontmatter(fields map[string]interface{}) {
+	j.doc.Frontmatter = fields
+}
+
+// SetProvenance for JSONRenderer records source and exported as the
+// document's Source and Exported.
+func (j *JSONRenderer) SetProvenance(source, exported string) {
+	j.doc.Source = source
+	j.doc.Exported = exported
+}
+
+func (j *JSONRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{Anchor: ResolveHeadingAnchor(b)}, o...)
+}
+
+func (j *JSONRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{Anchor: ResolveHeadingAnchor(b)}, o...)
+}
+
+func (j *JSONRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{Anchor: ResolveHeadingAnchor(b)}, o...)
+}
+
+func (j *JSONRenderer) RenderParagraph(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+func (j *JSONRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+func (j *JSONRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+func (j *JSONRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+func (j *JSONRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	var fields JSONBlock
+	if emoji, src := resolveCalloutIcon(b); emoji != "" {
+		fields.Icon = emoji
+	} else {
+		fields.Icon = src
+	}
+	return j.addBlock(b, fields, o...)
+}
+
+func (j *JSONRenderer) RenderQuote(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+func (j *JSONRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+func (j *JSONRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	var fields JSONBlock
+	if tb, ok := b.BlockRef.(*na.ToDoBlock); ok {
+		checked := tb.ToDo.Checked
+		fields.Checked = &checked
+	}
+	return j.addBlock(b, fields, o...)
+}
+
+func (j *JSONRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	var fields JSONBlock
+	if cb, ok := b.BlockRef.(*na.CodeBlock); ok {
+		fields.Language = ResolveLanguageForCodeBlock(cb.Code.Language,
+			resolveRenderConfig(b.Opts...).LanguageOverrides)
+		fields.Caption = ResolveCodeCaption(b)
+	}
+	return j.addBlock(b, fields, o...)
+}
+
+func (j *JSONRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	var fields JSONBlock
+	if bb, ok := b.BlockRef.(*na.BookmarkBlock); ok {
+		fields.URL = bb.Bookmark.URL
+	}
+	return j.addBlock(b, fields, o...)
+}
+
+// RenderChildPage for JSONRenderer records the subpage's title as its text and
+// the file it was exported to as its URL.
+func (j *JSONRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{URL: ResolveChildPageFile(b)}, o...)
+}
+
+// RenderChildDatabase for JSONRenderer records the database's title as its
+// text and its URL in Notion.
+func (j *JSONRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{URL: ResolveChildDatabaseURL(b)}, o...)
+}
+
+// RenderLinkToPage for JSONRenderer records the linked page's title as its
+// text and the target of the link.
+func (j *JSONRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{URL: ResolveLinkToPageTarget(b)}, o...)
+}
+
+// RenderColumnList for JSONRenderer records nothing, as the content of every
+// column is recorded sequentially.
+func (j *JSONRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderColumn for JSONRenderer records nothing, as the column's content is
+// recorded sequentially.
+func (j *JSONRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderTemplate for JSONRenderer records the label of a template button. The
+// template's content is recorded after it.
+func (j *JSONRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+// RenderImage for JSONRenderer records the image's URL. For images hosted in
+// Notion, the image is downloaded and the URL is its path on the local
+// filesystem.
+func (j *JSONRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ib, ok := b.BlockRef.(*na.ImageBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderImage was passed a %s but expected an ImageBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	fields := JSONBlock{}
+	switch {
+	case ib.Image.External != nil:
+		fields.URL = ib.Image.External.URL
+	case ib.Image.File != nil:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
+		if err != nil {
+			return "", err
+		}
+		fields.URL = filePath
+	default:
+		return "", errImageWithoutSource(ib)
+	}
+
+	return j.addBlock(b, fields), nil
+}
+
+// RenderFile for JSONRenderer records the file's URL. For files hosted in
+// Notion, the file is downloaded and the URL is its path on the local
+// filesystem.
+func (j *JSONRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderFile was passed a %s but expected a FileBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveFileBlockPath(fb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	return j.addBlock(b, JSONBlock{URL: filePath}), nil
+}
+
+// RenderVideo for JSONRenderer records the video's URL. For videos hosted in
+// Notion, the video is downloaded and the URL is its path on the local
+// filesystem.
+func (j *JSONRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderVideo was passed a %s but expected a VideoBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveVideoBlockPath(vb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	return j.addBlock(b, JSONBlock{URL: filePath}), nil
+}
+
+// RenderAudio for JSONRenderer records the audio's URL. For audio hosted in
+// Notion, the audio is downloaded and the URL is its path on the local
+// filesystem.
+func (j *JSONRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	return j.addBlock(b, JSONBlock{URL: filePath}), nil
+}
+
+// RenderTableRow for JSONRenderer records the text of each cell in the row.
+func (j *JSONRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
+	// when a rowOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](cells)
+	}
+
+	row := JSONBlock{Type: "table_row", Cells: []string{}}
+	for _, c := range cells {
+		row.Cells = append(row.Cells, c.rowTxt)
+		row.Header = row.Header || c.isRowHeader
+	}
+	j.doc.Blocks = append(j.doc.Blocks, row)
+
+	return ""
+}
+
+// RenderText for JSONRenderer returns the plain text content of the RichText,
+// with no stylization.
+func (j *JSONRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](rt)
+	}
+
+	var parsed string
+	for _, t := range rt {
+		switch t.Type {
+		case "equation":
+			parsed += t.PlainText
+		case "mention":
+			parsed += resolveMentionText(t)
+		default:
+			parsed += t.Text.Content
+		}
+	}
+
+	return parsed
+}
+
+// AddPadding for JSONRenderer does not pad text, as depth is a field of each
+// JSONBlock. It's called after every block is rendered with that block's
+// depth, so it is used to record the ID and depth of the block just added.
+func (j *JSONRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if len(j.doc.Blocks) > 0 && b.BlockRef != nil {
+		last := &j.doc.Blocks[len(j.doc.Blocks)-1]
+		if last.ID == "" || last.ID == string(b.BlockRef.GetID()) {
+			last.ID = string(b.BlockRef.GetID())
+			last.Depth = b.Depth
+		}
+	}
+
+	return b.Text
+}
+
+// AddBlockEnd for JSONRenderer returns nothing, as blocks are closed when the
+// document is marshalled.
+func (j *JSONRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// AddSectionSeperation for JSONRenderer returns nothing, as blocks are
+// separated when the document is marshalled.
+func (j *JSONRenderer) AddSectionSeperation(previousType string, currentType string,
+	o ...seperationOverride) string {
+	// when a seperationOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](previousType, currentType)
+	}
+
+	return ""
+}
+
+// RenderUnsupported for JSONRenderer records a JSONBlock with the block's ID
+// and type, but no content, when RenderOptions.MarkUnsupportedBlocks is set.
+// Otherwise, the block is left out of the document.
+func (j *JSONRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	if len(o) < 1 || o[0] == nil {
+		if !resolveRenderConfig(b.Opts...).MarkUnsupportedBlocks {
+			return ""
+		}
+	}
+	return j.addBlock(b, JSONBlock{}, o...)
+}
+
+// addBlock records a JSONBlock for b, merging in any type-specific fields
+// already set on fields. When an override function is passed, the block is
+// not recorded and the override's output is returned instead.
+func (j *JSONRenderer) addBlock(b *Block, fields JSONBlock, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fields.ID = string(b.BlockRef.GetID())
+	fields.Type = string(b.BlockRef.GetType())
+	fields.Text = b.Text
+	fields.Depth = b.Depth
+	j.doc.Blocks = append(j.doc.Blocks, fields)
+
+	return ""
+}
@@ -0,0 +1,82 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	checked := true
+	out, err := newTestExporter(t, "json", samplePage()).Render("sample")
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+
+	var doc JSONDocument
+	dec := json.NewDecoder(bytes.NewReader(out))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&doc); err != nil {
+		t.Fatalf("Render() doesn't match JSONDocument, error: %s\n%s", err, out)
+	}
+	// the document is encoded the same way JSONRenderer encodes it.
+	again := &bytes.Buffer{}
+	enc := json.NewEncoder(again)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(out), bytes.TrimSpace(again.Bytes())) {
+		t.Errorf("JSONDocument changed in a round trip, got:\n%s\nwant:\n%s", again, out)
+	}
+	if doc.Title != "Sample Page" {
+		t.Errorf("Title = %q, want %q", doc.Title, "Sample Page")
+	}
+
+	blocks := map[string]JSONBlock{}
+	for _, b := range doc.Blocks {
+		blocks[b.ID] = b
+	}
+	tests := []struct {
+		name string
+		want JSONBlock
+	}{
+		{
+			name: "heading",
+			want: JSONBlock{ID: "h1", Type: "heading_1", Text: "Introduction"},
+		},
+		{
+			name: "text without markup",
+			want: JSONBlock{ID: "p2", Type: "paragraph", Text: "See the docs for more."},
+		},
+		{
+			name: "nested list item",
+			want: JSONBlock{ID: "l1a", Type: "bulleted_list_item", Text: "Nested", Depth: 1},
+		},
+		{
+			name: "to-do",
+			want: JSONBlock{ID: "t1", Type: "to_do", Text: "Done", Checked: &checked},
+		},
+		{
+			name: "code",
+			want: JSONBlock{ID: "c1", Type: "code", Text: "echo \"hi\"\necho bye", Language: "shell"},
+		},
+		{
+			name: "table row",
+			want: JSONBlock{ID: "r2", Type: "table_row", Cells: []string{"a", "1"}},
+		},
+		{
+			name: "image",
+			want: JSONBlock{ID: "i1", Type: "image", Text: "A cat", URL: "https://example.com/cat.jpg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := blocks[tt.want.ID]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("block %s = %+v, want %+v", tt.want.ID, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,783 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	latexTitlePattern          = "\\title{%s}\n\\maketitle"
+	latexLabelPattern          = "\\label{%s}"
+	latexLinkPattern           = "\\href{%s}{%s}"
+	latexURLPattern            = "\\url{%s}"
+	latexBoldPattern           = "\\textbf{%s}"
+	latexItalicPattern         = "\\emph{%s}"
+	latexUnderlinePattern      = "\\underline{%s}"
+	latexStrikeThroughPattern  = "\\sout{%s}"
+	latexInlineCodePattern     = "\\texttt{%s}"
+	latexItemPattern           = "\\item %s"
+	latexLabeledItemPattern    = "\\item[%s] %s"
+	latexTodoUncheckedMarker   = "{[ ]}"
+	latexTodoCheckedMarker     = "{[x]}"
+	latexQuotePattern          = "\\begin{quote}\n%s\n\\end{quote}"
+	latexCodePattern           = "\\begin{verbatim}\n%s\n\\end{verbatim}"
+	latexImagePattern          = "\\includegraphics[width=\\linewidth]{%s}"
+	latexFigurePattern         = "\\begin{figure}[h]\n\\centering\n%s\n\\caption{%s}\n\\end{figure}"
+	latexIconPattern           = "\\includegraphics[height=1em]{%s}"
+	latexDividerPattern        = "\\noindent\\rule{\\linewidth}{0.4pt}"
+	latexEquationPattern       = "$$%s$$"
+	latexInlineEquationPattern = "$%s$"
+	latexUnsupportedPattern    = "%% unsupported: %s"
+	latexCommentPattern        = "%% %s"
+	latexBeginPattern          = "\\begin{%s}"
+	latexEndPattern            = "\\end{%s}"
+	latexTableColumn           = "l"
+	latexTableCellSeparator    = " & "
+	latexTableRowEnd           = " \\\\"
+	latexTableRule             = "\\hline"
+)
+
+var (
+	// latexHeadingCommands are the sectioning commands headings are rendered
+	// with, by level. The page's title is set with \title, so Notion's
+	// headings start at \section and nested headings are deepened, down to
+	// \subparagraph.
+	latexHeadingCommands = []string{"section", "subsection", "subsubsection", "paragraph",
+		"subparagraph"}
+	// latexTextReplacer escapes the characters LaTeX reserves, so text is
+	// typeset as written.
+	latexTextReplacer = strings.NewReplacer(
+		"\\", "\\textbackslash{}",
+		"&", "\\&",
+		"%", "\\%",
+		"$", "\\$",
+		"#", "\\#",
+		"_", "\\_",
+		"{", "\\{",
+		"}", "\\}",
+		"~", "\\textasciitilde{}",
+		"^", "\\textasciicircum{}",
+		"<", "\\textless{}",
+		">", "\\textgreater{}",
+	)
+	// latexURLReplacer escapes the characters in a URL that would otherwise
+	// end the argument of \href or \url, or start a comment, early.
+	latexURLReplacer = strings.NewReplacer("%", "\\%", "#", "\\#", "{", "%7B", "}", "%7D")
+)
+
+// latexEnvironment is an environment that wraps a run of sibling blocks, such
+// as the itemize around bulleted list items or the tabular around table rows.
+type latexEnvironment struct {
+	blockType string
+	depth     int
+	name      string
+}
+
+// LaTeXRenderer renders a Notion page as the body of a LaTeX document, to be
+// included in, or pasted into, a document that loads the graphicx, hyperref,
+// and ulem packages. The page's title is set with \title and \maketitle, so
+// Notion's headings map to \section, \subsection, and \subsubsection. Like
+// HTMLRenderer, it's stateful: Notion has no block for a list or table, only
+// for its items and rows, so LaTeXRenderer tracks which environments (e.g.
+// itemize) are open and ends them as the block type and depth change.
+type LaTeXRenderer struct {
+	environments []latexEnvironment
+}
+
+// RenderPageHeader for LaTeXRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, it
+// defaults to setting the title of the page with \title and typesetting it
+// with \maketitle.
+func (l *LaTeXRenderer) RenderPageHeader(page *na.Page,
+	o ...headerFooterOverride) string {
+
+	// a new page is starting; drop any state from a previous render.
+	l.environments = nil
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return fmt.Sprintf(latexTitlePattern, latexTextReplacer.Replace(ResolveTitleInPage(page)))
+}
+
+// RenderPageFooter for LaTeXRenderer ends any list or table environments still
+// open from the final blocks of the page. It then returns the results of a
+// client's custom pageOverrider definition, or nothing when one is not
+// provided.
+func (l *LaTeXRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	footer := l.endEnvironments(func(latexEnvironment) bool { return true })
+	if footer != "" {
+		footer = "\n" + footer
+	}
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return footer + o[0](page)
+	}
+
+	return footer
+}
+
+// RenderPageHeader1 for LaTeXRenderer returns the Block's text as a \section,
+// deepened for each level of depth. If an override is provided, that function
+// is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	return l.renderHeading(1, b, o...)
+}
+
+// RenderPageHeader2 for LaTeXRenderer returns the Block's text as a
+// \subsection, deepened for each level of depth. If an override is provided,
+// that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	return l.renderHeading(2, b, o...)
+}
+
+// RenderPageHeader3 for LaTeXRenderer returns the Block's text as a
+// \subsubsection, deepened for each level of depth. If an override is
+// provided, that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	return l.renderHeading(3, b, o...)
+}
+
+// RenderParagraph for LaTeXRenderer returns the Block's text. If an override
+// is provided, that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderParagraph(b *Block, o ...blockOverride) string {
+	return l.renderPattern("%s", b, o...)
+}
+
+// RenderDivider for LaTeXRenderer returns a horizontal rule as wide as the
+// line. If an override is provided, that function is run and returned value
+// is used instead.
+func (l *LaTeXRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return latexDividerPattern
+}
+
+// RenderNumberedList for LaTeXRenderer returns the Block's text as an \item.
+// The surrounding enumerate environment is added by AddPadding, which numbers
+// the items, unless RenderOptions.OrderedListStyle asks for a style other than
+// decimal, in which case the item is labeled with its marker, e.g. "a.". If
+// an override is provided, that function is run and returned value is used
+// instead.
+func (l *LaTeXRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	switch resolveRenderConfig(b.Opts...).OrderedListStyle {
+	case "", OrderedListStyleDecimal:
+		return fmt.Sprintf(latexItemPattern, b.Text)
+	}
+	return fmt.Sprintf(latexLabeledItemPattern, resolveListMarker(b), b.Text)
+}
+
+// RenderBulletedList for LaTeXRenderer returns the Block's text as an \item.
+// The surrounding itemize environment is added by AddPadding. If an override
+// is provided, that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
+	return l.renderPattern(latexItemPattern, b, o...)
+}
+
+// RenderTodoList for LaTeXRenderer returns the Block's text as an \item
+// labeled with a checkbox, "[ ]" or "[x]" when checked. The surrounding
+// itemize environment is added by AddPadding. If an override is provided,
+// that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a ToDoBlock (e.g. passed from a custom override
+	// pipeline), there is no checked state to read, so render it unchecked.
+	tb, ok := b.BlockRef.(*na.ToDoBlock)
+	if ok && tb.ToDo.Checked {
+		return fmt.Sprintf(latexLabeledItemPattern, latexTodoCheckedMarker, b.Text)
+	}
+	return fmt.Sprintf(latexLabeledItemPattern, latexTodoUncheckedMarker, b.Text)
+}
+
+// RenderCallout for LaTeXRenderer returns the Block's text within a quote
+// environment, prefixed with the callout's icon: emoji as is and downloaded
+// images with \includegraphics. External images are left out, as LaTeX can't
+// include an image from a URL. If an override is provided, that function is
+// run and returned value is used instead.
+func (l *LaTeXRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	txt := b.Text
+	switch emoji, src := resolveCalloutIcon(b); {
+	case emoji != "":
+		txt = emoji + " " + txt
+	case src != "" && !hasURLScheme(src):
+		txt = fmt.Sprintf(latexIconPattern, src) + " " + txt
+	}
+	return fmt.Sprintf(latexQuotePattern, txt)
+}
+
+// RenderQuote for LaTeXRenderer returns the Block's text within a quote
+// environment. If an override is provided, that function is run and returned
+// value is used instead.
+func (l *LaTeXRenderer) RenderQuote(b *Block, o ...blockOverride) string {
+	return l.renderPattern(latexQuotePattern, b, o...)
+}
+
+// RenderCode for LaTeXRenderer returns the code within a verbatim
+// environment, which typesets it as is without requiring a package. The code
+// is read from the CodeBlock, rather than the Block's text, as the text is
+// escaped. If an override is provided, that function is run and returned
+// value is used instead.
+func (l *LaTeXRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a CodeBlock (e.g. passed from a custom override
+	// pipeline), there is no code to read but the text.
+	cb, ok := b.BlockRef.(*na.CodeBlock)
+	if !ok {
+		return fmt.Sprintf(latexCodePattern, b.Text)
+	}
+	return fmt.Sprintf(latexCodePattern, richTextToPlain(cb.Code.RichText))
+}
+
+// RenderImage for LaTeXRenderer returns the image with \includegraphics,
+// scaled to the width of the line. Images hosted in Notion are downloaded.
+// LaTeX can't include an image from a URL, so images that aren't downloaded,
+// such as external images, are linked to instead. When RenderOptions.CaptionImages is set, the image is placed in a
+// figure with its caption. If an override is provided, that function is run
+// and returned value is used instead.
+func (l *LaTeXRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ib, ok := b.BlockRef.(*na.ImageBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderImage was passed a %s but expected an ImageBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	var src string
+	switch {
+	case ib.Image.External != nil:
+		src = ib.Image.External.URL
+	case ib.Image.File == nil:
+		return "", errImageWithoutSource(ib)
+	default:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
+		if err != nil {
+			return "", err
+		}
+		src = filePath
+	}
+
+	// images that weren't downloaded, such as external images, are linked to.
+	if hasURLScheme(src) {
+		linkTxt := b.Text
+		if linkTxt == "" {
+			linkTxt = latexTextReplacer.Replace(resolveImageAltText(ib))
+		}
+		return renderLaTeXLink(src, linkTxt), nil
+	}
+	img := fmt.Sprintf(latexImagePattern, src)
+	if config.CaptionImages && b.Text != "" {
+		return fmt.Sprintf(latexFigurePattern, img, b.Text), nil
+	}
+
+	return img, nil
+}
+
+// RenderFile for LaTeXRenderer returns a link to the file. Files hosted in
+// Notion are downloaded and linked to locally, while external files are linked
+// to directly. The caption is used as the link text when present, otherwise
+// the name of the file is used. If an override is provided, that function is
+// run and returned value is used instead.
+func (l *LaTeXRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderFile was passed a %s but expected a FileBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveFileBlockPath(fb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = latexTextReplacer.Replace(resolveFileName(filePath))
+	}
+	return renderLaTeXLink(filePath, linkTxt), nil
+}
+
+// RenderVideo for LaTeXRenderer returns a link to the video, as LaTeX can't
+// embed one; RenderOptions.EmbedVideos is ignored. Videos hosted in Notion are
+// downloaded and linked to locally. The caption is used as the link text when
+// present, otherwise the location of the video is used. If an override is
+// provided, that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderVideo was passed a %s but expected a VideoBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveVideoBlockPath(vb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	if b.Text == "" {
+		return fmt.Sprintf(latexURLPattern, latexURLReplacer.Replace(filePath)), nil
+	}
+	return renderLaTeXLink(filePath, b.Text), nil
+}
+
+// RenderAudio for LaTeXRenderer returns a link to the audio. Audio hosted in
+// Notion is downloaded and linked to locally. The caption is used as the link
+// text when present, otherwise the name of the audio file is used. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (l *LaTeXRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = latexTextReplacer.Replace(resolveFileName(filePath))
+	}
+	return renderLaTeXLink(filePath, linkTxt), nil
+}
+
+// RenderBookmark for LaTeXRenderer returns a link to the bookmarked URL. When
+// the bookmark has a caption, it is used as the link text, otherwise the URL
+// itself is typeset with \url. If an override is provided, that function is
+// run and returned value is used instead.
+func (l *LaTeXRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	bb, ok := b.BlockRef.(*na.BookmarkBlock)
+	if !ok {
+		return b.Text
+	}
+
+	if b.Text == "" {
+		return fmt.Sprintf(latexURLPattern, latexURLReplacer.Replace(bb.Bookmark.URL))
+	}
+	return renderLaTeXLink(bb.Bookmark.URL, b.Text)
+}
+
+// RenderEquation for LaTeXRenderer returns the expression as display math,
+// delimited by $$. If an override is provided, that function is run and
+// returned value is used instead.
+func (l *LaTeXRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	return l.renderPattern(latexEquationPattern, b, o...)
+}
+
+// RenderChildPage for LaTeXRenderer returns a link to the file the subpage was
+// exported to, using the subpage's title as the link text. If an override is
+// provided, that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fileName := ResolveChildPageFile(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = latexTextReplacer.Replace(fileName)
+	}
+	return renderLaTeXLink(fileName, linkTxt)
+}
+
+// RenderChildDatabase for LaTeXRenderer returns a link to the database in
+// Notion, using the database's title as the link text. If an override is
+// provided, that function is run and returned value is used instead.
+func (l *LaTeXRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	url := ResolveChildDatabaseURL(b)
+	if b.Text == "" {
+		return fmt.Sprintf(latexURLPattern, latexURLReplacer.Replace(url))
+	}
+	return renderLaTeXLink(url, b.Text)
+}
+
+// RenderLinkToPage for LaTeXRenderer returns a link to the linked page, using
+// its title as the link text, or the target of the link when the title is
+// unknown. If an override is provided, that function is run and returned
+// value is used instead.
+func (l *LaTeXRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	target := ResolveLinkToPageTarget(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = latexTextReplacer.Replace(target)
+	}
+	return renderLaTeXLink(target, linkTxt)
+}
+
+// RenderColumnList for LaTeXRenderer returns nothing, as columns are rendered
+// sequentially. If an override is provided, that function is run and
+// returned value is used instead.
+func (l *LaTeXRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderColumn for LaTeXRenderer returns nothing, as the column's content is
+// rendered sequentially. If an override is provided, that function is run and
+// returned value is used instead.
+func (l *LaTeXRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderTemplate for LaTeXRenderer returns the label of a template button in
+// bold, introducing the template's content, which is rendered after it. An
+// empty label returns nothing. If an override is provided, that function is
+// run and returned value is used instead.
+func (l *LaTeXRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.Text == "" {
+		return ""
+	}
+	return fmt.Sprintf(latexBoldPattern, b.Text)
+}
+
+// RenderUnsupported for LaTeXRenderer returns a comment naming the type of the
+// block, e.g. % unsupported: breadcrumb, when
+// RenderOptions.MarkUnsupportedBlocks is set. Otherwise, nothing is returned.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (l *LaTeXRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).MarkUnsupportedBlocks {
+		return ""
+	}
+	return fmt.Sprintf(latexUnsupportedPattern, b.BlockRef.GetType())
+}
+
+// RenderComment for LaTeXRenderer returns text as a comment.
+func (l *LaTeXRenderer) RenderComment(text string) string {
+	return fmt.Sprintf(latexCommentPattern, text)
+}
+
+// RenderTableRow for LaTeXRenderer returns the row's cells separated by "&"
+// and ended by "\\". A header row is followed by a horizontal rule. The
+// surrounding tabular environment is added by AddPadding.
+func (l *LaTeXRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
+	// when a rowOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](cells)
+	}
+
+	txts := make([]string, len(cells))
+	var headerRow bool
+	for i, c := range cells {
+		txt := c.rowTxt
+		if c.isColumnHeader && c.tableRef.boldColumnHeader && txt != "" {
+			txt = fmt.Sprintf(latexBoldPattern, txt)
+		}
+		headerRow = headerRow || c.isRowHeader
+		txts[i] = txt
+	}
+	row := strings.Join(txts, latexTableCellSeparator) + latexTableRowEnd
+	if headerRow {
+		row += "\n" + latexTableRule
+	}
+	return row
+}
+
+// RenderText takes the RichText object from the Notion API and converts it to
+// LaTeX, escaping the characters LaTeX reserves and rewriting all formatting
+// required. Examples are text that is bold, italicised, or a hyperlink.
+func (l *LaTeXRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](rt)
+	}
+
+	var parsed string
+	for _, t := range rt {
+		var content string
+		switch t.Type {
+		// text is an inline equation. The Notion API sets the plain text of an
+		// equation to its LaTeX expression, which is kept as is.
+		case "equation":
+			parsed += fmt.Sprintf(latexInlineEquationPattern, t.PlainText)
+			continue
+
+		// text is a mention of a page, database, user, or date. Page and
+		// database mentions carry the Notion URL of the target in Href.
+		case "mention":
+			content = latexTextReplacer.Replace(resolveMentionText(t))
+
+		default:
+			content = latexTextReplacer.Replace(t.Text.Content)
+		}
+
+		// each annotation applied to the text wraps it, so text that is both
+		// bold and italicised keeps both. Links are outermost, with the
+		// formatting inside the link text (e.g. \href{url}{\textbf{text}}).
+		address := resolveLinkURL(t)
+		if address == "" {
+			parsed += renderLaTeXAnnotations(content, t.Annotations)
+			continue
+		}
+		leading, content, trailing := splitSurroundingSpace(content)
+		if content != "" {
+			content = renderLaTeXLink(address, renderLaTeXAnnotations(content, t.Annotations))
+		}
+		parsed += leading + content + trailing
+	}
+	// unlike other renderers, Notion's smart quotes are kept, as LaTeX
+	// typesets a straight double quote as a closing quote.
+
+	return parsed
+}
+
+// AddPadding for LaTeXRenderer does not indent blocks, as indentation would be
+// kept in verbatim environments. Instead, like HTMLRenderer, it is where list
+// and table environments are begun and ended, as it's called for every block
+// with both its type and depth. As blocks are separated by a single line
+// break, it's also where the blank line that ends a paragraph is added,
+// before every block that doesn't continue, or begin, an environment nested
+// in another.
+func (l *LaTeXRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	blockType := string(b.BlockRef.GetType())
+
+	// end environments that are deeper than this block, or at the same depth
+	// but of a different type. Environments at a lower depth remain open as
+	// this block is nested inside of them.
+	out := l.endEnvironments(func(env latexEnvironment) bool {
+		return env.depth > b.Depth || (env.depth == b.Depth && env.blockType != blockType)
+	})
+	if b.Text == "" {
+		return out
+	}
+
+	begin, name := resolveLaTeXEnvironment(b)
+	switch {
+	case name == "":
+		out += "\n"
+	case len(l.environments) > 0 && l.environments[len(l.environments)-1].depth == b.Depth:
+		// another item in an already open environment
+	default:
+		if len(l.environments) == 0 {
+			out += "\n"
+		}
+		out += begin + "\n"
+		l.environments = append(l.environments,
+			latexEnvironment{blockType: blockType, depth: b.Depth, name: name})
+	}
+
+	return out + b.Text
+}
+
+// AddBlockEnd for LaTeXRenderer returns nothing, as the blocks it renders with
+// children have no closing syntax. If an override is provided, that function
+// is run and returned value is used instead.
+func (l *LaTeXRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// AddSectionSeperation for LaTeXRenderer adds a single line break between
+// rendered blocks. The blank line ending a paragraph is added by AddPadding,
+// after any environment it ends.
+func (l *LaTeXRenderer) AddSectionSeperation(previousType string, currentType string,
+	o ...seperationOverride) string {
+
+	// when a seperationOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](previousType, currentType)
+	}
+
+	return "\n"
+}
+
+// endEnvironments pops open environments off the top of the stack while
+// shouldEnd returns true for them. It returns the markup ending every
+// environment popped.
+func (l *LaTeXRenderer) endEnvironments(shouldEnd func(latexEnvironment) bool) string {
+	var out string
+	for len(l.environments) > 0 {
+		env := l.environments[len(l.environments)-1]
+		if !shouldEnd(env) {
+			break
+		}
+		out += fmt.Sprintf(latexEndPattern, env.name) + "\n"
+		l.environments = l.environments[:len(l.environments)-1]
+	}
+	return out
+}
+
+// renderHeading returns the Block's text with the sectioning command of level,
+// deepened by the Block's depth. When the heading has an anchor, it's set as
+// the heading's \label. If an override is provided, that function is run and
+// returned value is used instead.
+func (l *LaTeXRenderer) renderHeading(level int, b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	i := level + b.Depth - 1
+	if i >= len(latexHeadingCommands) {
+		i = len(latexHeadingCommands) - 1
+	}
+	heading := fmt.Sprintf("\\%s{%s}", latexHeadingCommands[i], b.Text)
+	if anchor := ResolveHeadingAnchor(b); anchor != "" {
+		heading += fmt.Sprintf(latexLabelPattern, anchor)
+	}
+	return heading
+}
+
+// renderPattern returns the Block's text formatted with pattern. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (l *LaTeXRenderer) renderPattern(pattern string, b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(pattern, b.Text)
+}
+
+// resolveLaTeXEnvironment returns the markup beginning the environment the
+// block in b is rendered within, and the environment's name. An empty name is
+// returned when blocks of its type aren't rendered within an environment. The
+// columns of a tabular are taken from the number of cells in the row.
+func resolveLaTeXEnvironment(b *Block) (string, string) {
+	switch b.BlockRef.GetType() {
+	case "bulleted_list_item", "to_do":
+		return fmt.Sprintf(latexBeginPattern, "itemize"), "itemize"
+	case "numbered_list_item":
+		return fmt.Sprintf(latexBeginPattern, "enumerate"), "enumerate"
+	case "table_row":
+		var columns int
+		if rb, ok := b.BlockRef.(*na.TableRowBlock); ok {
+			columns = len(rb.TableRow.Cells)
+		}
+		return fmt.Sprintf(latexBeginPattern, "tabular") +
+			"{" + strings.Repeat(latexTableColumn, columns) + "}", "tabular"
+	}
+	return "", ""
+}
+
+// renderLaTeXLink returns a link to target with text, which is expected to be
+// escaped already, as its text. Targets without a scheme, such as downloaded
+// files, are linked to relative to the document.
+func renderLaTeXLink(target, text string) string {
+	return fmt.Sprintf(latexLinkPattern, latexURLReplacer.Replace(target), text)
+}
+
+// renderLaTeXAnnotations returns content wrapped in the command for each of
+// the annotations applied to it, with inline code innermost. Colors aren't
+// rendered.
+func renderLaTeXAnnotations(content string, a *na.Annotations) string {
+	if a == nil || content == "" {
+		return content
+	}
+	if a.Code {
+		content = fmt.Sprintf(latexInlineCodePattern, content)
+	}
+	if a.Underline {
+		content = fmt.Sprintf(latexUnderlinePattern, content)
+	}
+	if a.Strikethrough {
+		content = fmt.Sprintf(latexStrikeThroughPattern, content)
+	}
+	if a.Italic {
+		content = fmt.Sprintf(latexItalicPattern, content)
+	}
+	if a.Bold {
+		content = fmt.Sprintf(latexBoldPattern, content)
+	}
+	return content
+}
+
+// hasURLScheme reports whether address has a scheme, such as https, rather
+// than being the path of a downloaded file.
+func hasURLScheme(address string) bool {
+	u, err := url.Parse(address)
+	return err == nil && u.Scheme != ""
+}
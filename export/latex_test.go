@@ -0,0 +1,62 @@
+package export
+
+import (
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+func TestLaTeXRenderTextAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   []na.RichText
+		want string
+	}{
+		{
+			name: "no annotations",
+			rt:   []na.RichText{text("50% of $5")},
+			want: "50\\% of \\$5",
+		},
+		{
+			name: "bold and italic",
+			rt:   []na.RichText{annotated("both", na.Annotations{Bold: true, Italic: true})},
+			want: "\\textbf{\\emph{both}}",
+		},
+		{
+			name: "link without annotations",
+			rt:   []na.RichText{linked(text("docs"), "https://example.com/a#b")},
+			want: "\\href{https://example.com/a\\#b}{docs}",
+		},
+		{
+			name: "bold link",
+			rt:   []na.RichText{linked(annotated("docs", na.Annotations{Bold: true}), "https://example.com")},
+			want: "\\href{https://example.com}{\\textbf{docs}}",
+		},
+		{
+			name: "underlined code link",
+			rt: []na.RichText{linked(annotated("a_b", na.Annotations{Code: true, Underline: true}),
+				"https://example.com")},
+			want: "\\href{https://example.com}{\\underline{\\texttt{a\\_b}}}",
+		},
+		{
+			name: "link with surrounding spaces",
+			rt:   []na.RichText{text("see"), linked(text(" docs "), "https://example.com"), text("now")},
+			want: "see \\href{https://example.com}{docs} now",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&LaTeXRenderer{}).RenderText(tt.rt); got != tt.want {
+				t.Errorf("RenderText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaTeXGolden(t *testing.T) {
+	out, err := newTestExporter(t, "latex", samplePage()).Render("sample")
+	if err != nil {
+		t.Fatalf("Render() error: %s", err)
+	}
+	assertGolden(t, "golden/sample.tex", out)
+}
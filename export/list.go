@@ -0,0 +1,65 @@
+package export
+
+// This file contains the logic used to discover the pages shared with the
+// integration, so their IDs can be found without opening Notion.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	na "github.com/jomei/notionapi"
+)
+
+// PageInfo describes a page shared with the integration, as returned by
+// ListPages.
+type PageInfo struct {
+	// ID is the page's UUID without dashes, as accepted by Render.
+	ID    string
+	Title string
+	// LastEditedTime is when the page was last edited, according to Notion.
+	LastEditedTime time.Time
+}
+
+// ListPages retrieves every page shared with the integration, most recently
+// edited first, using the Notion search API. Databases are left out, though
+// their rows, which Notion considers pages, are included. Pages that were
+// only just shared may take a few moments to be returned.
+func (e *exporter) ListPages(ctx context.Context) ([]PageInfo, error) {
+	var pages []PageInfo
+	var cursor na.Cursor
+	for {
+		res, err := e.c.Search.Do(ctx, &na.SearchRequest{
+			Filter: map[string]string{"property": "object", "value": "page"},
+			Sort: &na.SortObject{
+				Timestamp: na.TimestampLastEdited,
+				Direction: na.SortOrderDESC,
+			},
+			StartCursor: cursor,
+		})
+		if err != nil {
+			var apiErr *na.Error
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusUnauthorized {
+				return nil, errors.New(invalidTokenMessage)
+			}
+			return nil, fmt.Errorf("Failed listing Notion pages, error from client: %s", err)
+		}
+		for _, o := range res.Results {
+			p, ok := o.(*na.Page)
+			if !ok {
+				continue
+			}
+			pages = append(pages, PageInfo{
+				ID:             normalizePageID(string(p.ID)),
+				Title:          ResolveTitleInPage(p),
+				LastEditedTime: p.LastEditedTime,
+			})
+		}
+		if !res.HasMore {
+			return pages, nil
+		}
+		cursor = res.NextCursor
+	}
+}
@@ -1,13 +1,10 @@
 package export
 
 import (
-	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"os"
-	"path/filepath"
+	"path"
+	"regexp"
+	"strconv"
 	"strings"
 
 	na "github.com/jomei/notionapi"
@@ -16,26 +13,44 @@ import (
 const (
 	tokenEnvVarName = "NOTION_TOKEN"
 
-	mdCodeBlockDelimiter   = "```"
-	mdHeadingOnePattern    = "# %s"
-	mdHeadingTwoPattern    = "## %s"
-	mdHeadingThreePattern  = "### %s"
-	mdLinkPattern          = "[%s](%s)"
-	mdBoldPattern          = "**%s**"
-	mdItalicPattern        = "_%s_"
-	mdStrikeThroughPattern = "~%s~"
-	mdInlineCodePattern    = "`%s`"
-	mdListItemPattern      = "* %s"
-	mdNumItemPattern       = "1. %s"
-	mdTodoUncheckedPattern = "* [] %s"
-	mdTodoCheckedPattern   = "* [x] %s"
-	MdImagePattern         = "![%s](%s)"
-	mdTableElementPattern  = "| %s "
-	mdDividerPattern       = "---"
-	mdQuotePattern         = "> %s"
-
-	defaultImageSaveLocation = "images"
-	notionImageExtension     = ".png"
+	mdCodeBlockDelimiter    = "```"
+	mdHeadingOnePattern     = "# %s"
+	mdHeadingTwoPattern     = "## %s"
+	mdHeadingThreePattern   = "### %s"
+	mdLinkPattern           = "[%s](%s)"
+	mdAutolinkPattern       = "<%s>"
+	mdBoldPattern           = "**%s**"
+	mdItalicPattern         = "_%s_"
+	mdStrikeThroughPattern  = "~%s~"
+	mdInlineCodePattern     = "`%s`"
+	mdListItemPattern       = "* %s"
+	mdNumItemPattern        = "%s %s"
+	mdTodoUncheckedPattern  = "- [ ] %s"
+	mdTodoCheckedPattern    = "- [x] %s"
+	MdImagePattern          = "![%s](%s)"
+	mdTableElementPattern   = "| %s "
+	mdDividerPattern        = "---"
+	mdQuotePattern          = "> %s"
+	mdEquationPattern       = "$$%s$$"
+	mdInlineEquationPattern = "$%s$"
+	mdAlertPattern          = "> [!%s]\n%s"
+	mdHeadingAnchorPattern  = "%s {#%s}"
+
+	// CommonMark has no task lists, so the checkbox is escaped to render as
+	// literal text rather than, in some parsers, a link.
+	mdLiteralTodoUncheckedPattern = "- \\[ \\] %s"
+	mdLiteralTodoCheckedPattern   = "- \\[x\\] %s"
+	// TodoStyleEmoji prefixes to-dos with an emoji in place of a checkbox.
+	mdEmojiTodoUncheckedPattern = "- ⬜ %s"
+	mdEmojiTodoCheckedPattern   = "- ✅ %s"
+	// CommonMark has no tables, so they're rendered as HTML.
+	mdHTMLTableOpen  = "<table>"
+	mdHTMLTableClose = "</table>"
+
+	defaultImageAltText = "image"
+	calloutIconAltText  = "icon"
+	defaultIndentChar   = " "
+	defaultIndentWidth  = 4
 
 	ulquo = "“"
 	urquo = "”"
@@ -44,6 +59,70 @@ const (
 var (
 	languages            map[string]string
 	unicodeQuoteReplacer = strings.NewReplacer(ulquo, "\"", urquo, "\"")
+	// mdAltTextReplacer escapes brackets in an image's alt text, which would
+	// otherwise end the alt text early.
+	mdAltTextReplacer = strings.NewReplacer("[", "\\[", "]", "\\]")
+	// mdTableCellReplacer escapes cell text that would otherwise break the
+	// structure of a table. Pipes delimit cells and a table row can't span
+	// lines, so line breaks are replaced with <br>.
+	mdTableCellReplacer = strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	// mdHTMLTableCellReplacer replaces line breaks in the text of a cell in
+	// an HTML table, as a blank line would end the HTML block in CommonMark.
+	mdHTMLTableCellReplacer = strings.NewReplacer("\r\n", "<br>", "\n", "<br>")
+	// mdListMarkerPattern matches the marker starting a list item, along with
+	// the whitespace after it, in any of the OrderedListStyles (e.g. "* ",
+	// "12. ", "iv. ", or "3) ").
+	mdListMarkerPattern = regexp.MustCompile(`^(?:[-*+]|[0-9]+[.)]|[a-z]+[.)])[ \t]+`)
+)
+
+var (
+	// romanNumerals are the symbols of roman numerals, including those
+	// written subtractively (e.g. "iv"), from largest to smallest.
+	romanNumerals = []struct {
+		value  int
+		symbol string
+	}{
+		{1000, "m"}, {900, "cm"}, {500, "d"}, {400, "cd"}, {100, "c"}, {90, "xc"},
+		{50, "l"}, {40, "xl"}, {10, "x"}, {9, "ix"}, {5, "v"}, {4, "iv"}, {1, "i"},
+	}
+	// mdTableSeparators maps the alignment of a column to the separator
+	// beneath its header cell.
+	mdTableSeparators = map[TableAlignment]string{
+		TableAlignmentDefault: "---",
+		TableAlignmentLeft:    ":---",
+		TableAlignmentCenter:  ":---:",
+		TableAlignmentRight:   "---:",
+	}
+	// calloutAlertsByEmoji maps the emoji icon of a callout to the GitHub
+	// alert type closest in meaning. Emoji are keyed without the variation
+	// selector (U+FE0F) that sometimes follows them.
+	calloutAlertsByEmoji = map[string]string{
+		"💡": "TIP",
+		"✅": "TIP",
+		"ℹ": "NOTE",
+		"📝": "NOTE",
+		"📌": "IMPORTANT",
+		"❗": "IMPORTANT",
+		"‼": "IMPORTANT",
+		"⚠": "WARNING",
+		"🚧": "WARNING",
+		"🚨": "CAUTION",
+		"🛑": "CAUTION",
+		"⛔": "CAUTION",
+		"❌": "CAUTION",
+		"🔥": "CAUTION",
+	}
+	// calloutAlertsByColor maps the color of a callout, with or without the
+	// "_background" suffix, to the GitHub alert type closest in meaning. It's
+	// used when the callout's icon has no mapping.
+	calloutAlertsByColor = map[string]string{
+		"blue":   "NOTE",
+		"green":  "TIP",
+		"purple": "IMPORTANT",
+		"yellow": "WARNING",
+		"orange": "WARNING",
+		"red":    "CAUTION",
+	}
 )
 
 func init() {
@@ -58,6 +137,13 @@ func init() {
 }
 
 type MDRenderer struct {
+	// htmlTableOpen is true while the rows of a table rendered as HTML, for
+	// MarkdownFlavorCommonMark, are being rendered, so the table can be
+	// closed by the first block that isn't one of its rows.
+	htmlTableOpen bool
+	// htmlTablePadding is the indentation of the open HTML table, so it's
+	// closed at the same depth it was opened, such as within a list item.
+	htmlTablePadding string
 }
 
 // RenderPageHeader for MDRenderer takes a client's custom pageOverrider
@@ -67,6 +153,9 @@ type MDRenderer struct {
 func (m *MDRenderer) RenderPageHeader(page *na.Page,
 	o ...headerFooterOverride) string {
 
+	// a new page is starting; drop any state from a previous render.
+	m.htmlTableOpen = false
+
 	// when an overrider is provided, use its render functionality.
 	if len(o) > 0 && o[0] != nil {
 		return o[0](page)
@@ -77,16 +166,18 @@ func (m *MDRenderer) RenderPageHeader(page *na.Page,
 	return output
 }
 
-// RenderPageFooter for MDRenderer takes a client's custom pageOverrider
-// definition and returns its results. If a pageOverrider is not provided, a
-// blank footer is returned.
+// RenderPageFooter for MDRenderer closes an HTML table still open from the
+// final blocks of the page. It then returns the results of a client's custom
+// pageOverrider definition, or nothing when one is not provided.
 func (m *MDRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	footer := m.closeHTMLTable()
+
 	// when an overrider is provided, use its render functionality.
 	if len(o) > 0 && o[0] != nil {
-		return o[0](page)
+		return footer + o[0](page)
 	}
 
-	return ""
+	return footer
 }
 
 // RenderPageHeader1 for MDRenderer takes a client's the text object present in
@@ -99,7 +190,7 @@ func (m *MDRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
 		return o[0](b)
 	}
 
-	return fmt.Sprintf(mdHeadingOnePattern, b.Text)
+	return withMarkdownAnchor(b, fmt.Sprintf(mdHeadingOnePattern, b.Text))
 }
 
 // RenderPageHeader2 for MDRenderer takes a client's the text object present in
@@ -112,7 +203,7 @@ func (m *MDRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
 		return o[0](b)
 	}
 
-	return fmt.Sprintf(mdHeadingTwoPattern, b.Text)
+	return withMarkdownAnchor(b, fmt.Sprintf(mdHeadingTwoPattern, b.Text))
 }
 
 // RenderPageHeader3 for MDRenderer takes a client's the text object present in
@@ -125,7 +216,7 @@ func (m *MDRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
 		return o[0](b)
 	}
 
-	return fmt.Sprintf(mdHeadingThreePattern, b.Text)
+	return withMarkdownAnchor(b, fmt.Sprintf(mdHeadingThreePattern, b.Text))
 }
 
 // RenderParagraph for MDRenderer takes a client's the text object present in
@@ -153,7 +244,8 @@ func (m *MDRenderer) RenderDivider(b *Block, o ...blockOverride) string {
 }
 
 // RenderNumberedList for MDRenderer takes a client's the text object present
-// in the Block and returns it prepended with "1. ". If an override is
+// in the Block and returns it prepended with its number in the list, e.g.
+// "1. ", "2. ", styled by RenderOptions.OrderedListStyle. If an override is
 // provided, that function is run and returned value is used instead.
 func (m *MDRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
 	// when an override function is passed, call it and return its output
@@ -161,7 +253,7 @@ func (m *MDRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
 		return o[0](b)
 	}
 
-	return fmt.Sprintf(mdNumItemPattern, b.Text)
+	return fmt.Sprintf(mdNumItemPattern, resolveListMarker(b), b.Text)
 }
 
 // RenderBulletedList for MDRenderer takes a client's the text object present
@@ -179,25 +271,40 @@ func (m *MDRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
 // The first row of cells retrieved is always treated as a row header. While
 // Notion supports tables without row headers, many markdown parsers do not:
 // (https://stackoverflow.com/questions/17536216). Similarlly, many markdown
-// parsers do not support column headers, thus they are not respected here.
+// parsers do not support column headers, thus they are only respected when
+// RenderOptions.BoldColumnHeaders is set, in which case the header cells are
+// bolded.
 func (m *MDRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
 	// when a rowOverride function is passed, call it and return its output
 	if len(o) > 0 && o[0] != nil {
 		return o[0](cells)
 	}
 
+	if len(cells) > 0 && cells[0].tableRef.markdownFlavor == MarkdownFlavorCommonMark {
+		return m.renderHTMLTableRow(cells)
+	}
+
 	var row string
 	var currentRow int
 	for _, c := range cells {
 		currentRow = c.tableRef.currentRow
-		row += fmt.Sprintf(mdTableElementPattern, c.rowTxt)
+		txt := mdTableCellReplacer.Replace(c.rowTxt)
+		if c.isColumnHeader && c.tableRef.boldColumnHeader && txt != "" {
+			txt = fmt.Sprintf(mdBoldPattern, txt)
+		}
+		row += fmt.Sprintf(mdTableElementPattern, txt)
 	}
 	row += "|"
 	// when row is the first, it's a header
 	if currentRow == 0 {
 		var rowHeader string
-		for range cells {
-			rowHeader += "| --- "
+		for i, c := range cells {
+			// unknown alignments are left to the viewer.
+			sep, ok := mdTableSeparators[c.tableRef.columnAlignment(i)]
+			if !ok {
+				sep = mdTableSeparators[TableAlignmentDefault]
+			}
+			rowHeader += fmt.Sprintf(mdTableElementPattern, sep)
 		}
 		rowHeader += "|"
 		row += "\n" + rowHeader
@@ -205,30 +312,90 @@ func (m *MDRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string
 	return row
 }
 
+// renderHTMLTableRow returns the row as an HTML <tr> element, opening the
+// surrounding <table> on the first row. Cells in a header row or header column
+// are rendered as <th>, the same as HTMLRenderer.
+func (m *MDRenderer) renderHTMLTableRow(cells []tableCell) string {
+	var open string
+	if !m.htmlTableOpen {
+		open = mdHTMLTableOpen + "\n"
+		m.htmlTableOpen = true
+		if len(cells) > 0 {
+			m.htmlTablePadding = cells[0].tableRef.padding
+		}
+	}
+	var row string
+	for _, c := range cells {
+		txt := mdHTMLTableCellReplacer.Replace(c.rowTxt)
+		if c.isRowHeader || c.isColumnHeader {
+			row += fmt.Sprintf(htmlTableHeaderPattern, txt)
+			continue
+		}
+		row += fmt.Sprintf(htmlTableCellPattern, txt)
+	}
+	return open + fmt.Sprintf(htmlTableRowPattern, row)
+}
+
+// closeHTMLTable returns the tag closing the HTML table being rendered, or
+// nothing when no table is open.
+func (m *MDRenderer) closeHTMLTable() string {
+	if !m.htmlTableOpen {
+		return ""
+	}
+	m.htmlTableOpen = false
+	return "\n" + m.htmlTablePadding + mdHTMLTableClose
+}
+
+// RenderTodoList for MDRenderer returns the Block's text as a task list item,
+// checked according to the to-do. For MarkdownFlavorCommonMark, which has no
+// task lists, the checkbox is literal text. For TodoStyleEmoji, an emoji is
+// used in place of the checkbox, regardless of the flavor. If an override is
+// provided, that function is run and returned value is used instead.
 func (m *MDRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
 	// when an override function is passed, call it and return its output
 	if len(o) > 0 && o[0] != nil {
 		return o[0](b)
 	}
 
-	var tb *na.ToDoBlock
-	if b.BlockRef.GetType() == "to_do" {
-		tb = b.BlockRef.(*na.ToDoBlock)
+	checked, unchecked := mdTodoCheckedPattern, mdTodoUncheckedPattern
+	if len(b.Opts) > 0 && b.Opts[0].MarkdownFlavor == MarkdownFlavorCommonMark {
+		checked, unchecked = mdLiteralTodoCheckedPattern, mdLiteralTodoUncheckedPattern
 	}
-	if tb.ToDo.Checked {
-		return fmt.Sprintf(mdTodoCheckedPattern, b.Text)
+	if len(b.Opts) > 0 && b.Opts[0].TodoStyle == TodoStyleEmoji {
+		checked, unchecked = mdEmojiTodoCheckedPattern, mdEmojiTodoUncheckedPattern
 	}
-	return fmt.Sprintf(mdTodoUncheckedPattern, b.Text)
+	// when the block isn't a ToDoBlock (e.g. passed from a custom override
+	// pipeline), there is no checked state to read, so render it unchecked.
+	tb, ok := b.BlockRef.(*na.ToDoBlock)
+	if ok && tb.ToDo.Checked {
+		return fmt.Sprintf(checked, b.Text)
+	}
+	return fmt.Sprintf(unchecked, b.Text)
 }
 
+// RenderCallout for MDRenderer returns the Block's text as a markdown quote,
+// prefixed with the callout's icon: emoji as is and images as a markdown
+// image. If an override is provided, that function is run and returned value
+// is used instead.
 func (m *MDRenderer) RenderCallout(b *Block, o ...blockOverride) string {
 	// when an override function is passed, call it and return its output
 	if len(o) > 0 && o[0] != nil {
 		return o[0](b)
 	}
 
+	if alert := resolveCalloutAlert(b); alert != "" {
+		return fmt.Sprintf(mdAlertPattern, alert, quoteMDLines(b.Text))
+	}
+
 	// quote pattern used here as callouts are treated as markdown quotes
-	return fmt.Sprintf(mdQuotePattern, b.Text)
+	txt := b.Text
+	switch emoji, src := resolveCalloutIcon(b); {
+	case emoji != "":
+		txt = emoji + " " + txt
+	case src != "":
+		txt = fmt.Sprintf(MdImagePattern, calloutIconAltText, src) + " " + txt
+	}
+	return quoteMDLines(txt)
 }
 
 func (m *MDRenderer) RenderQuote(b *Block, o ...blockOverride) string {
@@ -237,7 +404,33 @@ func (m *MDRenderer) RenderQuote(b *Block, o ...blockOverride) string {
 		return o[0](b)
 	}
 
-	return fmt.Sprintf(mdQuotePattern, b.Text)
+	return quoteMDLines(b.Text)
+}
+
+// quoteMDLines returns s as a markdown quote, prefixing every line, not only
+// the first, so text spanning multiple lines stays within the quote. Blank
+// lines are prefixed with ">" alone, leaving no trailing whitespace.
+func quoteMDLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		if l == "" {
+			lines[i] = ">"
+			continue
+		}
+		lines[i] = fmt.Sprintf(mdQuotePattern, l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// RenderQuotedChildren for MDRenderer quotes every line of the children of a
+// quote, separated from the quote's text by a blank quoted line.
+func (m *MDRenderer) RenderQuotedChildren(text string) string {
+	return ">\n" + quoteMDLines(text)
+}
+
+// RenderComment for MDRenderer returns text as an HTML comment.
+func (m *MDRenderer) RenderComment(text string) string {
+	return fmt.Sprintf(htmlCommentPattern, text)
 }
 
 func (m *MDRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
@@ -253,25 +446,288 @@ func (m *MDRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
 	config := resolveRenderConfig(b.Opts...)
 	ib := b.BlockRef.(*na.ImageBlock)
 
+	var src string
+	switch {
 	// image was not uploaded to Notion, but is referenced from an
 	// external URL.
-	if ib.Image.External != nil {
-		// TODO(joshrosso): Friendly name is currently "image". Should think
-		// about how to make this more eloquent.
-		return fmt.Sprintf(MdImagePattern, "image", ib.Image.External.URL), nil
-	}
+	case ib.Image.External != nil:
+		src = ib.Image.External.URL
+	// image has no source. Rather than emitting an empty image reference,
+	// fail the render so the malformed block is surfaced.
+	case ib.Image.File == nil:
+		return "", errImageWithoutSource(ib)
 	// image was uploaded to Notion, need to download to local
 	// filesystem.
-	var filePath string
-	var err error
-	if ib.Image.File != nil {
-		filePath, err = SaveNotionImageToFilesystem(ib.Image.File.URL, config.ImageOpts)
+	default:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
 		if err != nil {
 			return "", err
 		}
+		src = filePath
+	}
+
+	alt := mdAltTextReplacer.Replace(resolveImageAltText(ib))
+	img := fmt.Sprintf(MdImagePattern, alt, src)
+	if config.CaptionImages && b.Text != "" {
+		img += "\n" + fmt.Sprintf(mdItalicPattern, b.Text)
+	}
+
+	return img, nil
+}
+
+// RenderFile for MDRenderer returns a markdown link to the file. Files hosted
+// in Notion are downloaded and linked to locally, while external files are
+// linked to directly. The caption is used as the link text when present,
+// otherwise the name of the file is used. If an override is provided, that
+// function is run and returned value is used instead.
+func (m *MDRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderFile was passed a %s but expected a FileBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveFileBlockPath(fb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = resolveFileName(filePath)
+	}
+	// file names commonly contain spaces, which terminate a markdown link
+	// destination.
+	return fmt.Sprintf(mdLinkPattern, linkTxt, strings.ReplaceAll(filePath, " ", "%20")), nil
+}
+
+// RenderVideo for MDRenderer returns a markdown link to the video. Videos
+// hosted in Notion are downloaded and linked to locally. When
+// RenderOptions.EmbedVideos is set, the video is embedded using HTML instead,
+// with an <iframe> for known providers (e.g. YouTube) and a <video> element
+// for downloaded videos. The caption is used as the link text when present,
+// otherwise the location of the video is used. If an override is provided,
+// that function is run and returned value is used instead.
+func (m *MDRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderVideo was passed a %s but expected a VideoBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveVideoBlockPath(vb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+	if config.EmbedVideos {
+		if embed, ok := resolveVideoEmbed(vb, filePath); ok {
+			return embed, nil
+		}
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = filePath
+	}
+	return fmt.Sprintf(mdLinkPattern, linkTxt, strings.ReplaceAll(filePath, " ", "%20")), nil
+}
+
+// RenderAudio for MDRenderer returns a markdown link to the audio. Audio
+// hosted in Notion is downloaded and linked to locally. The caption is used as
+// the link text when present, otherwise the name of the audio file is used.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (m *MDRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = resolveFileName(filePath)
+	}
+	return fmt.Sprintf(mdLinkPattern, linkTxt, strings.ReplaceAll(filePath, " ", "%20")), nil
+}
+
+// RenderBookmark for MDRenderer returns a markdown link to the bookmarked URL.
+// When the bookmark has a caption, it is used as the link text, otherwise the
+// URL itself is used. If an override is provided, that function is run and
+// returned value is used instead.
+func (m *MDRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a BookmarkBlock (e.g. passed from a custom
+	// override pipeline), there is no URL to link, so return the text as is.
+	bb, ok := b.BlockRef.(*na.BookmarkBlock)
+	if !ok {
+		return b.Text
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = bb.Bookmark.URL
+	}
+	return fmt.Sprintf(mdLinkPattern, linkTxt, bb.Bookmark.URL)
+}
+
+// RenderEquation for MDRenderer takes the LaTeX expression present in the
+// Block and wraps it in "$$", resulting in a display equation understood by
+// most markdown math extensions (e.g. KaTeX or MathJax). If an override is
+// provided, that function is run and returned value is used instead.
+func (m *MDRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(mdEquationPattern, b.Text)
+}
+
+// RenderChildPage for MDRenderer returns a markdown link to the file the
+// subpage was exported to, using the subpage's title as the link text. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (m *MDRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fileName := ResolveChildPageFile(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = fileName
+	}
+	return fmt.Sprintf(mdLinkPattern, linkTxt, fileName)
+}
+
+// RenderChildDatabase for MDRenderer returns a markdown link to the database
+// in Notion, using the database's title as the link text. If an override is
+// provided, that function is run and returned value is used instead.
+func (m *MDRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	url := ResolveChildDatabaseURL(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = url
+	}
+	return fmt.Sprintf(mdLinkPattern, linkTxt, url)
+}
+
+// RenderLinkToPage for MDRenderer returns a markdown link to the linked page,
+// using its title as the link text, or the target of the link when the title
+// is unknown. If an override is provided, that function is run and returned
+// value is used instead.
+func (m *MDRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	target := ResolveLinkToPageTarget(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = target
+	}
+	return fmt.Sprintf(mdLinkPattern, linkTxt, target)
+}
+
+// RenderColumnList for MDRenderer returns nothing, as markdown has no concept
+// of columns and their content is rendered sequentially. When
+// RenderOptions.HTMLColumns is set, a flexbox HTML <div> is opened instead, so
+// the columns render side by side wherever markdown permits inline HTML. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (m *MDRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// column blocks have no separation (see AddSectionSeperation), so any
+	// HTML opened must separate itself from the previous block.
+	if !resolveRenderConfig(b.Opts...).HTMLColumns {
+		return ""
+	}
+	return "\n\n" + htmlColumnListOpen
+}
+
+// RenderColumn for MDRenderer returns nothing, as the column's content is
+// rendered sequentially. When RenderOptions.HTMLColumns is set, an HTML <div>
+// is opened for the column's content instead. If an override is provided, that
+// function is run and returned value is used instead.
+func (m *MDRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
 	}
 
-	return fmt.Sprintf(MdImagePattern, "image", filePath), nil
+	if !resolveRenderConfig(b.Opts...).HTMLColumns {
+		return ""
+	}
+	return "\n\n" + htmlColumnOpen
+}
+
+// RenderTemplate for MDRenderer returns the label of a template button in
+// bold, introducing the template's content, which is rendered after it. An
+// empty label returns nothing. If an override is provided, that function is
+// run and returned value is used instead.
+func (m *MDRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.Text == "" {
+		return ""
+	}
+	return fmt.Sprintf(mdBoldPattern, b.Text)
+}
+
+// RenderUnsupported for MDRenderer returns an HTML comment naming the type of
+// the block, e.g. <!-- unsupported: breadcrumb -->, when
+// RenderOptions.MarkUnsupportedBlocks is set. Otherwise, nothing is returned.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (m *MDRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).MarkUnsupportedBlocks {
+		return ""
+	}
+	return fmt.Sprintf(htmlUnsupportedPattern, b.BlockRef.GetType())
 }
 
 func (m *MDRenderer) RenderCode(b *Block, o ...blockOverride) string {
@@ -280,14 +736,22 @@ func (m *MDRenderer) RenderCode(b *Block, o ...blockOverride) string {
 		return o[0](b)
 	}
 
-	var cb *na.CodeBlock
-	if b.BlockRef.GetType() == "code" {
-		cb = b.BlockRef.(*na.CodeBlock)
+	// when the block isn't a CodeBlock (e.g. passed from a custom override
+	// pipeline), there is no language to read, so leave it unspecified.
+	var language string
+	if cb, ok := b.BlockRef.(*na.CodeBlock); ok {
+		language = ResolveLanguageForCodeBlock(cb.Code.Language,
+			resolveRenderConfig(b.Opts...).LanguageOverrides)
 	}
 
-	r := mdCodeBlockDelimiter + ResolveLanguageForCodeBlock(cb.Code.Language) +
+	r := mdCodeBlockDelimiter + language +
 		"\n" + b.Text + "\n" + mdCodeBlockDelimiter
 
+	// the caption is emitted as an italic line below the code block.
+	if caption := ResolveCodeCaption(b); caption != "" {
+		r += "\n" + fmt.Sprintf(mdItalicPattern, caption)
+	}
+
 	return r
 }
 
@@ -302,31 +766,39 @@ func (m *MDRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string
 
 	var parsed string
 	for _, t := range rt {
-		switch {
-		// text is a hyperlink
-		case t.Href != "":
-			parsed += fmt.Sprintf(mdLinkPattern, t.Text.Content, t.Href)
-
-		// text is bolded
-		case t.Annotations.Bold:
-			parsed += fmt.Sprintf(mdBoldPattern, t.Text.Content)
-
-		// text is italicised
-		case t.Annotations.Italic:
-			parsed += fmt.Sprintf(mdItalicPattern, t.Text.Content)
-
-		// text is strikethrough
-		case t.Annotations.Strikethrough:
-			parsed += fmt.Sprintf(mdStrikeThroughPattern, t.Text.Content)
+		var content string
+		switch t.Type {
+		// text is an inline equation. The Notion API sets the plain text of an
+		// equation to its LaTeX expression.
+		case "equation":
+			parsed += fmt.Sprintf(mdInlineEquationPattern, t.PlainText)
+			continue
+
+		// text is a bare URL linked by RenderOptions.AutolinkBareURLs, which
+		// keeps the formatting of the text it was typed in.
+		case autolinkRichTextType:
+			parsed += renderMDAnnotations(fmt.Sprintf(mdAutolinkPattern, t.Href), t.Annotations)
+			continue
+
+		// text is a mention of a page, database, user, or date. Page and
+		// database mentions carry the Notion URL of the target in Href.
+		case "mention":
+			content = resolveMentionText(t)
 
-		// text is code
-		case t.Annotations.Code:
-			parsed += fmt.Sprintf(mdInlineCodePattern, t.Text.Content)
-
-		// text is plain
 		default:
-			parsed += fmt.Sprintf(t.Text.Content)
+			content = t.Text.Content
+		}
+
+		// each annotation applied to the text wraps it, so text that is both
+		// bold and italicised keeps both. Links are outermost, with the
+		// formatting inside the link text (e.g. [**text**](url)). Whitespace
+		// is kept outside of both, as emphasis can't end with a space.
+		leading, content, trailing := splitSurroundingSpace(content)
+		content = renderMDAnnotations(content, t.Annotations)
+		if address := resolveLinkURL(t); address != "" && content != "" {
+			content = fmt.Sprintf(mdLinkPattern, content, address)
 		}
+		parsed += leading + content + trailing
 	}
 	// Notoin uses smart quotes by default, replace them with normal quotes.
 	parsed = unicodeQuoteReplacer.Replace(parsed)
@@ -334,22 +806,48 @@ func (m *MDRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string
 	return parsed
 }
 
+// renderMDAnnotations returns content wrapped in the syntax for each of the
+// annotations applied to it, with inline code innermost. Underlines and colors
+// have no markdown syntax, so are dropped.
+func renderMDAnnotations(content string, a *na.Annotations) string {
+	if a == nil || content == "" {
+		return content
+	}
+	if a.Code {
+		content = fmt.Sprintf(mdInlineCodePattern, content)
+	}
+	if a.Strikethrough {
+		content = fmt.Sprintf(mdStrikeThroughPattern, content)
+	}
+	if a.Italic {
+		content = fmt.Sprintf(mdItalicPattern, content)
+	}
+	if a.Bold {
+		content = fmt.Sprintf(mdBoldPattern, content)
+	}
+	return content
+}
+
 func (m *MDRenderer) AddPadding(b *Block, o ...blockOverride) string {
 	// when an override function is passed, call it and return its output
 	if len(o) > 0 && o[0] != nil {
 		return o[0](b)
 	}
 
-	// when at root (depth: 0) do no padding processing
-	if b.Depth == 0 {
-		return b.Text
+	config := resolveRenderConfig(b.Opts...)
+	if config.HangingIndent && b.BlockRef != nil && isListItemType(string(b.BlockRef.GetType())) {
+		return addHangingIndent(b.Text, createPadding(b.Depth, config))
 	}
 
-	padding := ""
-	for i := 0; i < b.Depth*4; i++ {
-		padding += " "
+	// when at root (depth: 0) do no padding processing. Blocks without text
+	// of their own, such as tables, whose rows are padded, aren't padded
+	// either, as it would leave trailing whitespace.
+	if b.Depth == 0 || b.Text == "" {
+		return b.Text
 	}
 
+	padding := createPadding(b.Depth, config)
+
 	paddedTxt := padding + b.Text
 	// When there are line breaks in the block (e.g. code); pad the next line.
 	paddedTxt = strings.ReplaceAll(paddedTxt, "\n", "\n"+padding)
@@ -357,89 +855,230 @@ func (m *MDRenderer) AddPadding(b *Block, o ...blockOverride) string {
 	return paddedTxt
 }
 
-// ResolveLanguageForCodeBlock takes a Notion code block's language type as
-// input and returns a representation more friendly for markdown parsers. For
-// example, Notion uses 'plain text' for Plain Text codeblocks, however most
-// markdown parsers expects this to be specified as 'txt'. For some languages,
-// Notion uses the correct (for Markdown) name. In this cause, the language
-// name passed is returned. If the language is entirely unknown, the language
-// name passed is returned.
-func ResolveLanguageForCodeBlock(language string) string {
-	if val, ok := languages[language]; ok {
-		return val
+// addHangingIndent returns the text of a list item with padding added to
+// every line, as AddPadding does, except the lines following the item's
+// marker, such as those wrapped with line breaks in Notion, are further
+// indented by the width of the marker, aligning them with the item's text
+// (e.g. "* first\n  second"). Lines before the marker, such as a comment
+// added by RenderOptions.EmbedBlockIDs, and blank lines are padded as usual.
+func addHangingIndent(text, padding string) string {
+	lines := strings.Split(text, "\n")
+	indent := padding
+	marked := false
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		lines[i] = indent + line
+		if marker := mdListMarkerPattern.FindString(line); !marked && marker != "" {
+			indent = padding + strings.Repeat(" ", len(marker))
+			marked = true
+		}
 	}
-	return language
+	return strings.Join(lines, "\n")
 }
 
-func createPathIfNonExistent(path string) error {
-	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
-		err := os.MkdirAll(path, os.ModePerm)
-		if err != nil {
-			return err
+// AddBlockEnd for MDRenderer closes the HTML <div> opened for column_list and
+// column blocks when RenderOptions.HTMLColumns is set. Nothing is returned for
+// other blocks, as markdown has no closing syntax. If an override is provided,
+// that function is run and returned value is used instead.
+func (m *MDRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	switch b.BlockRef.GetType() {
+	case "column_list", "column":
+		// a blank line is required for the markdown before the closing tag
+		// to be parsed as markdown.
+		if resolveRenderConfig(b.Opts...).HTMLColumns {
+			return "\n\n" + htmlDivClose
 		}
 	}
-	return nil
+
+	return ""
 }
 
-// SaveNotionImageToFilesystem takes the URL of a Notion-hosted image. The URL
-// is typically an S3 address. ImageSaveOptions can be optinally provided. If
-// multiple options are provided, only the first is respected. By default the
-// image is save in a ./images directory. If successful, the path the image was
-// saved is returned. An error is returned if the image can not be returned or
-// saved to the filesystem.
-func SaveNotionImageToFilesystem(address string,
-	opts ...ImageSaveOptions) (string, error) {
+// resolveImageAltText returns the alt text for the image in ib, which is the
+// plain text of its caption. When the image has no caption, a slug of the
+// image's file name, without its extension, is returned (e.g. "IMG_0042.JPG"
+// returns "img-0042"). When neither is available, "image" is returned.
+func resolveImageAltText(ib *na.ImageBlock) string {
+	if alt := strings.TrimSpace(richTextToPlain(ib.Image.Caption)); alt != "" {
+		return alt
+	}
 
-	// establish config for image save from options
-	config := ResolveImageSaveOptions(opts...)
-	createPathIfNonExistent(config.SavePath)
+	address := ib.Image.GetURL()
+	name := resolveFileName(address)
+	if name == address {
+		return defaultImageAltText
+	}
+	name = slugify(strings.TrimSuffix(name, path.Ext(name)))
+	if name == untitledPageSlug {
+		return defaultImageAltText
+	}
+	return name
+}
 
-	// determine name of image using UUID created by notion
-	u, err := url.Parse(address)
-	if err != nil {
-		return "", err
+// resolveCalloutIcon returns the icon of the callout in b. Emoji icons are
+// returned as emoji, while image icons are returned as the location of the
+// image, downloading icons hosted in Notion according to ImageOpts. Nothing
+// is returned when the callout has no icon, RenderOptions.OmitCalloutIcons
+// is set, or an image icon is skipped or can't be downloaded.
+func resolveCalloutIcon(b *Block) (emoji string, src string) {
+	cb, ok := b.BlockRef.(*na.CalloutBlock)
+	if !ok || cb.Callout.Icon == nil {
+		return "", ""
 	}
-	resources := strings.Split(u.Path, "/")
-	if len(resources) < 2 {
-		return "", fmt.Errorf("Path from Notion Image URL was invalid. Path was: %s", address)
+	config := resolveRenderConfig(b.Opts...)
+	if config.OmitCalloutIcons {
+		return "", ""
 	}
-	fileName := resources[2]
-	filePath := filepath.Join(config.SavePath, fileName) + notionImageExtension
 
-	// if file exists, do no more and return the existing file's path
-	if !config.OverwriteExisting {
-		_, err := os.Stat(filePath)
-		if !os.IsNotExist(err) {
-			return filePath, nil
+	icon := cb.Callout.Icon
+	switch {
+	case icon.Emoji != nil:
+		return string(*icon.Emoji), ""
+	case config.ImageOpts.IgnoreImages:
+		return "", ""
+	case icon.External != nil:
+		return "", icon.External.URL
+	case icon.File != nil && config.ImageOpts.KeepRemoteImageURLs:
+		return "", icon.File.URL
+	case icon.File != nil:
+		filePath, err := SaveNotionImageToFilesystem(icon.File.URL, config.ImageOpts)
+		if err != nil {
+			return "", ""
 		}
+		return "", resolveLinkPath(filePath, config.ImageOpts)
 	}
 
-	// download the image from the Notion-provided URL
-	// TODO(joshrosso): Don't rely on default HTTP client; need better control
-	// of timeouts.
-	resp, err := http.Get(address)
-	if err != nil {
-		return "", err
+	return "", ""
+}
+
+// withMarkdownAnchor returns heading with the anchor of the heading in b, if
+// it has one, appended as a heading attribute.
+func withMarkdownAnchor(b *Block, heading string) string {
+	if anchor := ResolveHeadingAnchor(b); anchor != "" {
+		return fmt.Sprintf(mdHeadingAnchorPattern, heading, anchor)
 	}
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("Non 200 status code returned when retrieveing."+
-			"Code was: %d", resp.StatusCode)
+	return heading
+}
+
+// resolveCalloutAlert returns the GitHub alert type (e.g. TIP) for the callout
+// in b, chosen by its emoji icon or, failing that, its color. Nothing is
+// returned when RenderOptions.CalloutsAsAlerts isn't set or neither the icon
+// nor the color has a mapping.
+func resolveCalloutAlert(b *Block) string {
+	cb, ok := b.BlockRef.(*na.CalloutBlock)
+	if !ok || !resolveRenderConfig(b.Opts...).CalloutsAsAlerts {
+		return ""
 	}
+	return matchCalloutAlert(cb)
+}
 
-	// persist the downloaded image to the filesystem
-	f, err := os.Create(filePath)
-	if err != nil {
-		return "", err
+// matchCalloutAlert returns the GitHub alert type (e.g. TIP) closest in
+// meaning to the callout in cb, chosen by its emoji icon or, failing that, its
+// color. Nothing is returned when neither has a mapping.
+func matchCalloutAlert(cb *na.CalloutBlock) string {
+	if icon := cb.Callout.Icon; icon != nil && icon.Emoji != nil {
+		if alert, ok := calloutAlertsByEmoji[strings.TrimSuffix(string(*icon.Emoji), "\ufe0f")]; ok {
+			return alert
+		}
 	}
-	_, err = io.Copy(f, resp.Body)
-	if err != nil {
-		return "", err
+	return calloutAlertsByColor[strings.TrimSuffix(cb.Callout.Color, htmlBackgroundColorSuffix)]
+}
+
+// resolveListNumber returns the number of a numbered list item within its
+// list. When the Block was not passed list state (e.g. it was rendered outside
+// of an exporter), it is treated as the first item.
+func resolveListNumber(b *Block) int {
+	config := resolveRenderConfig(b.Opts...)
+	if config.numberedListIndex < 1 {
+		return 1
 	}
+	return config.numberedListIndex
+}
 
-	return filePath, nil
+// resolveListMarker returns the marker of a numbered list item, which is its
+// number, as resolved by resolveListNumber, in the RenderOptions.OrderedListStyle
+// of the Block, e.g. "1.", "a.", "i.", or "1)".
+func resolveListMarker(b *Block) string {
+	n := resolveListNumber(b)
+	switch resolveRenderConfig(b.Opts...).OrderedListStyle {
+	case OrderedListStyleLowerAlpha:
+		return toLowerAlpha(n) + "."
+	case OrderedListStyleLowerRoman:
+		return toLowerRoman(n) + "."
+	case OrderedListStyleParen:
+		return strconv.Itoa(n) + ")"
+	}
+	return strconv.Itoa(n) + "."
+}
+
+// toLowerAlpha returns n as letters, counting as spreadsheet columns do: 1 is
+// "a", 26 is "z", 27 is "aa", and so on.
+func toLowerAlpha(n int) string {
+	var out []byte
+	for ; n > 0; n = (n - 1) / 26 {
+		out = append([]byte{byte('a' + (n-1)%26)}, out...)
+	}
+	return string(out)
+}
+
+// toLowerRoman returns n as a lowercase roman numeral, e.g. 4 is "iv" and 9
+// is "ix". Roman numerals have no zero, so n is expected to be positive.
+func toLowerRoman(n int) string {
+	var b strings.Builder
+	for _, numeral := range romanNumerals {
+		for ; n >= numeral.value; n -= numeral.value {
+			b.WriteString(numeral.symbol)
+		}
+	}
+	return b.String()
+}
+
+// ResolveCodeCaption returns the caption of the code in a code Block, run
+// through RenderText. An empty string is returned when the code has no
+// caption or the Block was not passed this state (e.g. it was rendered outside
+// of an exporter).
+func ResolveCodeCaption(b *Block) string {
+	config := resolveRenderConfig(b.Opts...)
+	return config.codeCaption
+}
+
+// ResolveLanguageForCodeBlock takes a Notion code block's language type as
+// input and returns a representation more friendly for markdown parsers. For
+// example, Notion uses 'plain text' for Plain Text codeblocks, however most
+// markdown parsers expects this to be specified as 'txt'. For some languages,
+// Notion uses the correct (for Markdown) name. In this cause, the language
+// name passed is returned. If the language is entirely unknown, the language
+// name passed is returned.
+//
+// overrides can optionally be provided to map additional languages, or to
+// replace the built-in mapping for a language. If multiple maps are provided,
+// only the first is respected.
+func ResolveLanguageForCodeBlock(language string, overrides ...map[string]string) string {
+	if len(overrides) > 0 {
+		if val, ok := overrides[0][language]; ok {
+			return val
+		}
+	}
+	if val, ok := languages[language]; ok {
+		return val
+	}
+	return language
 }
 
 func (m *MDRenderer) AddSectionSeperation(previousType string, currentType string, o ...seperationOverride) string {
+	// an HTML table is closed by the first block that isn't one of its rows.
+	if currentType != "table_row" {
+		if close := m.closeHTMLTable(); close != "" {
+			return close + m.AddSectionSeperation(previousType, currentType, o...)
+		}
+	}
+
 	// when a rowOverride function is passed, call it and return its output
 	if len(o) > 0 && o[0] != nil {
 		return o[0](previousType, currentType)
@@ -500,6 +1139,39 @@ func (m *MDRenderer) AddSectionSeperation(previousType string, currentType strin
 
 	case "image":
 		return "\n\n"
+
+	case "file":
+		return "\n\n"
+
+	case "video":
+		return "\n\n"
+
+	case "audio":
+		return "\n\n"
+
+	case "bookmark":
+		return "\n\n"
+
+	case "equation":
+		return "\n\n"
+
+	case "child_page":
+		return "\n\n"
+
+	case "child_database":
+		return "\n\n"
+
+	case "link_to_page":
+		return "\n\n"
+
+	case "synced_block":
+		return "\n\n"
+
+	case "template":
+		return "\n\n"
+
+	case unsupportedBlockType:
+		return "\n\n"
 	}
 
 	// currentType won't be rendered, so don't bother with break.
@@ -507,38 +1179,20 @@ func (m *MDRenderer) AddSectionSeperation(previousType string, currentType strin
 }
 
 // createPadding takes the depth of a block (ie child) and calculates what the
-// appropraite left padding is. It returns a string of spaces representing this
-// padding.
-func createPadding(depth int) string {
-	padding := ""
-	for i := 0; i < depth*4; i++ {
-		padding += " "
-	}
-	return padding
-}
-
-// ResolveImageSaveOptions takes a list of ImageSaveOptions and sets defaults,
-// overwritting them with any options specified. While it takes multiple
-// arguments, it only respects the first option passed.
-func ResolveImageSaveOptions(opts ...ImageSaveOptions) ImageSaveOptions {
-	// setup default
-	config := ImageSaveOptions{
-		SavePath:     defaultImageSaveLocation,
-		IgnoreImages: false,
+// appropraite left padding is. It returns a string of config.IndentChar
+// (spaces by default), repeated config.IndentWidth times per depth.
+func createPadding(depth int, config RenderOptions) string {
+	char := config.IndentChar
+	if char == "" {
+		char = defaultIndentChar
 	}
-
-	// No options were provided; return the default
-	if len(opts) < 1 {
-		return config
-	}
-
-	if opts[0].SavePath != "" {
-		config.SavePath = opts[0].SavePath
-	}
-
-	if opts[0].IgnoreImages {
-		config.IgnoreImages = opts[0].IgnoreImages
+	width := config.IndentWidth
+	if width < 1 {
+		width = defaultIndentWidth
+		// a single tab is a level of indentation on its own.
+		if char == "\t" {
+			width = 1
+		}
 	}
-
-	return config
+	return strings.Repeat(char, depth*width)
 }
@@ -0,0 +1,336 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+func TestMDRenderCodeCaption(t *testing.T) {
+	code := &na.CodeBlock{BasicBlock: basicBlock("c1", na.BlockTypeCode, false),
+		Code: na.Code{Language: "go", RichText: []na.RichText{text("fmt.Println(\"https://example.com\")")},
+			Caption: []na.RichText{text("from https://example.com/main.go")}}}
+	notion := fakeNotion{}.page("code", "Code", code)
+
+	tests := []struct {
+		name string
+		opts RenderOptions
+		want string
+	}{
+		{
+			name: "caption",
+			want: "```go\nfmt.Println(\"https://example.com\")\n```\n_from https://example.com/main.go_",
+		},
+		{
+			name: "caption with bare URLs autolinked",
+			opts: RenderOptions{AutolinkBareURLs: true},
+			want: "```go\nfmt.Println(\"https://example.com\")\n```\n" +
+				"_from <https://example.com/main.go>_",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := newTestExporter(t, "markdown", notion).Render("code", tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if !strings.Contains(string(out), tt.want) {
+				t.Errorf("Render() = %q, want it to contain %q", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestMDRenderBookmark(t *testing.T) {
+	bookmark := func(id string, caption ...na.RichText) na.Block {
+		return &na.BookmarkBlock{BasicBlock: basicBlock(id, na.BlockTypeBookmark, false),
+			Bookmark: na.Bookmark{URL: "https://example.com/post", Caption: caption}}
+	}
+
+	tests := []struct {
+		name  string
+		block na.Block
+		want  string
+	}{
+		{
+			name:  "uncaptioned",
+			block: bookmark("b1"),
+			want:  "[https://example.com/post](https://example.com/post)",
+		},
+		{
+			name:  "captioned",
+			block: bookmark("b1", text("A "), annotated("great", na.Annotations{Bold: true}), text(" post")),
+			want:  "[A **great** post](https://example.com/post)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notion := fakeNotion{}.page("bookmark", "Bookmark", tt.block)
+			out, err := newTestExporter(t, "markdown", notion).Render("bookmark")
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if want := "# Bookmark\n\n" + tt.want; string(out) != want {
+				t.Errorf("Render() = %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestMDRenderTableRowEscaping(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells [][]na.RichText
+		want  string
+	}{
+		{
+			name:  "pipe",
+			cells: [][]na.RichText{{text("a | b")}, {text("c")}},
+			want:  "| a \\| b | c |",
+		},
+		{
+			name:  "pipe in code",
+			cells: [][]na.RichText{{annotated("ls | wc", na.Annotations{Code: true})}, {text("c")}},
+			want:  "| `ls \\| wc` | c |",
+		},
+		{
+			name:  "multiple lines",
+			cells: [][]na.RichText{{text("one\ntwo\r\nthree")}, {text("c")}},
+			want:  "| one<br>two<br>three | c |",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notion := fakeNotion{}.page("table", "Table",
+				&na.TableBlock{BasicBlock: basicBlock("tb1", na.BlockTypeTableBlock, true),
+					Table: na.Table{TableWidth: 2}})
+			notion.children("tb1",
+				&na.TableRowBlock{BasicBlock: basicBlock("r1", na.BlockTypeTableRowBlock, false),
+					TableRow: na.TableRow{Cells: [][]na.RichText{{text("Name")}, {text("Value")}}}},
+				&na.TableRowBlock{BasicBlock: basicBlock("r2", na.BlockTypeTableRowBlock, false),
+					TableRow: na.TableRow{Cells: tt.cells}})
+			out, err := newTestExporter(t, "markdown", notion).Render("table")
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			want := "# Table\n\n| Name | Value |\n| --- | --- |\n" + tt.want
+			if string(out) != want {
+				t.Errorf("Render() = %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestMDNestedBulletedList(t *testing.T) {
+	notion := fakeNotion{}.page("list", "List",
+		bulletedListItem("a", true, text("A")),
+		bulletedListItem("d", false, text("D")))
+	notion.children("a", bulletedListItem("b", true, text("B")), bulletedListItem("b2", false, text("B2")))
+	notion.children("b", bulletedListItem("c", false, text("C")))
+
+	tests := []struct {
+		name string
+		opts RenderOptions
+		want string
+	}{
+		{
+			name: "default",
+			want: "* A\n    * B\n        * C\n    * B2\n* D",
+		},
+		{
+			name: "two space indent",
+			opts: RenderOptions{IndentWidth: 2},
+			want: "* A\n  * B\n    * C\n  * B2\n* D",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := newTestExporter(t, "markdown", notion).Render("list", tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if want := "# List\n\n" + tt.want; string(out) != want {
+				t.Errorf("Render() = %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestMDRenderTextLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   []na.RichText
+		want string
+	}{
+		{
+			name: "link",
+			rt:   []na.RichText{linked(text("docs"), "https://example.com")},
+			want: "[docs](https://example.com)",
+		},
+		{
+			name: "bold link",
+			rt:   []na.RichText{linked(annotated("docs", na.Annotations{Bold: true}), "https://example.com")},
+			want: "[**docs**](https://example.com)",
+		},
+		{
+			name: "italic link",
+			rt:   []na.RichText{linked(annotated("docs", na.Annotations{Italic: true}), "https://example.com")},
+			want: "[_docs_](https://example.com)",
+		},
+		{
+			name: "code link",
+			rt:   []na.RichText{linked(annotated("main.go", na.Annotations{Code: true}), "https://example.com")},
+			want: "[`main.go`](https://example.com)",
+		},
+		{
+			name: "bold italic code link",
+			rt: []na.RichText{linked(annotated("main.go", na.Annotations{Bold: true, Italic: true, Code: true}),
+				"https://example.com")},
+			want: "[**_`main.go`_**](https://example.com)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&MDRenderer{}).RenderText(tt.rt); got != tt.want {
+				t.Errorf("RenderText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMDSeparationAfterChildren(t *testing.T) {
+	tests := []struct {
+		name     string
+		item     na.Block
+		children []na.Block
+		want     string
+	}{
+		{
+			name:     "nested list",
+			item:     bulletedListItem("a", true, text("A")),
+			children: []na.Block{bulletedListItem("b", false, text("B"))},
+			want:     "* A\n    * B\n\nafter",
+		},
+		{
+			name:     "nested paragraph",
+			item:     bulletedListItem("a", true, text("A")),
+			children: []na.Block{paragraph("b", text("B"))},
+			want:     "* A\n\n    B\n\nafter",
+		},
+		{
+			name: "numbered list with nested list",
+			item: &na.NumberedListItemBlock{BasicBlock: basicBlock("a", na.BlockTypeNumberedListItem, true),
+				NumberedListItem: na.ListItem{RichText: []na.RichText{text("A")}}},
+			children: []na.Block{numberedListItem("b", text("B"))},
+			want:     "1. A\n    1. B\n\nafter",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notion := fakeNotion{}.page("list", "List", tt.item, paragraph("p1", text("after")))
+			notion.children("a", tt.children...)
+			out, err := newTestExporter(t, "markdown", notion).Render("list")
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if want := "# List\n\n" + tt.want; string(out) != want {
+				t.Errorf("Render() = %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestMDRenderQuote(t *testing.T) {
+	quote := func(hasChildren bool, s string) na.Block {
+		return &na.QuoteBlock{BasicBlock: basicBlock("q1", "quote", hasChildren),
+			Quote: na.Quote{RichText: []na.RichText{text(s)}}}
+	}
+
+	tests := []struct {
+		name     string
+		quote    na.Block
+		children []na.Block
+		opts     RenderOptions
+		want     string
+	}{
+		{
+			name:  "two lines",
+			quote: quote(false, "line one\nline two"),
+			want:  "> line one\n> line two",
+		},
+		{
+			name:     "nested list",
+			quote:    quote(true, "line one"),
+			children: []na.Block{bulletedListItem("a", false, text("A")), bulletedListItem("b", false, text("B"))},
+			want:     "> line one\n\n    * A\n    * B",
+		},
+		{
+			name:     "nested list within the quote",
+			quote:    quote(true, "line one\nline two"),
+			children: []na.Block{bulletedListItem("a", false, text("A")), bulletedListItem("b", false, text("B"))},
+			opts:     RenderOptions{QuoteChildren: true},
+			want:     "> line one\n> line two\n>\n> * A\n> * B",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notion := fakeNotion{}.page("quote", "Quote", tt.quote, paragraph("p1", text("after")))
+			notion.children("q1", tt.children...)
+			out, err := newTestExporter(t, "markdown", notion).Render("quote", tt.opts)
+			if err != nil {
+				t.Fatalf("Render() error: %s", err)
+			}
+			if want := "# Quote\n\n" + tt.want + "\n\nafter"; string(out) != want {
+				t.Errorf("Render() = %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestMDRenderTextLinkSpacing(t *testing.T) {
+	withHref := func(t na.RichText, href string) na.RichText {
+		t.Href = href
+		return t
+	}
+
+	tests := []struct {
+		name string
+		rt   []na.RichText
+		want string
+	}{
+		{
+			name: "trailing space",
+			rt:   []na.RichText{text("foo "), linked(text("bar "), "https://example.com"), text("baz")},
+			want: "foo [bar](https://example.com) baz",
+		},
+		{
+			name: "leading space",
+			rt:   []na.RichText{text("foo"), linked(text(" bar"), "https://example.com"), text(" baz")},
+			want: "foo [bar](https://example.com) baz",
+		},
+		{
+			name: "leading and trailing spaces",
+			rt:   []na.RichText{text("foo"), linked(text("  bar  "), "https://example.com"), text("baz")},
+			want: "foo  [bar](https://example.com)  baz",
+		},
+		{
+			name: "only spaces",
+			rt:   []na.RichText{text("foo"), linked(text(" "), "https://example.com"), text("baz")},
+			want: "foo baz",
+		},
+		{
+			name: "link differing from Href",
+			rt: []na.RichText{withHref(linked(text("page "), "/abc123"),
+				"https://www.notion.so/abc123"), text("next")},
+			want: "[page](/abc123) next",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&MDRenderer{}).RenderText(tt.rt); got != tt.want {
+				t.Errorf("RenderText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
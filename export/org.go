@@ -0,0 +1,676 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	orgTitlePattern          = "#+TITLE: %s"
+	orgHeadingMarker         = "*"
+	orgLinkPattern           = "[[%s][%s]]"
+	orgImagePattern          = "[[%s]]"
+	orgBoldPattern           = "*%s*"
+	orgItalicPattern         = "/%s/"
+	orgStrikeThroughPattern  = "+%s+"
+	orgInlineCodePattern     = "~%s~"
+	orgListItemPattern       = "- %s"
+	orgNumItemPattern        = "%s %s"
+	orgTodoUncheckedPattern  = "- [ ] %s"
+	orgTodoCheckedPattern    = "- [X] %s"
+	orgCaptionPattern        = "#+CAPTION: %s"
+	orgSrcBlockPattern       = "#+BEGIN_SRC %s\n%s\n#+END_SRC"
+	orgQuotePattern          = "#+BEGIN_QUOTE\n%s\n#+END_QUOTE"
+	orgCalloutPattern        = "#+BEGIN_NOTE\n%s\n#+END_NOTE"
+	orgExportHTMLPattern     = "#+BEGIN_EXPORT html\n%s\n#+END_EXPORT"
+	orgDividerPattern        = "-----"
+	orgEquationPattern       = "\\[\n%s\n\\]"
+	orgInlineEquationPattern = "\\(%s\\)"
+	orgUnsupportedPattern    = "# unsupported: %s"
+	orgCommentPattern        = "# %s"
+	// orgCustomIDPattern is a property drawer setting a heading's
+	// CUSTOM_ID, which links of the form [[#id]] resolve to.
+	orgCustomIDPattern     = ":PROPERTIES:\n:CUSTOM_ID: %s\n:END:"
+	orgTableElementPattern = "| %s "
+	orgTableRuleCell       = "---"
+	orgFileLinkPrefix      = "file:"
+)
+
+var (
+	// orgTableCellReplacer escapes cell text that would otherwise break the
+	// structure of a table. Pipes delimit cells and a table row can't span
+	// lines, so line breaks are replaced with spaces.
+	orgTableCellReplacer = strings.NewReplacer("|", "\\vert{}", "\r\n", " ", "\n", " ")
+	// orgLinkReplacer escapes brackets in the target or description of a
+	// link, which would otherwise end it early.
+	orgLinkReplacer = strings.NewReplacer("[", "\\[", "]", "\\]")
+)
+
+// OrgRenderer renders a Notion page as Org, the markup of Emacs' Org mode. The
+// page's title is set with #+TITLE, so Notion's headings map to the first
+// three levels of Org headings: "*", "**", and "***", deepened for headings
+// nested in other blocks. Other nested blocks are indented, which Org uses to
+// nest list items.
+type OrgRenderer struct {
+}
+
+// RenderPageHeader for OrgRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, it
+// defaults to returning the title of the page as a #+TITLE keyword.
+func (r *OrgRenderer) RenderPageHeader(page *na.Page,
+	o ...headerFooterOverride) string {
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return fmt.Sprintf(orgTitlePattern, ResolveTitleInPage(page))
+}
+
+// RenderPageFooter for OrgRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, a
+// blank footer is returned.
+func (r *OrgRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return ""
+}
+
+// RenderPageHeader1 for OrgRenderer returns the Block's text as a level 1
+// heading, "* ", with a "*" added for each level of depth. If an override is
+// provided, that function is run and returned value is used instead.
+func (r *OrgRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	return r.renderHeading(1, b, o...)
+}
+
+// RenderPageHeader2 for OrgRenderer returns the Block's text as a level 2
+// heading, "** ", with a "*" added for each level of depth. If an override is
+// provided, that function is run and returned value is used instead.
+func (r *OrgRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	return r.renderHeading(2, b, o...)
+}
+
+// RenderPageHeader3 for OrgRenderer returns the Block's text as a level 3
+// heading, "*** ", with a "*" added for each level of depth. If an override
+// is provided, that function is run and returned value is used instead.
+func (r *OrgRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	return r.renderHeading(3, b, o...)
+}
+
+// RenderParagraph for OrgRenderer returns the Block's text. If an override is
+// provided, that function is run and returned value is used instead.
+func (r *OrgRenderer) RenderParagraph(b *Block, o ...blockOverride) string {
+	return r.renderPattern("%s", b, o...)
+}
+
+// RenderDivider for OrgRenderer returns "-----", a horizontal rule. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return orgDividerPattern
+}
+
+// RenderNumberedList for OrgRenderer returns the Block's text prepended with
+// its number in the list, e.g. "1. ", styled by RenderOptions.OrderedListStyle.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(orgNumItemPattern, resolveListMarker(b), b.Text)
+}
+
+// RenderBulletedList for OrgRenderer returns the Block's text prepended with
+// "- ". If an override is provided, that function is run and returned value is
+// used instead.
+func (r *OrgRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
+	return r.renderPattern(orgListItemPattern, b, o...)
+}
+
+// RenderTodoList for OrgRenderer returns the Block's text as a checkbox item,
+// "- [ ] " or "- [X] " when checked. If an override is provided, that function
+// is run and returned value is used instead.
+func (r *OrgRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a ToDoBlock (e.g. passed from a custom override
+	// pipeline), there is no checked state to read, so render it unchecked.
+	tb, ok := b.BlockRef.(*na.ToDoBlock)
+	if ok && tb.ToDo.Checked {
+		return fmt.Sprintf(orgTodoCheckedPattern, b.Text)
+	}
+	return fmt.Sprintf(orgTodoUncheckedPattern, b.Text)
+}
+
+// RenderCallout for OrgRenderer returns the Block's text within a NOTE block,
+// prefixed with the callout's icon: emoji as is and images as an image link.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	txt := b.Text
+	switch emoji, src := resolveCalloutIcon(b); {
+	case emoji != "":
+		txt = emoji + " " + txt
+	case src != "":
+		txt = fmt.Sprintf(orgImagePattern, resolveOrgLinkTarget(src)) + " " + txt
+	}
+	return fmt.Sprintf(orgCalloutPattern, txt)
+}
+
+// RenderQuote for OrgRenderer returns the Block's text within a QUOTE block.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderQuote(b *Block, o ...blockOverride) string {
+	return r.renderPattern(orgQuotePattern, b, o...)
+}
+
+// RenderCode for OrgRenderer returns the code within a SRC block for its
+// language. The caption, when present, precedes the block as a #+CAPTION
+// keyword. If an override is provided, that function is run and returned
+// value is used instead.
+func (r *OrgRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when the block isn't a CodeBlock (e.g. passed from a custom override
+	// pipeline), there is no language or caption to read.
+	cb, ok := b.BlockRef.(*na.CodeBlock)
+	if !ok {
+		return fmt.Sprintf(orgSrcBlockPattern, "", b.Text)
+	}
+
+	language := ResolveLanguageForCodeBlock(cb.Code.Language,
+		resolveRenderConfig(b.Opts...).LanguageOverrides)
+	// Org identifies languages by a single word.
+	src := fmt.Sprintf(orgSrcBlockPattern, strings.ReplaceAll(language, " ", "-"), b.Text)
+	if caption := ResolveCodeCaption(b); caption != "" {
+		src = fmt.Sprintf(orgCaptionPattern, caption) + "\n" + src
+	}
+
+	return src
+}
+
+// RenderImage for OrgRenderer returns a link to the image, which Org displays
+// inline. Images hosted in Notion are downloaded. When
+// RenderOptions.CaptionImages is set, the caption precedes the image as a
+// #+CAPTION keyword. If an override is provided, that function is run and
+// returned value is used instead.
+func (r *OrgRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ib, ok := b.BlockRef.(*na.ImageBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderImage was passed a %s but expected an ImageBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	var src string
+	switch {
+	case ib.Image.External != nil:
+		src = ib.Image.External.URL
+	case ib.Image.File == nil:
+		return "", errImageWithoutSource(ib)
+	default:
+		filePath, err := saveImageBlock(ib, config.ImageOpts)
+		if err != nil {
+			return "", err
+		}
+		src = filePath
+	}
+
+	img := fmt.Sprintf(orgImagePattern, resolveOrgLinkTarget(src))
+	if config.CaptionImages && b.Text != "" {
+		img = fmt.Sprintf(orgCaptionPattern, b.Text) + "\n" + img
+	}
+
+	return img, nil
+}
+
+// RenderFile for OrgRenderer returns a link to the file. Files hosted in
+// Notion are downloaded and linked to locally, while external files are linked
+// to directly. The caption is used as the link description when present,
+// otherwise the name of the file is used. If an override is provided, that
+// function is run and returned value is used instead.
+func (r *OrgRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderFile was passed a %s but expected a FileBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveFileBlockPath(fb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = resolveFileName(filePath)
+	}
+	return renderOrgLink(filePath, linkTxt), nil
+}
+
+// RenderVideo for OrgRenderer returns a link to the video. Videos hosted in
+// Notion are downloaded and linked to locally. When RenderOptions.EmbedVideos
+// is set, the video is embedded using HTML within an EXPORT block instead. The
+// caption is used as the link description when present, otherwise the
+// location of the video is used. If an override is provided, that function is
+// run and returned value is used instead.
+func (r *OrgRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderVideo was passed a %s but expected a VideoBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveVideoBlockPath(vb, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+	if config.EmbedVideos {
+		if embed, ok := resolveVideoEmbed(vb, filePath); ok {
+			return fmt.Sprintf(orgExportHTMLPattern, embed), nil
+		}
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = filePath
+	}
+	return renderOrgLink(filePath, linkTxt), nil
+}
+
+// RenderAudio for OrgRenderer returns a link to the audio. Audio hosted in
+// Notion is downloaded and linked to locally. The caption is used as the link
+// description when present, otherwise the name of the audio file is used. If
+// an override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok {
+		return "", fmt.Errorf("RenderAudio was passed a %s but expected an AudioBlock", b.BlockRef.GetType())
+	}
+
+	config := resolveRenderConfig(b.Opts...)
+	filePath, err := resolveAudioBlockPath(ab, config.ImageOpts)
+	if err != nil {
+		return "", err
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = resolveFileName(filePath)
+	}
+	return renderOrgLink(filePath, linkTxt), nil
+}
+
+// RenderBookmark for OrgRenderer returns a link to the bookmarked URL. When
+// the bookmark has a caption, it is used as the link description, otherwise
+// the URL itself is used. If an override is provided, that function is run and
+// returned value is used instead.
+func (r *OrgRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	bb, ok := b.BlockRef.(*na.BookmarkBlock)
+	if !ok {
+		return b.Text
+	}
+
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = bb.Bookmark.URL
+	}
+	return renderOrgLink(bb.Bookmark.URL, linkTxt)
+}
+
+// RenderEquation for OrgRenderer returns the LaTeX expression as a display
+// math fragment, delimited by \[ and \]. If an override is provided, that
+// function is run and returned value is used instead.
+func (r *OrgRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	return r.renderPattern(orgEquationPattern, b, o...)
+}
+
+// RenderChildPage for OrgRenderer returns a link to the file the subpage was
+// exported to, using the subpage's title as the link description. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fileName := ResolveChildPageFile(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = fileName
+	}
+	return renderOrgLink(fileName, linkTxt)
+}
+
+// RenderChildDatabase for OrgRenderer returns a link to the database in
+// Notion, using the database's title as the link text. If an override is
+// provided, that function is run and returned value is used instead.
+func (r *OrgRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	url := ResolveChildDatabaseURL(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = url
+	}
+	return renderOrgLink(url, linkTxt)
+}
+
+// RenderLinkToPage for OrgRenderer returns a link to the linked page, using
+// its title as the link text, or the target of the link when the title is
+// unknown. If an override is provided, that function is run and returned
+// value is used instead.
+func (r *OrgRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	target := ResolveLinkToPageTarget(b)
+	linkTxt := b.Text
+	if linkTxt == "" {
+		linkTxt = target
+	}
+	return renderOrgLink(target, linkTxt)
+}
+
+// RenderColumnList for OrgRenderer returns nothing, as Org has no concept of
+// columns and their content is rendered sequentially. If an override is
+// provided, that function is run and returned value is used instead.
+func (r *OrgRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderColumn for OrgRenderer returns nothing, as the column's content is
+// rendered sequentially. If an override is provided, that function is run and
+// returned value is used instead.
+func (r *OrgRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderTemplate for OrgRenderer returns the label of a template button in
+// bold, introducing the template's content, which is rendered after it. An
+// empty label returns nothing. If an override is provided, that function is
+// run and returned value is used instead.
+func (r *OrgRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.Text == "" {
+		return ""
+	}
+	return fmt.Sprintf(orgBoldPattern, b.Text)
+}
+
+// RenderUnsupported for OrgRenderer returns a comment naming the type of the
+// block, e.g. # unsupported: breadcrumb, when
+// RenderOptions.MarkUnsupportedBlocks is set. Otherwise, nothing is returned.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if !resolveRenderConfig(b.Opts...).MarkUnsupportedBlocks {
+		return ""
+	}
+	return fmt.Sprintf(orgUnsupportedPattern, b.BlockRef.GetType())
+}
+
+// RenderComment for OrgRenderer returns text as a comment line.
+func (r *OrgRenderer) RenderComment(text string) string {
+	return fmt.Sprintf(orgCommentPattern, text)
+}
+
+// RenderTableRow for OrgRenderer returns the row's cells delimited by pipes.
+// The first row of a table is followed by a horizontal rule, which Org uses to
+// mark it as the header.
+func (r *OrgRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
+	// when a rowOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](cells)
+	}
+
+	var row string
+	var currentRow int
+	for _, c := range cells {
+		currentRow = c.tableRef.currentRow
+		txt := orgTableCellReplacer.Replace(c.rowTxt)
+		if c.isColumnHeader && c.tableRef.boldColumnHeader && txt != "" {
+			txt = fmt.Sprintf(orgBoldPattern, txt)
+		}
+		row += fmt.Sprintf(orgTableElementPattern, txt)
+	}
+	row += "|"
+	// when row is the first, it's a header
+	if currentRow == 0 {
+		rule := make([]string, len(cells))
+		for i := range rule {
+			rule[i] = orgTableRuleCell
+		}
+		row += "\n|" + strings.Join(rule, "+") + "|"
+	}
+	return row
+}
+
+// RenderText takes the RichText object from the Notion API and converts it to
+// Org, rewriting all formatting required. Examples are text that is bold,
+// italicised, or a hyperlink.
+func (r *OrgRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](rt)
+	}
+
+	var parsed string
+	for _, t := range rt {
+		var content string
+		switch t.Type {
+		// text is an inline equation. The Notion API sets the plain text of an
+		// equation to its LaTeX expression.
+		case "equation":
+			parsed += fmt.Sprintf(orgInlineEquationPattern, t.PlainText)
+			continue
+
+		// text is a mention of a page, database, user, or date. Page and
+		// database mentions carry the Notion URL of the target in Href.
+		case "mention":
+			content = resolveMentionText(t)
+
+		default:
+			content = t.Text.Content
+		}
+
+		// each annotation applied to the text wraps it, so text that is both
+		// bold and italicised keeps both. Links are outermost, with the
+		// formatting inside the link's description (e.g. [[url][*text*]]).
+		address := resolveLinkURL(t)
+		if address == "" {
+			parsed += renderOrgAnnotations(content, t.Annotations)
+			continue
+		}
+		leading, content, trailing := splitSurroundingSpace(content)
+		if content != "" {
+			content = renderOrgLink(address, renderOrgAnnotations(content, t.Annotations))
+		}
+		parsed += leading + content + trailing
+	}
+	// Notion uses smart quotes by default, replace them with normal quotes.
+	parsed = unicodeQuoteReplacer.Replace(parsed)
+
+	return parsed
+}
+
+// AddPadding for OrgRenderer indents each line of the Block's text based on
+// its depth, the same as MDRenderer. Org nests list items by indentation.
+// Headings are never indented, as they must start a line.
+func (r *OrgRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	switch b.BlockRef.GetType() {
+	case "heading_1", "heading_2", "heading_3":
+		return b.Text
+	}
+
+	return (&MDRenderer{}).AddPadding(b)
+}
+
+// AddBlockEnd for OrgRenderer returns nothing, as the blocks it renders with
+// children have no closing syntax. If an override is provided, that function
+// is run and returned value is used instead.
+func (r *OrgRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// AddSectionSeperation for OrgRenderer separates blocks the same as
+// MDRenderer: consecutive list items and table rows by a single line break,
+// and everything else by a blank line.
+func (r *OrgRenderer) AddSectionSeperation(previousType string, currentType string,
+	o ...seperationOverride) string {
+
+	return (&MDRenderer{}).AddSectionSeperation(previousType, currentType, o...)
+}
+
+// renderHeading returns the Block's text as a heading of level, deepened by
+// the Block's depth, as Org expresses nesting through a heading's level. When
+// the heading has an anchor, it's set as the heading's CUSTOM_ID. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) renderHeading(level int, b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	heading := strings.Repeat(orgHeadingMarker, level+b.Depth) + " " + b.Text
+	if anchor := ResolveHeadingAnchor(b); anchor != "" {
+		heading += "\n" + fmt.Sprintf(orgCustomIDPattern, anchor)
+	}
+	return heading
+}
+
+// renderPattern returns the Block's text formatted with pattern. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (r *OrgRenderer) renderPattern(pattern string, b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(pattern, b.Text)
+}
+
+// renderOrgLink returns a link to target with text as its description.
+func renderOrgLink(target, text string) string {
+	return fmt.Sprintf(orgLinkPattern, resolveOrgLinkTarget(target), orgLinkReplacer.Replace(text))
+}
+
+// renderOrgAnnotations returns content wrapped in the syntax for each of the
+// annotations applied to it, with inline code innermost. Underlines and colors
+// aren't rendered.
+func renderOrgAnnotations(content string, a *na.Annotations) string {
+	if a == nil || content == "" {
+		return content
+	}
+	if a.Code {
+		content = fmt.Sprintf(orgInlineCodePattern, content)
+	}
+	if a.Strikethrough {
+		content = fmt.Sprintf(orgStrikeThroughPattern, content)
+	}
+	if a.Italic {
+		content = fmt.Sprintf(orgItalicPattern, content)
+	}
+	if a.Bold {
+		content = fmt.Sprintf(orgBoldPattern, content)
+	}
+	return content
+}
+
+// resolveOrgLinkTarget returns target as the target of an Org link. Targets
+// without a scheme, such as downloaded images, are local files, so are
+// prefixed with "file:".
+func resolveOrgLinkTarget(target string) string {
+	if u, err := url.Parse(target); err != nil || u.Scheme == "" {
+		target = orgFileLinkPrefix + target
+	}
+	return orgLinkReplacer.Replace(target)
+}
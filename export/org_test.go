@@ -0,0 +1,53 @@
+package export
+
+import (
+	"testing"
+
+	na "github.com/jomei/notionapi"
+)
+
+func TestOrgRenderTextAnnotations(t *testing.T) {
+	tests := []struct {
+		name string
+		rt   []na.RichText
+		want string
+	}{
+		{
+			name: "no annotations",
+			rt:   []na.RichText{text("plain")},
+			want: "plain",
+		},
+		{
+			name: "bold and italic",
+			rt:   []na.RichText{annotated("both", na.Annotations{Bold: true, Italic: true})},
+			want: "*/both/*",
+		},
+		{
+			name: "link without annotations",
+			rt:   []na.RichText{linked(text("docs"), "https://example.com")},
+			want: "[[https://example.com][docs]]",
+		},
+		{
+			name: "bold link",
+			rt:   []na.RichText{linked(annotated("docs", na.Annotations{Bold: true}), "https://example.com")},
+			want: "[[https://example.com][*docs*]]",
+		},
+		{
+			name: "code link",
+			rt:   []na.RichText{linked(annotated("nexp", na.Annotations{Code: true}), "https://example.com")},
+			want: "[[https://example.com][~nexp~]]",
+		},
+		{
+			name: "link with surrounding spaces",
+			rt:   []na.RichText{text("see"), linked(text(" docs "), "https://example.com"), text("now")},
+			want: "see [[https://example.com][docs]] now",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (&OrgRenderer{}).RenderText(tt.rt); got != tt.want {
+				t.Errorf("RenderText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package export
+
+// This file contains the state and helpers used to export pages referenced by
+// child_page blocks into files of their own.
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	untitledPageSlug = "untitled"
+	// manifestFileName is the name of the file, in RenderOptions.PagesDir,
+	// the Manifest of a recursive export is written to.
+	manifestFileName = "manifest.json"
+	// pageIDSuffixLength is the number of characters of a page's ID added to
+	// its file name when another page's file has the same name.
+	pageIDSuffixLength = 8
+)
+
+var (
+	// pageIDPattern matches a dashless page UUID at the end of a string, as
+	// found in the final path segment of a Notion URL (e.g. Climbing-<uuid>).
+	pageIDPattern = regexp.MustCompile("[0-9a-f]{32}$")
+)
+
+// pageExportState tracks the pages that are part of a recursive export. It's
+// shared by pointer across every RenderOptions copy made while rendering, so
+// pages found at any depth are known to the entire export.
+type pageExportState struct {
+	// files maps the ID of every page in the export to the name of the file it
+	// is written to. The root page maps to an empty name, as the caller
+	// decides where it is written.
+	files map[string]string
+	// names maps the name of every file claimed by a page to the page's ID.
+	names map[string]string
+	// queue contains pages found in child_page blocks that are yet to be
+	// rendered.
+	queue []queuedPage
+	// found contains every page queued, in the order they were found, which
+	// follows the page hierarchy (a page comes before its subpages).
+	found []queuedPage
+	// blocks holds the children retrieved from Notion while discovering the
+	// pages in the export, keyed by blockChildrenKey, so they aren't
+	// retrieved again when rendered. Entries are removed once used.
+	blocks map[string]*na.GetChildrenResponse
+	// edited maps the ID of every page retrieved in the export to when it was
+	// last edited.
+	edited map[string]time.Time
+	// previousFiles and previousEdited are the file and last edit of every
+	// page recorded in the Manifest of an earlier export, restored for
+	// RenderOptions.SkipUnchangedPages.
+	previousFiles  map[string]string
+	previousEdited map[string]time.Time
+	// root is the ID of the page, or database, the export started at, and
+	// rootTitle its title, once retrieved.
+	root      string
+	rootTitle string
+}
+
+// queuedPage is a page waiting to be exported to fileName.
+type queuedPage struct {
+	id       string
+	fileName string
+	title    string
+	// parent is the ID of the page containing this one, when known.
+	parent string
+}
+
+// newPageExportState returns the state for a recursive export starting at the
+// page rootID.
+func newPageExportState(rootID string) *pageExportState {
+	return &pageExportState{
+		files:  map[string]string{normalizePageID(rootID): ""},
+		root:   normalizePageID(rootID),
+		names:  map[string]string{},
+		blocks: map[string]*na.GetChildrenResponse{},
+		edited: map[string]time.Time{},
+	}
+}
+
+// restore sets up the state to continue the export recorded in m. Pages keep
+// the file they were written to, which is claimed up front so no other page
+// is written to it, as the files of pages that aren't exported again still
+// link to it.
+func (s *pageExportState) restore(m Manifest) {
+	s.previousFiles = map[string]string{}
+	for id, p := range m.Pages {
+		if p.Path == "" {
+			continue
+		}
+		s.previousFiles[id] = p.Path
+		s.names[p.Path] = id
+	}
+	s.previousEdited = m.LastEditedTimes
+}
+
+// enqueue adds the page id, found in the page parent, to the export, to be
+// written to a file named using its title and ext. When another page's file
+// already has the name, the start of the page's ID is added to it (e.g.
+// notes-1a2b3c4d.md). The name of the file is returned. When the page is
+// already part of the export, it is not queued again and its existing file
+// name is returned, which prevents circular references from looping forever.
+func (s *pageExportState) enqueue(id, title, parent, ext string) string {
+	key := normalizePageID(id)
+	if fileName, ok := s.files[key]; ok {
+		return fileName
+	}
+
+	fileName, ok := s.previousFiles[key]
+	if !ok || path.Ext(fileName) != ext {
+		fileName = s.claimFileName(key, slugify(title), ext)
+	}
+	s.files[key] = fileName
+	p := queuedPage{id: id, fileName: fileName, title: title, parent: parent}
+	s.queue = append(s.queue, p)
+	s.found = append(s.found, p)
+
+	return fileName
+}
+
+// claimFileName returns the name of the file the page key, whose title is
+// slug, is written to, claiming it so no other page is written to the same
+// file. Pages are claimed in the order they're found, which is the same for
+// every export of a page, so each page keeps its file name across exports.
+func (s *pageExportState) claimFileName(key, slug, ext string) string {
+	suffix := key
+	if len(suffix) > pageIDSuffixLength {
+		suffix = suffix[:pageIDSuffixLength]
+	}
+	// the full ID is the last resort, as it's unique to the page.
+	for _, candidate := range []string{slug + ext, slug + "-" + suffix + ext, slug + "-" + key + ext} {
+		if _, ok := s.names[candidate]; !ok {
+			s.names[candidate] = key
+			return candidate
+		}
+	}
+	return slug + "-" + key + ext
+}
+
+// next removes and returns the next page waiting to be exported. false is
+// returned when the queue is empty.
+func (s *pageExportState) next() (queuedPage, bool) {
+	if len(s.queue) < 1 {
+		return queuedPage{}, false
+	}
+	p := s.queue[0]
+	s.queue = s.queue[1:]
+
+	return p, true
+}
+
+// pageRetrieved records when the page p, identified by id, was last edited
+// and, when it's the root page, its title. A nil state records nothing.
+func (s *pageExportState) pageRetrieved(id string, p *na.Page) {
+	if s == nil {
+		return
+	}
+	key := normalizePageID(id)
+	s.edited[key] = p.LastEditedTime
+	if key == s.root {
+		s.rootTitle = ResolveTitleInPage(p)
+	}
+}
+
+// previousEdit returns when the page id was last edited according to the
+// Manifest the state was restored from. false is returned when it isn't
+// recorded.
+func (s *pageExportState) previousEdit(id string) (time.Time, bool) {
+	if s == nil {
+		return time.Time{}, false
+	}
+	t, ok := s.previousEdited[normalizePageID(id)]
+	return t, ok
+}
+
+// Manifest records the files the pages of a recursive export were written to.
+// It's written to manifest.json in RenderOptions.PagesDir once every subpage
+// has been exported.
+type Manifest struct {
+	// Pages maps the ID of every subpage in the export, without dashes, to
+	// where it was written. The root page is left out, as the caller decides
+	// where it's written.
+	Pages map[string]ManifestPage `json:"pages"`
+	// LastEditedTimes maps the ID of every page in the export, without
+	// dashes and including the root page, to when it was last edited, as
+	// of the export. It's used by RenderOptions.SkipUnchangedPages.
+	LastEditedTimes map[string]time.Time `json:"last_edited_times,omitempty"`
+}
+
+// ManifestPage records where a page of a recursive export was written.
+type ManifestPage struct {
+	// Path is the file the page was written to, relative to PagesDir.
+	Path  string `json:"path"`
+	Title string `json:"title"`
+	// Parent is the ID, without dashes, of the page containing this one.
+	// It's empty when not known.
+	Parent string `json:"parent,omitempty"`
+}
+
+// manifest returns the Manifest of every page found in the export.
+func (s *pageExportState) manifest() Manifest {
+	m := Manifest{Pages: make(map[string]ManifestPage, len(s.found))}
+	for _, p := range s.found {
+		m.Pages[normalizePageID(p.id)] = ManifestPage{
+			Path:   p.fileName,
+			Title:  p.title,
+			Parent: normalizePageID(p.parent),
+		}
+	}
+	if len(s.edited) > 0 {
+		m.LastEditedTimes = make(map[string]time.Time, len(s.edited))
+		for id, t := range s.edited {
+			m.LastEditedTimes[id] = t
+		}
+	}
+	return m
+}
+
+// ReadManifest reads the Manifest written to dir by an earlier recursive
+// export. An empty Manifest is returned when dir has none.
+func ReadManifest(dir string) (Manifest, error) {
+	var m Manifest
+	b, err := os.ReadFile(filepath.Join(dir, manifestFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return m, fmt.Errorf("Failed reading manifest of earlier export, error: %s", err)
+	}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, fmt.Errorf("Failed parsing manifest of earlier export (%s), error: %s",
+			filepath.Join(dir, manifestFileName), err)
+	}
+	return m, nil
+}
+
+// cacheChildren stores children, the blocks retrieved for the block id
+// starting at cursor, until they are rendered.
+func (s *pageExportState) cacheChildren(id, cursor string, children *na.GetChildrenResponse) {
+	s.blocks[blockChildrenKey(id, cursor)] = children
+}
+
+// cachedChildren removes and returns the blocks cached for the block id
+// starting at cursor. false is returned when none were cached.
+func (s *pageExportState) cachedChildren(id, cursor string) (*na.GetChildrenResponse, bool) {
+	key := blockChildrenKey(id, cursor)
+	children, ok := s.blocks[key]
+	delete(s.blocks, key)
+
+	return children, ok
+}
+
+// blockChildrenKey returns the key used to cache the children of the block id
+// retrieved starting at cursor.
+func blockChildrenKey(id, cursor string) string {
+	return normalizePageID(id) + "/" + cursor
+}
+
+// rewriteLinks returns rt with every link to a page in the export, including
+// page mentions, pointing at the file the page is exported to. Links to pages
+// outside of the export, and to the root page, whose file isn't known, are
+// left as is. rt is not modified.
+func (s *pageExportState) rewriteLinks(rt []na.RichText) []na.RichText {
+	var rewritten []na.RichText
+	for i, t := range rt {
+		fileName, ok := s.resolveLink(resolveLinkURL(t))
+		if !ok {
+			continue
+		}
+		if rewritten == nil {
+			rewritten = append([]na.RichText{}, rt...)
+		}
+		rewritten[i] = withLinkURL(t, fileName)
+	}
+
+	if rewritten == nil {
+		return rt
+	}
+	return rewritten
+}
+
+// resolveLink returns the name of the file the page linked to by href is
+// exported to. href may be a Notion URL or a path relative to Notion, such as
+// /de4d2477f3214ec98614fd46a4e1487f. false is returned when href does not link
+// to a page written to a file in the export.
+func (s *pageExportState) resolveLink(href string) (string, bool) {
+	id, ok := parseNotionPageLink(href)
+	if !ok {
+		return "", false
+	}
+	fileName := s.files[id]
+
+	return fileName, fileName != ""
+}
+
+// parseNotionPageLink returns the ID of the page linked to by href, which may
+// be a Notion URL or a path relative to Notion, such as
+// /de4d2477f3214ec98614fd46a4e1487f. false is returned when href doesn't link
+// to a Notion page.
+func parseNotionPageLink(href string) (string, bool) {
+	if href == "" {
+		return "", false
+	}
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	host := strings.ToLower(u.Host)
+	if host != "" && host != "notion.so" && !strings.HasSuffix(host, ".notion.so") &&
+		!strings.HasSuffix(host, ".notion.site") {
+		return "", false
+	}
+	id, err := ParsePageID(href)
+	if err != nil {
+		return "", false
+	}
+	return id, true
+}
+
+// ParsePageID extracts the UUID of a Notion page from s, which may be the
+// UUID itself, with or without dashes and in any case, or a Notion URL such as
+// https://www.notion.so/joshrosso/Climbing-de4d2477f3214ec98614fd46a4e1487f.
+// For URLs, with or without a scheme, the UUID is taken from the end of the
+// final path segment, so query parameters (e.g. a database view's ?v=) are
+// ignored. The UUID is returned as
+// 32 lowercase characters without dashes. An error is returned when no UUID is
+// found.
+func ParsePageID(s string) (string, error) {
+	candidate := strings.TrimSpace(s)
+	if u, err := url.Parse(candidate); err == nil {
+		candidate = path.Base(u.Path)
+	}
+	candidate = strings.ToLower(normalizePageID(candidate))
+
+	id := pageIDPattern.FindString(candidate)
+	if id == "" {
+		return "", fmt.Errorf("Could not detect valid page UUID in %s", s)
+	}
+	return id, nil
+}
+
+// normalizePageID returns id without dashes, as Notion accepts page IDs both
+// with and without them.
+func normalizePageID(id string) string {
+	return strings.ReplaceAll(id, "-", "")
+}
+
+// slugify returns a lowercase, filesystem-friendly representation of s where
+// every run of characters that aren't letters or numbers is replaced with a
+// single "-". For example, "Climbing: Gear & Notes" returns
+// "climbing-gear-notes". When nothing remains, "untitled" is returned.
+func slugify(s string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(s) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingDash = b.Len() > 0
+			continue
+		}
+		if pendingDash {
+			b.WriteRune('-')
+			pendingDash = false
+		}
+		b.WriteRune(r)
+	}
+
+	if b.Len() < 1 {
+		return untitledPageSlug
+	}
+	return b.String()
+}
+
+// ResolveChildPageFile returns the name of the file the subpage in a
+// child_page Block is exported to, relative to RenderOptions.PagesDir. An
+// empty string is returned when the Block was not passed this state (e.g. it
+// was rendered outside of a recursive export).
+func ResolveChildPageFile(b *Block) string {
+	config := resolveRenderConfig(b.Opts...)
+	return config.childPageFile
+}
+
+// ResolveLinkToPageTarget returns the target of the link in a link_to_page
+// Block: the file the linked page is exported to, relative to
+// RenderOptions.PagesDir, when it's part of a recursive export, otherwise its
+// URL in Notion. An empty string is returned when the Block was not passed
+// this state (e.g. it was rendered outside of an exporter).
+func ResolveLinkToPageTarget(b *Block) string {
+	config := resolveRenderConfig(b.Opts...)
+	return config.linkTarget
+}
+
+// ResolvePageFileExtension returns the extension of files written by r (e.g.
+// ".md"), used when naming the files pages are exported to, such as in a
+// recursive export. Unknown renderers use the extension of the default format,
+// markdown.
+func ResolvePageFileExtension(r Renderer) string {
+	switch r.(type) {
+	case *HTMLRenderer:
+		return ".html"
+	case *ConfluenceRenderer:
+		return ".xml"
+	case *epubRenderer:
+		return epubXHTMLExt
+	case *JSONRenderer:
+		return ".json"
+	case *TextRenderer:
+		return ".txt"
+	case *AsciiDocRenderer:
+		return ".adoc"
+	case *OrgRenderer:
+		return ".org"
+	case *LaTeXRenderer:
+		return ".tex"
+	}
+
+	return ".md"
+}
@@ -0,0 +1,132 @@
+package export
+
+// This file contains the logic used to download the files referenced by a set
+// of blocks concurrently, ahead of rendering them.
+
+import (
+	"sync"
+
+	na "github.com/jomei/notionapi"
+)
+
+// downloadResult is the outcome of a download made ahead of rendering.
+type downloadResult struct {
+	path string
+	err  error
+}
+
+// prefetchedDownloads holds the results of downloads made ahead of rendering,
+// keyed by the ID of the block referencing the file. It's only read once every
+// download has finished.
+type prefetchedDownloads map[string]downloadResult
+
+// downloadJob downloads a single file, returning its local path.
+type downloadJob func() (string, error)
+
+// prefetchDownloads downloads every Notion-hosted file referenced by the image,
+// file, and video blocks in blocks, using up to
+// config.ImageOpts.DownloadConcurrency downloads at once. Blocks whose type has
+// an override are skipped, as the override may not need the file. Blocks that
+// reference the same file share a single download. Errors are not returned,
+// but kept with each result so they surface when the block is rendered.
+func prefetchDownloads(blocks []na.Block, config RenderOptions) prefetchedDownloads {
+	opts := config.ImageOpts
+	// jobs are keyed by what they download, so duplicates are only fetched
+	// once.
+	jobs := map[string]downloadJob{}
+	var keys []string
+	blockKeys := map[string]string{}
+
+	for _, b := range blocks {
+		var key string
+		var job downloadJob
+		// the block is recorded so an expired URL can be refreshed.
+		blockOpts := opts
+		blockOpts.blockID = b.GetID().String()
+		switch in := b.(type) {
+		case *na.ImageBlock:
+			if opts.IgnoreImages || opts.KeepRemoteImageURLs || config.Overrides.Image != nil ||
+				in.Image.External != nil || in.Image.File == nil {
+				continue
+			}
+			// names are claimed here, in the order the images appear, so
+			// names derived from captions don't depend on which download
+			// finishes first.
+			address := in.Image.File.URL
+			name := claimImageBlockName(in, opts)
+			key = "image\n" + address + "\n" + name
+			job = func() (string, error) { return saveNotionImage(address, name, blockOpts) }
+		case *na.FileBlock:
+			if config.Overrides.File != nil || in.File.External != nil || in.File.File == nil {
+				continue
+			}
+			address := in.File.File.URL
+			key = "file\n" + address
+			job = func() (string, error) { return SaveNotionFileToFilesystem(address, blockOpts) }
+		case *na.VideoBlock:
+			if config.Overrides.Video != nil || in.Video.External != nil || in.Video.File == nil {
+				continue
+			}
+			address := in.Video.File.URL
+			key = "file\n" + address
+			job = func() (string, error) { return SaveNotionFileToFilesystem(address, blockOpts) }
+		case *AudioBlock:
+			if config.Overrides.Audio != nil || in.Audio.External != nil || in.Audio.File == nil {
+				continue
+			}
+			address := in.Audio.File.URL
+			key = "file\n" + address
+			job = func() (string, error) { return SaveNotionFileToFilesystem(address, blockOpts) }
+		default:
+			continue
+		}
+
+		if _, ok := jobs[key]; !ok {
+			jobs[key] = job
+			keys = append(keys, key)
+		}
+		blockKeys[b.GetID().String()] = key
+	}
+
+	results := runDownloadJobs(jobs, keys, opts.DownloadConcurrency)
+	prefetched := prefetchedDownloads{}
+	for id, key := range blockKeys {
+		prefetched[id] = results[key]
+	}
+
+	return prefetched
+}
+
+// runDownloadJobs runs the jobs named in keys using a pool of workers and
+// returns their results, keyed the same as jobs.
+func runDownloadJobs(jobs map[string]downloadJob, keys []string,
+	workers int) map[string]downloadResult {
+
+	if workers < 1 {
+		workers = 1
+	}
+	pending := make(chan string)
+	results := make(map[string]downloadResult, len(keys))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers && i < len(keys); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range pending {
+				path, err := jobs[key]()
+				mu.Lock()
+				results[key] = downloadResult{path, err}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, key := range keys {
+		pending <- key
+	}
+	close(pending)
+	wg.Wait()
+
+	return results
+}
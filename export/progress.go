@@ -0,0 +1,111 @@
+package export
+
+// This file contains the logic used to report the progress of an export, so
+// long running exports aren't silent.
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ProgressFunc is called as an export progresses, enabling callers to build
+// their own progress UI. blocksDone is the number of blocks rendered so far
+// and imagesDone the number of images, and other files, downloaded so far.
+// Counts start over with each Render or ExportDatabase call. It may be called
+// from multiple goroutines when ImageSaveOptions.DownloadConcurrency is
+// greater than 1, though never concurrently.
+type ProgressFunc func(blocksDone, imagesDone int)
+
+// progress tracks and reports the progress of an export. The zero value, or a
+// nil progress, reports nothing.
+type progress struct {
+	mu sync.Mutex
+	// log receives a line for each page fetched, batch of blocks retrieved,
+	// and file downloaded.
+	log    io.Writer
+	report ProgressFunc
+	blocks int
+	images int
+}
+
+// enabled returns whether progress is reported anywhere.
+func (p *progress) enabled() bool {
+	return p != nil && (p.log != nil || p.report != nil)
+}
+
+// reset starts the counts over for a new export.
+func (p *progress) reset() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocks, p.images = 0, 0
+}
+
+// logf writes a line, formatted according to format, to the log.
+func (p *progress) logf(format string, a ...interface{}) {
+	if p == nil || p.log == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.log, format+"\n", a...)
+}
+
+// blockDone counts a rendered block.
+func (p *progress) blockDone() {
+	if !p.enabled() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.blocks++
+	if p.report != nil {
+		p.report(p.blocks, p.images)
+	}
+}
+
+// fileDownloaded counts a file downloaded to filePath. It's safe to call from
+// the goroutines downloading files concurrently.
+func (p *progress) fileDownloaded(filePath string) {
+	p.countFile("Downloaded %s (%d downloaded)", filePath)
+}
+
+// filePlanned counts a file that would be downloaded to filePath in a dry
+// run. It's safe to call from the goroutines downloading files concurrently.
+func (p *progress) filePlanned(filePath string) {
+	p.countFile("Would download %s (%d to download)", filePath)
+}
+
+// countFile counts a file saved to filePath, logging it according to format.
+func (p *progress) countFile(format string, filePath string) {
+	if !p.enabled() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.images++
+	if p.log != nil {
+		fmt.Fprintf(p.log, format+"\n", filePath, p.images)
+	}
+	if p.report != nil {
+		p.report(p.blocks, p.images)
+	}
+}
+
+// withProgress returns config set up to report the files it downloads, or
+// would download in a dry run, to the exporter's progress.
+func (e *exporter) withProgress(config RenderOptions) RenderOptions {
+	if !e.progress.enabled() {
+		return config
+	}
+	report := e.progress.fileDownloaded
+	if config.ImageOpts.DryRun {
+		report = e.progress.filePlanned
+	}
+	// a dry run also records the file in the exporter's DryRunReport.
+	config.ImageOpts.downloaded = chainDownloaded(config.ImageOpts.downloaded, report)
+	return config
+}
@@ -0,0 +1,41 @@
+package export
+
+// This file contains helpers flattening a page's properties into plain values,
+// so overrides can use them without type asserting each property.
+
+import (
+	na "github.com/jomei/notionapi"
+)
+
+// ResolveProperties returns the properties of p, keyed by their name, as
+// strings. Text is returned as plain text, options and users by their names
+// (comma separated when there are many), numbers and checkboxes in their
+// shortest form (e.g. 1.5 or true), and dates as 2022-10-06, or RFC3339 when
+// they have a time. A date range is returned as "start → end". Properties
+// without a value are omitted.
+func ResolveProperties(p *na.Page) map[string]string {
+	props := map[string]string{}
+	for name, v := range ResolvePropertyValues(p) {
+		props[name] = formatPropertyValue(v)
+	}
+	return props
+}
+
+// ResolvePropertyValues returns the properties of p, keyed by their name, as
+// native values: a string for text, single options, users, URLs, and dates; a
+// float64 for numbers; a bool for checkboxes; a []string for multiple options,
+// people, relations, and files; and a map[string]string holding "start" and
+// "end" for date ranges. These are the same values serialized by
+// RenderFrontmatter. Properties without a value are omitted.
+func ResolvePropertyValues(p *na.Page) map[string]interface{} {
+	values := map[string]interface{}{}
+	if p == nil {
+		return values
+	}
+	for name, prop := range p.Properties {
+		if v := resolvePropertyValue(prop, ""); v != nil {
+			values[name] = v
+		}
+	}
+	return values
+}
@@ -0,0 +1,159 @@
+package export
+
+// This file contains the logic used to describe a rendered page, so callers
+// can index exported pages without parsing the output.
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	// defaultWordsPerMinute is the reading speed reading times are estimated
+	// at, which is typical for adults reading on a screen.
+	defaultWordsPerMinute = 200
+	// readingTimeKey is the frontmatter key RenderOptions.ReadingTime adds.
+	readingTimeKey = "reading_time"
+)
+
+// RenderResult describes a page rendered by RenderWithResult. When
+// RenderOptions.RecursePages is set, WordCount, Images, and UnsupportedBlocks
+// cover every page in the export.
+type RenderResult struct {
+	// Title is the title of the page.
+	Title string
+	// CreatedBy and LastEditedBy are the names of the users who created and
+	// last edited the page. They're only set with
+	// RenderOptions.ResolveUserNames.
+	CreatedBy    string
+	LastEditedBy string
+	// WordCount is the number of words in the text of the rendered blocks,
+	// including captions and table cells. Code is only counted when
+	// RenderOptions.ExcludeCodeFromWordCount isn't set.
+	WordCount int
+	// Images are the paths images and files hosted in Notion were downloaded
+	// to, in the order they were downloaded. Files already saved, which
+	// weren't downloaded again, are left out. In a dry run, these are the
+	// paths they would be downloaded to.
+	Images []string
+	// UnsupportedBlocks is the number of blocks of each type left out of the
+	// page as they aren't supported. See UnsupportedBlocks.
+	UnsupportedBlocks map[string]int
+}
+
+// renderResult records the RenderResult of a page rendered by
+// RenderWithResult. A nil renderResult records nothing.
+type renderResult struct {
+	mu sync.Mutex
+	// pageID is the page described, whose title is recorded.
+	pageID string
+	result RenderResult
+}
+
+// pageFetched records the title of p when pageID is the page described.
+func (r *renderResult) pageFetched(pageID string, p *na.Page) {
+	if r == nil || pageID != r.pageID {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Title = ResolveTitleInPage(p)
+}
+
+// usersResolved records the names of the users who created and last edited
+// the page pageID when it's the page described.
+func (r *renderResult) usersResolved(pageID string, createdBy, lastEditedBy string) {
+	if r == nil || pageID != r.pageID {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.CreatedBy = createdBy
+	r.result.LastEditedBy = lastEditedBy
+}
+
+// wordsRendered counts words rendered.
+func (r *renderResult) wordsRendered(words int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.WordCount += words
+}
+
+// fileDownloaded records a file downloaded to filePath. It's safe to call from
+// the goroutines downloading files concurrently.
+func (r *renderResult) fileDownloaded(filePath string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.result.Images = append(r.result.Images, filePath)
+}
+
+// countWords returns the number of words in the plain text of rt.
+func countWords(rt []na.RichText) int {
+	return len(strings.Fields(richTextToPlain(rt)))
+}
+
+// resolveReadingTime returns the minutes it takes to read words at
+// wordsPerMinute, rounded up. When wordsPerMinute isn't positive,
+// defaultWordsPerMinute is used.
+func resolveReadingTime(words, wordsPerMinute int) int {
+	if wordsPerMinute < 1 {
+		wordsPerMinute = defaultWordsPerMinute
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// RenderWithResult is the same as Render, except a RenderResult describing
+// the page is returned alongside its bytes. See the Render API docs for
+// details on arguments and behavior.
+func (e *exporter) RenderWithResult(pageID string,
+	opts ...RenderOptions) ([]byte, RenderResult, error) {
+
+	return e.RenderWithResultContext(context.Background(), pageID, opts...)
+}
+
+// RenderWithResultContext is the same as RenderWithResult, except ctx is
+// passed to every call made to the Notion API. See the Render API docs for
+// details on arguments and behavior.
+func (e *exporter) RenderWithResultContext(ctx context.Context, pageID string,
+	opts ...RenderOptions) ([]byte, RenderResult, error) {
+
+	e.result = &renderResult{pageID: pageID}
+	defer func() { e.result = nil }()
+
+	out, err := e.RenderContext(ctx, pageID, opts...)
+	result := e.result.result
+	result.UnsupportedBlocks = e.UnsupportedBlocks()
+	return out, result, err
+}
+
+// withResult returns config, when a RenderResult is being recorded, set up to
+// record the files it downloads.
+func (e *exporter) withResult(config RenderOptions) RenderOptions {
+	if e.result == nil {
+		return config
+	}
+	config.ImageOpts.downloaded = chainDownloaded(config.ImageOpts.downloaded,
+		e.result.fileDownloaded)
+	return config
+}
+
+// chainDownloaded returns a function calling first, when it's set, and then
+// then with the path of each file downloaded.
+func chainDownloaded(first, then func(filePath string)) func(filePath string) {
+	if first == nil {
+		return then
+	}
+	return func(filePath string) {
+		first(filePath)
+		then(filePath)
+	}
+}
@@ -0,0 +1,73 @@
+package export
+
+// This file contains the logic used to retry requests to the Notion API that
+// were rate limited or failed on Notion's side.
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultMaxAPIRetries is the number of times a Notion API request is
+	// retried after a 429 or 5xx response when MaxAPIRetries isn't set.
+	defaultMaxAPIRetries = 3
+)
+
+// retryTransport is an http.RoundTripper that retries requests when the
+// response is a 429 or 5xx. It waits as long as the Retry-After header asks
+// or, when it's absent, backs off exponentially.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+}
+
+// newRetryTransport returns a retryTransport wrapping base. maxRetries of 0
+// uses the default of 3, while a negative value disables retries.
+func newRetryTransport(base http.RoundTripper, maxRetries int) *retryTransport {
+	if maxRetries == 0 {
+		maxRetries = defaultMaxAPIRetries
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &retryTransport{base: base, maxRetries: maxRetries}
+}
+
+// RoundTrip sends req, retrying it as described on retryTransport. Retries
+// stop early when the request's context is done.
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		// the body was consumed by the previous attempt.
+		if attempt > 0 && req.Body != nil && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.base.RoundTrip(attemptReq)
+		if err != nil || !isRetryableStatus(resp.StatusCode) || attempt >= t.maxRetries {
+			return resp, err
+		}
+		// a body that can't be sent again can't be retried.
+		if req.Body != nil && req.GetBody == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		timer := time.NewTimer(resolveRetryDelay(resp.Header.Get("Retry-After"), attempt))
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
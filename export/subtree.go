@@ -0,0 +1,103 @@
+package export
+
+// This file contains the logic used to export a single block and the blocks
+// nested in it, rather than an entire page.
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+
+	na "github.com/jomei/notionapi"
+)
+
+// RenderBlockSubtree retrieves the block blockID and renders it along with
+// every block nested in it, such as a toggle and its content, returning a
+// []byte representation of them. When blockID is a page, its blocks are
+// rendered. The block is rendered at the top level, without the header of the
+// page it's in.
+//
+// The Notion client can't retrieve the page a block is in, so renderers are
+// passed a page with the block's ID and no title or properties. Frontmatter,
+// RecursePages, and EditedSince are ignored. See the Render API docs for
+// details on the remaining arguments and behavior.
+func (e *exporter) RenderBlockSubtree(blockID string, opts ...RenderOptions) ([]byte, error) {
+	return e.RenderBlockSubtreeContext(context.Background(), blockID, opts...)
+}
+
+// RenderBlockSubtreeContext is the same as RenderBlockSubtree, except ctx is
+// passed to every call made to the Notion API. See the RenderBlockSubtree API
+// docs for details on arguments and behavior.
+func (e *exporter) RenderBlockSubtreeContext(ctx context.Context, blockID string,
+	opts ...RenderOptions) ([]byte, error) {
+
+	e.dryRun = nil
+	e.downloads = nil
+	config := e.withDownloads(e.withProgress(e.withResult(e.withDryRun(resolveRenderConfig(opts...)))))
+	e.unsupported = nil
+	e.skipped = nil
+	e.linkTitles = nil
+	e.progress.reset()
+
+	b, err := e.c.Block.Get(ctx, na.BlockID(blockID))
+	if err != nil {
+		if clientErr := resolveClientError("block", blockID, err); clientErr != nil {
+			return nil, clientErr
+		}
+		return nil, fmt.Errorf("Failed getting Notion block (%s), "+
+			"error from client: %s", blockID, err)
+	}
+	b = e.audio.resolveBlock(b)
+
+	var buf bytes.Buffer
+	e.w = &buf
+	e.pageWords = 0
+	config.headingSlugs = headingSlugs{}
+	page := &na.Page{Object: na.ObjectTypePage, ID: na.ObjectID(b.GetID())}
+	config.originalPageRef = page
+	config.ImageOpts.page = page
+	config.ImageOpts.refreshURL = e.refreshFileURL(ctx)
+	config.ImageOpts.ctx = ctx
+
+	// the header is still rendered, as renderers may rely on it to start a
+	// new page, but its output is discarded.
+	err = e.write(e.Renderer.RenderPageHeader(page, func(*na.Page) string { return "" }))
+	if err != nil {
+		return nil, err
+	}
+	// the content of a page is rendered in place of a link to it.
+	if b.GetType() == na.BlockTypeChildPage {
+		_, err = e.renderFullPage(ctx, blockID, "", config)
+	} else {
+		_, err = e.renderBlocks(ctx, blockID, &na.GetChildrenResponse{Results: []na.Block{b}},
+			config)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed rendering Notion block, error: %s", err)
+	}
+	err = e.write(e.Renderer.RenderPageFooter(page, e.resolveFooterOverride(page, config)))
+	if err != nil {
+		return nil, err
+	}
+	// without a header before it, the separation added before the first
+	// block is left out.
+	e.page = bytes.TrimLeft(buf.Bytes(), "\n")
+
+	return e.page, nil
+}
+
+// ParseBlockID extracts the UUID of a Notion block from s, which may be the
+// UUID itself, with or without dashes, or a link to the block copied from
+// Notion, such as
+// https://www.notion.so/Climbing-de4d2477f3214ec98614fd46a4e1487f#9a0fbd4c2b4c4a5b8f5b2e4c1d0a3b7e,
+// where the block's UUID follows the "#". A link without a "#" is treated as
+// a link to the page, whose UUID is returned. The UUID is returned as 32
+// lowercase characters without dashes. An error is returned when no UUID is
+// found.
+func ParseBlockID(s string) (string, error) {
+	if u, err := url.Parse(s); err == nil && u.Fragment != "" {
+		return ParsePageID(u.Fragment)
+	}
+	return ParsePageID(s)
+}
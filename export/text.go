@@ -0,0 +1,428 @@
+package export
+
+import (
+	"fmt"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	textListItemPattern = "- %s"
+	textNumItemPattern  = "%s %s"
+	textCodeIndent      = "    "
+	textTableCellSep    = "\t"
+)
+
+// TextRenderer renders a Notion page as plain text, with no markup, for uses
+// such as search indexing. Headings are emitted as plain lines, list items are
+// prefixed with "- " (or their number), code is indented, and table rows are
+// tab-separated. Images, files, and videos are replaced by their caption and
+// are never downloaded.
+type TextRenderer struct {
+}
+
+// RenderPageHeader for TextRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, it
+// defaults to returning the title of the page.
+func (t *TextRenderer) RenderPageHeader(page *na.Page,
+	o ...headerFooterOverride) string {
+
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return ResolveTitleInPage(page)
+}
+
+// RenderPageFooter for TextRenderer takes a client's custom pageOverrider
+// definition and returns its results. If a pageOverrider is not provided, a
+// blank footer is returned.
+func (t *TextRenderer) RenderPageFooter(page *na.Page, o ...headerFooterOverride) string {
+	// when an overrider is provided, use its render functionality.
+	if len(o) > 0 && o[0] != nil {
+		return o[0](page)
+	}
+
+	return ""
+}
+
+// RenderPageHeader1 for TextRenderer returns the text of the heading as a
+// plain line. If an override is provided, that function is run and returned
+// value is used instead.
+func (t *TextRenderer) RenderPageHeader1(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderPageHeader2 for TextRenderer returns the text of the heading as a
+// plain line. If an override is provided, that function is run and returned
+// value is used instead.
+func (t *TextRenderer) RenderPageHeader2(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderPageHeader3 for TextRenderer returns the text of the heading as a
+// plain line. If an override is provided, that function is run and returned
+// value is used instead.
+func (t *TextRenderer) RenderPageHeader3(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderParagraph for TextRenderer returns the text of the paragraph. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (t *TextRenderer) RenderParagraph(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderDivider for TextRenderer returns nothing, as dividers carry no text.
+// If an override is provided, that function is run and returned value is used
+// instead.
+func (t *TextRenderer) RenderDivider(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderNumberedList for TextRenderer returns the text of the item prepended
+// with its number in the list, e.g. "1. ", styled by
+// RenderOptions.OrderedListStyle. If an override is provided, that function is
+// run and returned value is used instead.
+func (t *TextRenderer) RenderNumberedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(textNumItemPattern, resolveListMarker(b), b.Text)
+}
+
+// RenderBulletedList for TextRenderer returns the text of the item prepended
+// with "- ". If an override is provided, that function is run and returned
+// value is used instead.
+func (t *TextRenderer) RenderBulletedList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(textListItemPattern, b.Text)
+}
+
+// RenderTodoList for TextRenderer returns the text of the item prepended with
+// "- ", regardless of whether it's checked. If an override is provided, that
+// function is run and returned value is used instead.
+func (t *TextRenderer) RenderTodoList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return fmt.Sprintf(textListItemPattern, b.Text)
+}
+
+// RenderCallout for TextRenderer returns the text of the callout, prefixed
+// with its icon when it's an emoji. If an override is provided, that function
+// is run and returned value is used instead.
+func (t *TextRenderer) RenderCallout(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// image icons are dropped rather than downloaded.
+	cb, ok := b.BlockRef.(*na.CalloutBlock)
+	if !ok || cb.Callout.Icon == nil || cb.Callout.Icon.Emoji == nil ||
+		resolveRenderConfig(b.Opts...).OmitCalloutIcons {
+		return b.Text
+	}
+	return string(*cb.Callout.Icon.Emoji) + " " + b.Text
+}
+
+// RenderQuote for TextRenderer returns the text of the quote. If an override
+// is provided, that function is run and returned value is used instead.
+func (t *TextRenderer) RenderQuote(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderCode for TextRenderer returns the code with every line indented, so
+// it stands apart from the surrounding prose. The caption, when present,
+// follows as a plain line. If an override is provided, that function is run
+// and returned value is used instead.
+func (t *TextRenderer) RenderCode(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	r := textCodeIndent + strings.ReplaceAll(b.Text, "\n", "\n"+textCodeIndent)
+	if caption := ResolveCodeCaption(b); caption != "" {
+		r += "\n" + caption
+	}
+
+	return r
+}
+
+// RenderImage for TextRenderer returns the image's caption, or nothing when
+// it has none. The image is not downloaded. If an override is provided, that
+// function is run and returned value is used instead.
+func (t *TextRenderer) RenderImage(b *Block, o ...imageOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return b.Text, nil
+}
+
+// RenderFile for TextRenderer returns the file's caption or, when it has
+// none, the name of the file. The file is not downloaded. If an override is
+// provided, that function is run and returned value is used instead.
+func (t *TextRenderer) RenderFile(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	fb, ok := b.BlockRef.(*na.FileBlock)
+	if !ok || b.Text != "" {
+		return b.Text, nil
+	}
+	if fb.File.External != nil {
+		return resolveFileName(fb.File.External.URL), nil
+	}
+	if fb.File.File != nil {
+		return resolveFileName(fb.File.File.URL), nil
+	}
+	return "", nil
+}
+
+// RenderVideo for TextRenderer returns the video's caption or, when it has
+// none and is external, its URL. The video is not downloaded. If an override
+// is provided, that function is run and returned value is used instead.
+func (t *TextRenderer) RenderVideo(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	vb, ok := b.BlockRef.(*na.VideoBlock)
+	if ok && b.Text == "" && vb.Video.External != nil {
+		return vb.Video.External.URL, nil
+	}
+	return b.Text, nil
+}
+
+// RenderAudio for TextRenderer returns the audio's caption or, when it has
+// none, the name of the audio file. The audio is not downloaded. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (t *TextRenderer) RenderAudio(b *Block, o ...fileOverride) (string, error) {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	ab, ok := b.BlockRef.(*AudioBlock)
+	if !ok || b.Text != "" {
+		return b.Text, nil
+	}
+	if ab.Audio.External != nil {
+		return resolveFileName(ab.Audio.External.URL), nil
+	}
+	if ab.Audio.File != nil {
+		return resolveFileName(ab.Audio.File.URL), nil
+	}
+	return "", nil
+}
+
+// RenderBookmark for TextRenderer returns the bookmark's caption or, when it
+// has none, the bookmarked URL. If an override is provided, that function is
+// run and returned value is used instead.
+func (t *TextRenderer) RenderBookmark(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	bb, ok := b.BlockRef.(*na.BookmarkBlock)
+	if ok && b.Text == "" {
+		return bb.Bookmark.URL
+	}
+	return b.Text
+}
+
+// RenderEquation for TextRenderer returns the LaTeX expression of the
+// equation as is. If an override is provided, that function is run and
+// returned value is used instead.
+func (t *TextRenderer) RenderEquation(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderChildPage for TextRenderer returns the title of the subpage. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (t *TextRenderer) RenderChildPage(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderChildDatabase for TextRenderer returns the title of the database. If
+// an override is provided, that function is run and returned value is used
+// instead.
+func (t *TextRenderer) RenderChildDatabase(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderLinkToPage for TextRenderer returns the title of the linked page or,
+// when it's unknown, the target of the link. If an override is provided, that
+// function is run and returned value is used instead.
+func (t *TextRenderer) RenderLinkToPage(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	if b.Text == "" {
+		return ResolveLinkToPageTarget(b)
+	}
+	return b.Text
+}
+
+// RenderColumnList for TextRenderer returns nothing, as the content of
+// columns is rendered sequentially. If an override is provided, that function
+// is run and returned value is used instead.
+func (t *TextRenderer) RenderColumnList(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderColumn for TextRenderer returns nothing, as the content of the column
+// is rendered sequentially. If an override is provided, that function is run
+// and returned value is used instead.
+func (t *TextRenderer) RenderColumn(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderTemplate for TextRenderer returns the label of a template button,
+// introducing the template's content, which is rendered after it. If an
+// override is provided, that function is run and returned value is used
+// instead.
+func (t *TextRenderer) RenderTemplate(b *Block, o ...blockOverride) string {
+	return t.renderPlain(b, o...)
+}
+
+// RenderUnsupported for TextRenderer returns nothing, as plain text has no way
+// to mark content that was left out. If an override is provided, that function
+// is run and returned value is used instead.
+func (t *TextRenderer) RenderUnsupported(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// RenderTableRow for TextRenderer returns the text of each cell separated by
+// tabs. Header rows are not distinguished from other rows.
+func (t *TextRenderer) RenderTableRow(cells []tableCell, o ...rowOverride) string {
+	// when a rowOverride function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](cells)
+	}
+
+	var row []string
+	for _, c := range cells {
+		// a row can't span lines.
+		row = append(row, strings.ReplaceAll(c.rowTxt, "\n", " "))
+	}
+	return strings.Join(row, textTableCellSep)
+}
+
+// RenderText for TextRenderer returns the content of the RichText with all
+// formatting, including links, dropped.
+func (t *TextRenderer) RenderText(rt []na.RichText, o ...richTextOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](rt)
+	}
+
+	var parsed string
+	for _, r := range rt {
+		switch r.Type {
+		// the Notion API sets the plain text of an equation to its LaTeX
+		// expression.
+		case "equation":
+			parsed += r.PlainText
+		case "mention":
+			parsed += resolveMentionText(r)
+		default:
+			parsed += r.Text.Content
+		}
+	}
+
+	return parsed
+}
+
+// AddPadding for TextRenderer indents each line of the Block's text based on
+// its depth, the same as MDRenderer.
+func (t *TextRenderer) AddPadding(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	// when at root (depth: 0) do no padding processing
+	if b.Depth == 0 || b.Text == "" {
+		return b.Text
+	}
+
+	padding := createPadding(b.Depth, resolveRenderConfig(b.Opts...))
+
+	return padding + strings.ReplaceAll(b.Text, "\n", "\n"+padding)
+}
+
+// AddBlockEnd for TextRenderer returns nothing, as plain text has no closing
+// syntax. If an override is provided, that function is run and returned value
+// is used instead.
+func (t *TextRenderer) AddBlockEnd(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return ""
+}
+
+// AddSectionSeperation for TextRenderer separates blocks the same as
+// MDRenderer: consecutive list items and table rows by a single line break,
+// and everything else by a blank line.
+func (t *TextRenderer) AddSectionSeperation(previousType string, currentType string,
+	o ...seperationOverride) string {
+
+	return (&MDRenderer{}).AddSectionSeperation(previousType, currentType, o...)
+}
+
+// renderPlain returns the Block's text as is. If an override is provided, that
+// function is run and returned value is used instead.
+func (t *TextRenderer) renderPlain(b *Block, o ...blockOverride) string {
+	// when an override function is passed, call it and return its output
+	if len(o) > 0 && o[0] != nil {
+		return o[0](b)
+	}
+
+	return b.Text
+}
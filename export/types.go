@@ -1,6 +1,8 @@
 package export
 
 import (
+	"io"
+
 	na "github.com/jomei/notionapi"
 )
 
@@ -123,6 +125,76 @@ type Renderer interface {
 	// a Renderer implementation should be able to download and save the image
 	// to the local filesystem.
 	RenderImage(*Block, ...imageOverride) (string, error)
+	// RenderFile receives the file's caption, which has been run through
+	// RenderText, and a reference to the original FileBlock object. It
+	// returns a string representation of how the file should be referenced.
+	// Like RenderImage, it must handle both external files and files hosted
+	// within Notion, which should be downloaded to the local filesystem.
+	RenderFile(*Block, ...fileOverride) (string, error)
+	// RenderBookmark receives the bookmark's caption, which has been run
+	// through RenderText, and a reference to the original BookmarkBlock
+	// object. It returns the string representation of the bookmark, which is
+	// typically a link to the bookmarked URL.
+	RenderBookmark(*Block, ...blockOverride) string
+	// RenderEquation receives the LaTeX expression of the equation as its
+	// text and a reference to the original EquationBlock object. It returns
+	// the string representation of the block-level equation. Inline
+	// equations are handled by RenderText.
+	RenderEquation(*Block, ...blockOverride) string
+	// RenderChildPage receives the title of a subpage and a reference to the
+	// original ChildPageBlock object. It's only called when
+	// RenderOptions.RecursePages is set, in which case the subpage is
+	// exported to a file of its own. It returns the string representation of
+	// a link to that file, whose name is resolved with
+	// ResolveChildPageFile.
+	RenderChildPage(*Block, ...blockOverride) string
+	// RenderChildDatabase receives the title of a database embedded in the
+	// page and a reference to the original ChildDatabaseBlock object. It
+	// returns the string representation of the database, typically a link
+	// to it in Notion, whose URL is resolved with ResolveChildDatabaseURL.
+	// When RenderOptions.InlineChildDatabases is set, the database's rows
+	// are rendered as a table after it.
+	RenderChildDatabase(*Block, ...blockOverride) string
+	// RenderLinkToPage receives the title of the page linked to by a
+	// link_to_page block, which is empty when the page couldn't be
+	// retrieved, and a reference to the original LinkToPageBlock object. It
+	// returns the string representation of a link to the page, whose target
+	// is resolved with ResolveLinkToPageTarget.
+	RenderLinkToPage(*Block, ...blockOverride) string
+	// RenderVideo receives the video's caption, which has been run through
+	// RenderText, and a reference to the original VideoBlock object. Like
+	// RenderFile, it must handle both external videos (e.g. YouTube) and
+	// videos hosted within Notion, which should be downloaded to the local
+	// filesystem. When RenderOptions.EmbedVideos is set, renderers that can
+	// should embed the video rather than link to it.
+	RenderVideo(*Block, ...fileOverride) (string, error)
+	// RenderAudio receives the audio's caption, which has been run through
+	// RenderText, and a reference to the original AudioBlock object. Like
+	// RenderFile, it must handle both external audio and audio hosted within
+	// Notion, which should be downloaded to the local filesystem.
+	RenderAudio(*Block, ...fileOverride) (string, error)
+	// RenderColumnList receives a reference to the original ColumnListBlock
+	// object. Its children, column blocks, are rendered after it at the same
+	// depth. It returns anything that should open the list of columns, such
+	// as an HTML container. AddBlockEnd is called once the columns are
+	// rendered, to close anything opened here.
+	RenderColumnList(*Block, ...blockOverride) string
+	// RenderColumn receives a reference to the original ColumnBlock object.
+	// Its children, the column's content, are rendered after it at the same
+	// depth. It returns anything that should open the column. AddBlockEnd is
+	// called once the column's content is rendered.
+	RenderColumn(*Block, ...blockOverride) string
+	// RenderTemplate receives the label of a template button, which has been
+	// run through RenderText, and a reference to the original TemplateBlock
+	// object. Its children, the content the button duplicates, are rendered
+	// after it at the same depth. It returns the string representation of
+	// the label, introducing that content.
+	RenderTemplate(*Block, ...blockOverride) string
+	// RenderUnsupported receives a reference to a Block whose type isn't
+	// supported. Its children, if any, are still rendered after it. It
+	// returns a placeholder for the block when
+	// RenderOptions.MarkUnsupportedBlocks is set, otherwise an empty string.
+	RenderUnsupported(*Block, ...blockOverride) string
 
 	// RenderTableRow receives a list of cells that contain text that has been
 	// run through ParseText and metadata around the table the row belongs to.
@@ -144,6 +216,12 @@ type Renderer interface {
 	// implementations of AddPadding must calculate how many spaces (or tabs)
 	// should be prefixed and return that representation to the caller.
 	AddPadding(*Block, ...blockOverride) string
+	// AddBlockEnd is called after the children of a Block have been
+	// rendered. It receives a reference to the original parent Block object
+	// and returns anything that should close the parent, such as a closing
+	// HTML tag for a column. Most blocks don't need closing, in which case an
+	// empty string is returned.
+	AddBlockEnd(*Block, ...blockOverride) string
 	// AddSectionSeperation is responsible for adding additional seperation
 	// (often linebreaks) based on what the previous type was. For example. If
 	// the previousType was a list element and current type is a list element,
@@ -155,10 +233,80 @@ type Renderer interface {
 		o ...seperationOverride) string
 }
 
+// CommentRenderer is implemented by renderers whose format has comments. The
+// exporter uses RenderComment for notes that aren't part of the page's
+// content, such as the IDs embedded with RenderOptions.EmbedBlockIDs.
+// Renderers that don't implement it, such as TextRenderer, get no such notes.
+type CommentRenderer interface {
+	// RenderComment returns text as a comment, e.g. <!-- text --> in HTML.
+	RenderComment(text string) string
+}
+
+// StructuredRenderer is implemented by renderers, such as JSONRenderer, that
+// build a structured document rather than text. The exporter records the
+// page's frontmatter and footer in the document instead of rendering them,
+// and doesn't separate its blocks with RenderOptions.Separation.
+type StructuredRenderer interface {
+	// SetFrontmatter records the frontmatter of the page, keyed as in the
+	// frontmatter of other formats (see RenderFrontmatter).
+	SetFrontmatter(fields map[string]interface{})
+	// SetProvenance records the URL of the page in Notion and the time it
+	// was exported, for RenderOptions.FooterSourceLink and
+	// RenderOptions.FooterTimestamp. Either may be empty.
+	SetProvenance(source, exported string)
+}
+
+// QuoteRenderer is implemented by renderers that can render the blocks nested
+// in a quote within the quote, for RenderOptions.QuoteChildren.
+type QuoteRenderer interface {
+	// RenderQuotedChildren receives the rendered children of a quote and
+	// returns them quoted, continuing the quote they're nested in.
+	RenderQuotedChildren(text string) string
+}
+
 type exporter struct {
-	c        *na.Client
-	page     []byte
+	c    *na.Client
+	page []byte
+	// w is where rendered blocks are written as they're produced.
+	w        io.Writer
 	Renderer Renderer
+	// unsupported counts the blocks, by type, left out of the export as they
+	// aren't supported.
+	unsupported map[string]int
+	// skipped are the IDs of the pages left out of the export as they weren't
+	// edited since RenderOptions.EditedSince.
+	skipped []string
+	// root is the page most recently exported with RenderTo and rootTitle
+	// its title, once retrieved, for ExportedTitle.
+	root      string
+	rootTitle string
+	// linkTitles caches the titles of pages linked to, keyed by page ID, for
+	// RenderOptions.ResolveInternalLinkTitles. Pages that couldn't be
+	// retrieved are cached with an empty title.
+	linkTitles map[string]string
+	// userNames caches the names of users, keyed by user ID, for
+	// RenderOptions.ResolveUserNames. Unlike linkTitles, it's kept across
+	// exports.
+	userNames map[na.UserID]string
+	// progress reports the progress of exports, when enabled with
+	// ExporterOptions.Logger or ExporterOptions.Progress.
+	progress *progress
+	// dryRun records what exports made with RenderOptions.DryRun would
+	// download and write.
+	dryRun *dryRun
+	// result records the RenderResult of the page rendered by
+	// RenderWithResult.
+	result *renderResult
+	// downloads is the state of the images and files downloaded during the
+	// current export, which RenderAppend continues.
+	downloads *downloads
+	// audio decodes the audio blocks retrieved with c. It's nil when c was
+	// set with ExporterOptions.Client, in which case audio blocks are left
+	// out as unsupported.
+	audio *audioTransport
+	// pageWords is the number of words in the text rendered for the page
+	// being rendered, used for its reading time.
+	pageWords int
 }
 
 type Block struct {
@@ -173,11 +321,40 @@ type Block struct {
 
 type ExporterOptions struct {
 	NotionToken string
-	ClientOpts  na.ClientOption
+	// Profile selects the profile, in ${HOME}/.config/nexp.yaml, whose token
+	// is used when NotionToken isn't set. It takes precedence over the
+	// NOTION_TOKEN environment variable.
+	Profile string
+	// ClientOpts is applied when the Notion API client is created. Setting
+	// an HTTP client with it (na.WithHTTPClient) replaces the client that
+	// retries rate limited requests and decodes audio blocks, so
+	// MaxAPIRetries is ignored and audio blocks are left out as unsupported.
+	ClientOpts na.ClientOption
+	// The optional Notion API client to be used in the exporter. This is
+	// useful for pointing the exporter at a mock Notion API in tests. When
+	// this is set, NotionToken and ClientOpts are ignored and no token
+	// resolution occurs. Audio blocks, which the client can't decode, are
+	// left out as unsupported.
+	Client *na.Client
 	// The desired format used to create the appropraite renderer for the exporter.
+	// When empty, markdown is used. See NewRenderer for the formats known.
 	Format string
 	// The optional renderer instance to be used in the exporter. This acts as
 	// a full override for injecting a custom renderer into an exporter. When
 	// this is set, the Format option is ignored.
 	Renderer Renderer
+	// MaxAPIRetries is how many times a request to the Notion API is retried
+	// when it responds with a 429 (rate limited) or 5xx, waiting as long as
+	// its Retry-After header asks, up to a minute, or backing off
+	// exponentially. When not
+	// set, the default is 3. A negative value disables retries. It's ignored
+	// when Client is set.
+	MaxAPIRetries int
+	// Logger, when set, receives a line for each page fetched, batch of
+	// blocks retrieved, and image or file downloaded during an export. When
+	// not set, exports are silent.
+	Logger io.Writer
+	// Progress, when set, is called each time a block is rendered or a file
+	// is downloaded. See ProgressFunc.
+	Progress ProgressFunc
 }
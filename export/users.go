@@ -0,0 +1,66 @@
+package export
+
+// This file contains the logic used to resolve the users who created and last
+// edited a page to their names, as Notion only references them by ID.
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	// createdByKey and lastEditedByKey are the frontmatter keys
+	// RenderOptions.ResolveUserNames adds.
+	createdByKey    = "created_by"
+	lastEditedByKey = "last_edited_by"
+	// deletedUserName names users Notion no longer knows of, such as those
+	// removed from the workspace.
+	deletedUserName = "Deleted user"
+	// unnamedBotName names bots, such as integrations, without a name.
+	unnamedBotName = "Bot"
+)
+
+// resolveUserName returns the name of the user u, retrieving it from the
+// Notion API only the first time the user is seen by the exporter, as names
+// rarely change. Notion only includes the user's ID in pages, so u's name is
+// typically empty. Users that were deleted are named deletedUserName and bots
+// without a name are named unnamedBotName. An empty string is returned when
+// the user can't be retrieved otherwise, such as when the integration lacks
+// the capability to read user information.
+func (e *exporter) resolveUserName(ctx context.Context, u na.User) string {
+	if u.ID == "" {
+		return ""
+	}
+	if name, ok := e.userNames[u.ID]; ok {
+		return name
+	}
+	if e.userNames == nil {
+		e.userNames = map[na.UserID]string{}
+	}
+	if u.Name == "" {
+		user, err := e.c.User.Get(ctx, u.ID)
+		var apiErr *na.Error
+		switch {
+		case errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound:
+			u.Name = deletedUserName
+		case err != nil:
+			e.progress.logf("Failed getting user %s, error from client: %s", u.ID, err)
+		default:
+			u = *user
+		}
+	}
+	if u.Name == "" && u.Type == na.UserTypeBot {
+		u.Name = unnamedBotName
+	}
+	e.userNames[u.ID] = u.Name
+	return u.Name
+}
+
+// resolvePageUserNames returns the names of the users who created and last
+// edited p. See resolveUserName.
+func (e *exporter) resolvePageUserNames(ctx context.Context, p *na.Page) (createdBy, lastEditedBy string) {
+	return e.resolveUserName(ctx, p.CreatedBy), e.resolveUserName(ctx, p.LastEditedBy)
+}
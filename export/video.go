@@ -0,0 +1,80 @@
+package export
+
+// This file contains the logic used to embed videos from common providers,
+// shared by renderers that can emit HTML.
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"path"
+	"strings"
+
+	na "github.com/jomei/notionapi"
+)
+
+const (
+	htmlVideoIframePattern = "<iframe src=\"%s\" allowfullscreen></iframe>"
+	htmlVideoPattern       = "<video src=\"%s\" controls></video>"
+	youtubeEmbedURL        = "https://www.youtube.com/embed/"
+	vimeoEmbedURL          = "https://player.vimeo.com/video/"
+)
+
+// resolveVideoBlockPath returns the location a video block should be linked
+// to. For external videos, this is the URL of the video. For Notion-hosted
+// videos, the video is downloaded and this is its path on the local
+// filesystem.
+func resolveVideoBlockPath(vb *na.VideoBlock, opts ImageSaveOptions) (string, error) {
+	return resolveBlockSourcePath(vb, vb.Video.File, vb.Video.External, opts)
+}
+
+// resolveVideoEmbed returns the HTML used to embed the video in vb, located at
+// filePath. Videos from known providers are embedded with an <iframe>, while
+// videos hosted in Notion, which were downloaded, are embedded with a <video>
+// element. false is returned for other external videos, which can't be
+// reliably embedded.
+func resolveVideoEmbed(vb *na.VideoBlock, filePath string) (string, bool) {
+	if embedURL, ok := resolveVideoEmbedURL(filePath); ok {
+		return fmt.Sprintf(htmlVideoIframePattern, html.EscapeString(embedURL)), true
+	}
+	if vb.Video.External == nil {
+		return fmt.Sprintf(htmlVideoPattern, html.EscapeString(filePath)), true
+	}
+	return "", false
+}
+
+// resolveVideoEmbedURL returns the URL used to embed the video at address in
+// an <iframe>. YouTube (youtube.com/watch?v=, youtu.be, and youtube.com/shorts)
+// and Vimeo URLs are supported. false is returned when address isn't from a
+// known provider.
+func resolveVideoEmbedURL(address string) (string, bool) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	switch host {
+	case "youtube.com", "m.youtube.com":
+		if id := u.Query().Get("v"); id != "" {
+			return youtubeEmbedURL + id, true
+		}
+		if len(segments) == 2 && (segments[0] == "embed" || segments[0] == "shorts") {
+			return youtubeEmbedURL + segments[1], true
+		}
+	case "youtu.be":
+		if segments[0] != "" {
+			return youtubeEmbedURL + segments[0], true
+		}
+	case "vimeo.com":
+		id := path.Base(u.Path)
+		if id != "" && strings.Trim(id, "0123456789") == "" {
+			return vimeoEmbedURL + id, true
+		}
+	case "player.vimeo.com":
+		return address, true
+	}
+
+	return "", false
+}